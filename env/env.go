@@ -1,6 +1,8 @@
 package env
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
 )
@@ -18,20 +20,136 @@ type E struct {
 	DBDialect string `env:"DIALECT_DB" envDefault:"sqlite3"`                   // sqlite3 or postgres
 	DBURL     string `env:"DB_URL" envDefault:"file:data.db?_foreign_keys=on"` // "postgres://<user>:<pass>@<host>:<port>/<DB>?sslmode=disable
 
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" envDefault:"0"` // 0 = unlimited
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" envDefault:"0"` // 0 = database/sql default (2)
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"0"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"0"`
+	DBStmtCacheSize   int           `env:"DB_STMT_CACHE_SIZE" envDefault:"0"` // prepared statements kept per connection, 0 disables
+
+	DeviceStoreCacheEnabled bool `env:"DEVICE_STORE_CACHE_ENABLED" envDefault:"false"` // in-memory cache for signal identities and sessions
+	DeviceStoreCacheSize    int  `env:"DEVICE_STORE_CACHE_SIZE" envDefault:"10000"`    // entries per device and store, 0 = unbounded
+
+	BootRetryAttempts int           `env:"BOOT_RETRY_ATTEMPTS" envDefault:"5"`  // attempts to load devices from DB at boot
+	BootRetryDelay    time.Duration `env:"BOOT_RETRY_DELAY" envDefault:"500ms"` // first retry delay, doubles each attempt
+
+	QrChannelRetryAttempts int           `env:"QR_CHANNEL_RETRY_ATTEMPTS" envDefault:"3"`  // attempts to open the QR channel before emitting qrcode.error
+	QrChannelRetryDelay    time.Duration `env:"QR_CHANNEL_RETRY_DELAY" envDefault:"500ms"` // first retry delay, doubles each attempt
+
+	QRObserveTimeout time.Duration `env:"QR_OBSERVE_TIMEOUT" envDefault:"2m"`  // how long a login waits for the QR code to be scanned
+	QRPollTimeout    time.Duration `env:"QR_POLL_TIMEOUT" envDefault:"15s"`    // how long Connect waits for the first QR code
+	QRPollInterval   time.Duration `env:"QR_POLL_INTERVAL" envDefault:"200ms"` // how often Connect checks for the QR code
+
+	QrImageSize       int `env:"QR_IMAGE_SIZE" envDefault:"256"`      // width and height in pixels of QR code PNGs
+	QrImageModuleSize int `env:"QR_IMAGE_MODULE_SIZE" envDefault:"0"` // pixels per module, overrides QR_IMAGE_SIZE when set
+	QrImageQuietZone  int `env:"QR_IMAGE_QUIET_ZONE" envDefault:"4"`  // border in modules, scanners need at least 4
+
 	GCSEnabled bool   `env:"GCS_ENABLED" envDefault:"false"`
 	GCSBucket  string `env:"GCS_BUCKET" envDefault:"whatsmiau"`
 	GCSURL     string `env:"GCS_URL" envDefault:"https://storage.googleapis.com"`
 
+	S3Enabled   bool   `env:"S3_ENABLED" envDefault:"false"`
+	S3Bucket    string `env:"S3_BUCKET" envDefault:"whatsmiau"`
+	S3Endpoint  string `env:"S3_ENDPOINT" envDefault:"s3.amazonaws.com"` // host[:port], ex: minio:9000
+	S3Region    string `env:"S3_REGION"`
+	S3AccessKey string `env:"S3_ACCESS_KEY"` // empty uses AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or the IAM role
+	S3SecretKey string `env:"S3_SECRET_KEY"`
+	S3UseSSL    bool   `env:"S3_USE_SSL" envDefault:"true"`
+	S3PathStyle bool   `env:"S3_PATH_STYLE" envDefault:"false"` // endpoint/bucket/key addressing, needed by MinIO
+	S3PublicURL string `env:"S3_PUBLIC_URL"`                    // prefix of media urls, ex: a CDN, default the bucket url
+
+	LocalStorageEnabled bool   `env:"LOCAL_STORAGE_ENABLED" envDefault:"false"`
+	LocalStoragePath    string `env:"LOCAL_STORAGE_PATH" envDefault:"./data/media"`
+	LocalStorageURL     string `env:"LOCAL_STORAGE_URL" envDefault:"http://localhost:8080/media"` // where LOCAL_STORAGE_PATH is served, whatsmiau serves it at /media
+
 	GCL          string `json:"GCL_APP_NAME" envDefault:"whatsmiau-br-1"`
 	GCLEnabled   bool   `json:"GCL_ENABLED" envDefault:"false"`
 	GCLProjectID string `json:"GCL_PROJECT_ID"`
 
-	EmitterBufferSize    int `env:"EMITTER_BUFFER_SIZE" envDefault:"2048"`
-	HandlerSemaphoreSize int `env:"HANDLER_SEMAPHORE_SIZE" envDefault:"512"`
+	EmitterBufferSize    int           `env:"EMITTER_BUFFER_SIZE" envDefault:"2048"`
+	HandlerSemaphoreSize int           `env:"HANDLER_SEMAPHORE_SIZE" envDefault:"512"`
+	HandlerDrainTimeout  time.Duration `env:"HANDLER_DRAIN_TIMEOUT" envDefault:"10s"` // max wait for in-flight handlers on disconnect/logout
+	ShutdownTimeout      time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"25s"`      // max wait for clients and queued webhooks on SIGTERM
+
+	EmitterMaxPayloadSize int `env:"EMITTER_MAX_PAYLOAD_SIZE" envDefault:"0"` // bytes, bigger events are split or truncated, 0 = unbounded
+
+	EmitterLatencyWarnThreshold time.Duration `env:"EMITTER_LATENCY_WARN_THRESHOLD" envDefault:"10s"` // 0 disables the warning
+	MetricsInstanceLabels       bool          `env:"METRICS_INSTANCE_LABELS" envDefault:"false"`      // true labels the series with the instance id, /metrics is served without apikey
+
+	WebhookURL    string `env:"WEBHOOK_URL"`    // default webhook for instances without webhook.url
+	WebhookSecret string `env:"WEBHOOK_SECRET"` // default HMAC secret for instances without webhook.secret, empty = unsigned
+
+	WebhookBreakerThreshold int           `env:"WEBHOOK_BREAKER_THRESHOLD" envDefault:"5"` // consecutive failures before opening
+	WebhookBreakerCooldown  time.Duration `env:"WEBHOOK_BREAKER_COOLDOWN" envDefault:"1m"` // time open before trying again
+	DeadLetterMaxSize       int           `env:"DEAD_LETTER_MAX_SIZE" envDefault:"10000"`  // per instance, 0 = unbounded
+
+	WebhookMaxAttempts    int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"5"`      // deliveries tried before the event goes to the dead letter, 1 disables retries
+	WebhookRetryBaseDelay time.Duration `env:"WEBHOOK_RETRY_BASE_DELAY" envDefault:"1s"` // retry waits a random delay up to base * 2^(attempt-1)
+	WebhookRetryMaxDelay  time.Duration `env:"WEBHOOK_RETRY_MAX_DELAY" envDefault:"1m"`  // cap of the retry backoff window
+	WebhookRetryQueueSize int           `env:"WEBHOOK_RETRY_QUEUE_SIZE" envDefault:"50"` // per instance, events past it go to the dead letter
+
+	EmitFromMe      bool          `env:"EMIT_FROM_ME" envDefault:"true"`     // emit messages sent from the phone, instances can override with emitFromMe
+	AutoMarkRead    bool          `env:"AUTO_MARK_READ" envDefault:"false"`  // send read receipts for inbound messages, instances can override with autoMarkRead
+	SentMessagesTTL time.Duration `env:"SENT_MESSAGES_TTL" envDefault:"10m"` // how long sent ids are remembered to tag events with origin self, 0 disables
+
+	SendRateLimit     int           `env:"SEND_RATE_LIMIT" envDefault:"0"`        // messages per minute per instance, instances can override with sendRateLimit, 0 = unbounded
+	SendRateLimitWait time.Duration `env:"SEND_RATE_LIMIT_WAIT" envDefault:"10s"` // how long a send waits for the rate limit before failing with ErrRateLimited
+
+	HistorySyncTimeout time.Duration `env:"HISTORY_SYNC_TIMEOUT" envDefault:"2m"` // a chat accepts a new history request after the phone answers or this long
+
+	ScheduleBootDelay time.Duration `env:"SCHEDULE_BOOT_DELAY" envDefault:"10s"` // scheduled messages overdue on boot wait this long for the clients to log in
+
+	AutoDownloadMedia    bool          `env:"AUTO_DOWNLOAD_MEDIA" envDefault:"true"`          // fetch inbound media for webhooks, instances can override with autoDownloadMedia
+	MediaDownloadMaxSize uint64        `env:"MEDIA_DOWNLOAD_MAX_SIZE" envDefault:"104857600"` // bytes, bigger media isn't fetched, 0 = unbounded
+	MediaInlineMaxSize   uint64        `env:"MEDIA_INLINE_MAX_SIZE" envDefault:"5242880"`     // bytes, without a storage smaller media is sent as base64
+	MediaFetchMaxSize    uint64        `env:"MEDIA_FETCH_MAX_SIZE" envDefault:"104857600"`    // bytes, media sent by url can't be bigger, 0 = unbounded
+	MediaRetryTimeout    time.Duration `env:"MEDIA_RETRY_TIMEOUT" envDefault:"30s"`           // how long DownloadMedia waits for the sender to re-upload expired media
+
+	ReconnectBaseDelay   time.Duration `env:"RECONNECT_BASE_DELAY" envDefault:"2s"`  // reconnect waits a random delay up to base * 2^(attempt-1), 0 = immediately
+	ReconnectMaxDelay    time.Duration `env:"RECONNECT_MAX_DELAY" envDefault:"2m"`   // cap of the reconnect backoff window
+	ReconnectMaxAttempts int           `env:"RECONNECT_MAX_ATTEMPTS" envDefault:"0"` // reconnect gives up after this many attempts, 0 = unbounded
+
+	UndecryptableRequestFromPhone bool `env:"UNDECRYPTABLE_REQUEST_FROM_PHONE" envDefault:"false"` // also ask our phone for messages the sender didn't resend after the retry receipt
+
+	ConnectionDebounceWindow time.Duration `env:"CONNECTION_DEBOUNCE_WINDOW" envDefault:"5s"` // disconnects shorter than this aren't emitted, 0 disables
+
+	VerifiedNameLookup   bool          `env:"VERIFIED_NAME_LOOKUP" envDefault:"false"`  // look up senders without a verified name on the message
+	VerifiedNameCacheTTL time.Duration `env:"VERIFIED_NAME_CACHE_TTL" envDefault:"24h"` // 0 disables the cache
+
+	GroupInfoCacheTTL      time.Duration `env:"GROUP_INFO_CACHE_TTL" envDefault:"1m"`       // 0 disables the cache
+	NewsletterInfoCacheTTL time.Duration `env:"NEWSLETTER_INFO_CACHE_TTL" envDefault:"10m"` // 0 disables the cache
+	ProfilePictureCacheTTL time.Duration `env:"PROFILE_PICTURE_CACHE_TTL" envDefault:"10m"` // 0 disables the cache
+	PollCacheTTL           time.Duration `env:"POLL_CACHE_TTL" envDefault:"24h"`            // 0 disables the cache
+	PollCacheSize          int           `env:"POLL_CACHE_SIZE" envDefault:"10000"`         // polls across instances, 0 = unbounded
+	RecentMessageCacheTTL  time.Duration `env:"RECENT_MESSAGE_CACHE_TTL" envDefault:"1h"`   // 0 disables the cache
+	LidCacheTTL            time.Duration `env:"LID_CACHE_TTL" envDefault:"1h"`              // 0 disables the cache
+	LidCacheSize           int           `env:"LID_CACHE_SIZE" envDefault:"100000"`         // entries across instances, 0 = unbounded
+
+	GroupAutoJoinMaxGroups int `env:"GROUP_AUTO_JOIN_MAX_GROUPS" envDefault:"100"` // default cap for instances with groupAutoJoin, 0 = unbounded
+
+	AlwaysOnlineInterval time.Duration `env:"ALWAYS_ONLINE_INTERVAL" envDefault:"5m"` // available presence refresh for alwaysOnline instances, 0 = only on connect
+
+	ClockSkewWarnThreshold time.Duration `env:"CLOCK_SKEW_WARN_THRESHOLD" envDefault:"5s"` // 0 disables the warning
+
+	NumberExistsChunkSize    int           `env:"NUMBER_EXISTS_CHUNK_SIZE" envDefault:"50"`
+	NumberExistsConcurrency  int           `env:"NUMBER_EXISTS_CONCURRENCY" envDefault:"4"`
+	NumberExistsChunkTimeout time.Duration `env:"NUMBER_EXISTS_CHUNK_TIMEOUT" envDefault:"15s"`
+
+	GenerateLinkPreview bool          `env:"GENERATE_LINK_PREVIEW" envDefault:"false"` // attach a preview of the first url to sent texts, instances can override with generateLinkPreview
+	LinkPreviewTimeout  time.Duration `env:"LINK_PREVIEW_TIMEOUT" envDefault:"5s"`
+	LinkPreviewMaxBytes int64         `env:"LINK_PREVIEW_MAX_BYTES" envDefault:"524288"` // only the page head is needed
+
+	FetchAllowPrivate bool `env:"FETCH_ALLOW_PRIVATE" envDefault:"false"` // let link preview and media urls reach private and loopback addresses
+
+	StoreMessages       bool          `env:"STORE_MESSAGES" envDefault:"false"`         // keeps recent messages for dedup and reply-quoting
+	MessageStoreTTL     time.Duration `env:"MESSAGE_STORE_TTL" envDefault:"24h"`        // 0 = no expiration
+	MessageStoreMaxSize int           `env:"MESSAGE_STORE_MAX_SIZE" envDefault:"16384"` // bytes, bigger messages keep only metadata, 0 = unbounded
 
 	ProxyAddresses []string `env:"PROXY_ADDRESSES" envDefault:""`      // random choices proxies ex: <SOCKS5|HTTP|HTTPS>://<username>:<password>@<host>:<port>
 	ProxyStrategy  string   `env:"PROXY_STRATEGY" envDefault:"RANDOM"` // todo: implement BALANCED
 	ProxyNoMedia   bool     `env:"PROXY_NO_MEDIA" envDefault:"false"`
+
+	ProxyHealthInterval   time.Duration `env:"PROXY_HEALTH_INTERVAL" envDefault:"1m"`  // probe of the instances proxyPool, 0 disables
+	ProxyFailoverAttempts int           `env:"PROXY_FAILOVER_ATTEMPTS" envDefault:"3"` // failed reconnects before rotating to the next proxy of the pool, 0 disables
 }
 
 var Env E
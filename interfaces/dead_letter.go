@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+type DeadLetterRepository interface {
+	Push(ctx context.Context, id string, letter *models.DeadLetter) error
+	Pop(ctx context.Context, id string, max int) ([]models.DeadLetter, error) // oldest first
+}
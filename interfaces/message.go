@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+type MessageRepository interface {
+	// Save stores the message and reports false when it was already stored
+	Save(ctx context.Context, message *models.StoredMessage) (bool, error)
+	Get(ctx context.Context, instanceID, messageID string) (*models.StoredMessage, error)
+	// ListChat returns up to limit messages of the chat (its ChatJID) newest
+	// first, starting after the before message id when set
+	ListChat(ctx context.Context, instanceID, chat string, limit int, before string) ([]models.StoredMessage, error)
+	SetMediaURL(ctx context.Context, instanceID, messageID, url string) error
+	// SaveVote replaces the options selected by voter on the poll
+	SaveVote(ctx context.Context, instanceID, pollID, voter string, options [][]byte) error
+	// GetVotes returns the selected option hashes by voter
+	GetVotes(ctx context.Context, instanceID, pollID string) (map[string][][]byte, error)
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+type ScheduleRepository interface {
+	Save(ctx context.Context, message *models.ScheduledMessage) error
+	List(ctx context.Context) ([]models.ScheduledMessage, error)
+	Get(ctx context.Context, id string) (*models.ScheduledMessage, error)
+	// Delete reports whether the message was still pending, so a single caller
+	// claims it when several race
+	Delete(ctx context.Context, id string) (bool, error)
+}
@@ -1,12 +1,20 @@
 package interfaces
 
 import (
+	"errors"
 	"io"
 
+	"github.com/verbeux-ai/whatsmiau/models"
 	"golang.org/x/net/context"
 )
 
+// ErrObjectNotFound is returned by every Storage implementation for missing keys
+var ErrObjectNotFound = errors.New("object not found")
+
 type Storage interface {
 	UploadBase64(ctx context.Context, fileName, mimetype, b64 string) (string, error)
 	Upload(ctx context.Context, fileName, mimetype string, file io.Reader) (string, string, error)
+	// List returns one page of objects and the token of the next one, empty on the last page
+	List(ctx context.Context, query *models.StorageQuery) ([]models.StoredMedia, string, error)
+	Download(ctx context.Context, key string) (io.ReadCloser, *models.StoredMedia, error)
 }
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+type TemplateRepository interface {
+	// Save creates or replaces the template with the same name
+	Save(ctx context.Context, template *models.MessageTemplate) error
+	Get(ctx context.Context, instanceID, name string) (*models.MessageTemplate, error)
+	List(ctx context.Context, instanceID string) ([]models.MessageTemplate, error)
+	Delete(ctx context.Context, instanceID, name string) error
+	// DeleteAll removes every template of the instance
+	DeleteAll(ctx context.Context, instanceID string) error
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var instancesDesc = prometheus.NewDesc(
+	"whatsmiau_instances",
+	"Loaded instances by connection status.",
+	[]string{"status"}, nil,
+)
+
+var instancesSource atomic.Pointer[func() map[string]int]
+
+// SetInstancesSource sets the function counting instances by status, called on
+// every scrape so the gauge is never stale
+func SetInstancesSource(source func() map[string]int) {
+	instancesSource.Store(&source)
+}
+
+type instancesCollector struct{}
+
+func (instancesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- instancesDesc
+}
+
+func (instancesCollector) Collect(ch chan<- prometheus.Metric) {
+	source := instancesSource.Load()
+	if source == nil {
+		return
+	}
+
+	for status, count := range (*source)() {
+		ch <- prometheus.MustNewConstMetric(instancesDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+func init() {
+	prometheus.MustRegister(instancesCollector{})
+}
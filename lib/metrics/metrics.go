@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+// Instance is the value of the instance label for id, empty (the label is
+// dropped) when METRICS_INSTANCE_LABELS is disabled
+func Instance(id string) string {
+	if !env.Env.MetricsInstanceLabels {
+		return ""
+	}
+
+	return id
+}
+
+// WebhookBreakerState reports the webhook circuit breaker state per instance:
+// 0 = closed, 1 = half-open, 2 = open
+var WebhookBreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "whatsmiau",
+	Name:      "webhook_breaker_state",
+	Help:      "Webhook circuit breaker state by instance (0 closed, 1 half-open, 2 open).",
+}, []string{"instance"})
+
+// EmitterLatency measures how long events wait in the emitter queue between
+// being produced by the event handler and picked up for delivery
+var EmitterLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "whatsmiau",
+	Name:      "emitter_latency_seconds",
+	Help:      "Time events wait in the emitter queue before delivery.",
+	Buckets:   []float64{.005, .01, .05, .1, .5, 1, 2.5, 5, 10, 30, 60},
+})
+
+// ClockSkew is the offset in seconds between the WhatsApp server clock and the
+// local clock, sampled from message acks
+var ClockSkew = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "whatsmiau",
+	Name:      "clock_skew_seconds",
+	Help:      "Offset between the WhatsApp server clock and the local clock by instance.",
+}, []string{"instance"})
+
+var ClockSkewExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "whatsmiau",
+	Name:      "clock_skew_exceeded_total",
+	Help:      "Clock samples with skew above CLOCK_SKEW_WARN_THRESHOLD by instance.",
+}, []string{"instance"})
+
+// EventsEmitted counts events queued to the webhook by event key, ex:
+// MESSAGES_UPSERT or MESSAGE for normalized events
+var EventsEmitted = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "whatsmiau",
+	Name:      "events_emitted_total",
+	Help:      "Events queued for webhook delivery by instance and event.",
+}, []string{"instance", "event"})
+
+// WebhookDeliveries counts webhook delivery attempts by result, success or
+// failure, retries are counted as new attempts
+var WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "whatsmiau",
+	Name:      "webhook_deliveries_total",
+	Help:      "Webhook delivery attempts by instance and result.",
+}, []string{"instance", "result"})
+
+// SendLatency measures the round-trip of messages sent through the API until
+// the server ack, by message type (ex: conversation, imageMessage)
+var SendLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "whatsmiau",
+	Name:      "send_latency_seconds",
+	Help:      "Time to send a message until the server ack by instance, message type and result.",
+	Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+}, []string{"instance", "type", "result"})
+
+// DeleteInstance drops the series labeled with the instance, called once the
+// instance is deleted so its id isn't reported forever
+func DeleteInstance(id string) {
+	if !env.Env.MetricsInstanceLabels {
+		return
+	}
+
+	labels := prometheus.Labels{"instance": id}
+	WebhookBreakerState.DeletePartialMatch(labels)
+	ClockSkew.DeletePartialMatch(labels)
+	ClockSkewExceeded.DeletePartialMatch(labels)
+	EventsEmitted.DeletePartialMatch(labels)
+	WebhookDeliveries.DeletePartialMatch(labels)
+	SendLatency.DeletePartialMatch(labels)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+func countSeries(collector prometheus.Collector) int {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.Collect(ch)
+		close(ch)
+	}()
+
+	count := 0
+	for range ch {
+		count++
+	}
+	return count
+}
+
+func TestDeleteInstance(t *testing.T) {
+	previous := env.Env.MetricsInstanceLabels
+	env.Env.MetricsInstanceLabels = true
+	t.Cleanup(func() { env.Env.MetricsInstanceLabels = previous })
+
+	EventsEmitted.WithLabelValues(Instance("deleted"), "MESSAGES_UPSERT").Inc()
+	EventsEmitted.WithLabelValues(Instance("kept"), "MESSAGES_UPSERT").Inc()
+	WebhookBreakerState.WithLabelValues(Instance("deleted")).Set(2)
+
+	DeleteInstance("deleted")
+
+	if count := countSeries(EventsEmitted); count != 1 {
+		t.Errorf("events series = %d, want only the kept instance", count)
+	}
+	if count := countSeries(WebhookBreakerState); count != 0 {
+		t.Errorf("breaker series = %d, want none", count)
+	}
+}
@@ -1,21 +1,21 @@
 package gcs
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
-	"net/http"
 	"path/filepath"
 	"time"
 
 	"cloud.google.com/go/storage"
-	"github.com/google/uuid"
 	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/storage/upload"
+	"github.com/verbeux-ai/whatsmiau/models"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
@@ -46,7 +46,7 @@ func New(bucket string) (*Gcs, error) {
 }
 
 func (s *Gcs) UploadBase64(ctx context.Context, fileName, mimetype, b64 string) (string, error) {
-	file, newFileName, err := base64ToReader(b64, mimetype, fileName)
+	file, newFileName, err := upload.FromBase64(b64, mimetype, fileName)
 	if err != nil {
 		return "", err
 	}
@@ -63,32 +63,6 @@ func (s *Gcs) UploadBase64(ctx context.Context, fileName, mimetype, b64 string)
 	return url, nil
 }
 
-func base64ToReader(encodedData, mimeType, fileName string) (io.Reader, string, error) {
-	decodedData, err := base64.StdEncoding.DecodeString(encodedData)
-	if err != nil {
-		return nil, "", err
-	}
-
-	ext := filepath.Ext(fileName)
-	if ext == "" {
-		var dataSample []byte
-		if len(decodedData) > 512 {
-			dataSample = decodedData[:512]
-		} else {
-			dataSample = decodedData
-		}
-		detected := http.DetectContentType(dataSample)
-		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
-			ext = exts[0]
-		} else if exts, _ := mime.ExtensionsByType(detected); len(exts) > 0 {
-			ext = exts[0]
-		}
-	}
-
-	filename := uuid.New().String() + ext
-	return bytes.NewReader(decodedData), filename, nil
-}
-
 func (s *Gcs) Upload(ctx context.Context, fileName, mimetype string, file io.Reader) (string, string, error) {
 	obj := s.googleBucket.Object(fileName)
 	writer := obj.NewWriter(ctx)
@@ -103,9 +77,59 @@ func (s *Gcs) Upload(ctx context.Context, fileName, mimetype string, file io.Rea
 		return "", "", err
 	}
 
-	return fmt.Sprintf("%s/%s/%s",
-		env.Env.GCSURL,
-		obj.BucketName(),
-		fileName,
-	), fileName, nil
+	return s.objectURL(fileName), fileName, nil
+}
+
+func (s *Gcs) objectURL(fileName string) string {
+	return fmt.Sprintf("%s/%s/%s", env.Env.GCSURL, s.googleBucket.BucketName(), fileName)
+}
+
+func (s *Gcs) List(ctx context.Context, query *models.StorageQuery) ([]models.StoredMedia, string, error) {
+	it := s.googleBucket.Objects(ctx, &storage.Query{
+		Prefix:      query.Prefix,
+		StartOffset: query.StartOffset,
+		EndOffset:   query.EndOffset,
+	})
+
+	var attrs []*storage.ObjectAttrs
+	next, err := iterator.NewPager(it, query.Limit, query.PageToken).NextPage(&attrs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result := make([]models.StoredMedia, 0, len(attrs))
+	for _, attr := range attrs {
+		result = append(result, s.storedMedia(attr))
+	}
+
+	return result, next, nil
+}
+
+func (s *Gcs) Download(ctx context.Context, key string) (io.ReadCloser, *models.StoredMedia, error) {
+	obj := s.googleBucket.Object(key)
+	attr, err := obj.Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, nil, interfaces.ErrObjectNotFound
+		}
+		return nil, nil, err
+	}
+
+	reader, err := obj.NewReader(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	media := s.storedMedia(attr)
+	return reader, &media, nil
+}
+
+func (s *Gcs) storedMedia(attr *storage.ObjectAttrs) models.StoredMedia {
+	return models.StoredMedia{
+		Key:         attr.Name,
+		URL:         s.objectURL(attr.Name),
+		Size:        attr.Size,
+		ContentType: attr.ContentType,
+		CreatedAt:   attr.Created,
+	}
 }
@@ -0,0 +1,238 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/storage/upload"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+var _ interfaces.Storage = (*LocalFS)(nil)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+const tempPrefix = ".upload-"
+
+type LocalFS struct {
+	root    string
+	baseURL string
+}
+
+// New stores media as files under root, keys are slash separated paths relative
+// to it. baseURL is where root is served from, media urls are baseURL/key.
+func New(root, baseURL string) (*LocalFS, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalFS{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// path maps a key to its file. Keys come from clients on downloads, so only
+// clean relative paths are accepted: anything with "..", empty or repeated
+// segments, a leading slash or a backslash could alias another file.
+func (s *LocalFS) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "\\") || path.Clean("/" + key)[1:] != key {
+		return "", ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if strings.HasPrefix(segment, tempPrefix) {
+			return "", ErrInvalidKey
+		}
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalFS) UploadBase64(ctx context.Context, fileName, mimetype, b64 string) (string, error) {
+	file, newFileName, err := upload.FromBase64(b64, mimetype, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	url, _, err := s.Upload(ctx, newFileName, mimetype, file)
+	if err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+// Upload writes to a temporary file next to the destination and renames it, so
+// a listing or download never sees a partial file. The content type isn't kept,
+// it is derived from the extension or the content when read.
+func (s *LocalFS) Upload(ctx context.Context, fileName, mimetype string, file io.Reader) (string, string, error) {
+	dst, err := s.path(fileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), tempPrefix+"*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, file); err != nil {
+		tmp.Close()
+		return "", "", err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", "", err
+	}
+
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", "", err
+	}
+
+	return s.objectURL(fileName), fileName, nil
+}
+
+func (s *LocalFS) objectURL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// List walks the directory holding the prefix in lexical order, the page token
+// is the last key of the page. A prefix whose directory isn't a valid key lists
+// nothing, like Download of an invalid key.
+func (s *LocalFS) List(ctx context.Context, query *models.StorageQuery) ([]models.StoredMedia, string, error) {
+	root := s.root
+	if dir := path.Dir(query.Prefix + "x"); dir != "." {
+		var err error
+		if root, err = s.path(dir); err != nil {
+			return nil, "", nil
+		}
+	}
+
+	var (
+		result []models.StoredMedia
+		next   string
+		done   = errors.New("done")
+	)
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), tempPrefix) || entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, file)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, query.Prefix) || key < query.StartOffset || (query.PageToken != "" && key <= query.PageToken) {
+			return nil
+		}
+		if query.EndOffset != "" && key >= query.EndOffset {
+			return done
+		}
+		if query.Limit > 0 && len(result) == query.Limit {
+			next = result[len(result)-1].Key
+			return done
+		}
+
+		media, err := s.storedMedia(key, file, entry)
+		if err != nil {
+			return err
+		}
+		result = append(result, media)
+		return nil
+	})
+	if err != nil && !errors.Is(err, done) {
+		return nil, "", err
+	}
+
+	return result, next, nil
+}
+
+func (s *LocalFS) Download(ctx context.Context, key string) (io.ReadCloser, *models.StoredMedia, error) {
+	file, err := s.path(key)
+	if err != nil {
+		return nil, nil, interfaces.ErrObjectNotFound
+	}
+
+	reader, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, interfaces.ErrObjectNotFound
+		}
+		return nil, nil, err
+	}
+
+	info, err := reader.Stat()
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		reader.Close()
+		return nil, nil, interfaces.ErrObjectNotFound
+	}
+
+	media, err := s.storedMedia(key, file, fs.FileInfoToDirEntry(info))
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+
+	return reader, &media, nil
+}
+
+func (s *LocalFS) storedMedia(key, file string, entry fs.DirEntry) (models.StoredMedia, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return models.StoredMedia{}, err
+	}
+
+	return models.StoredMedia{
+		Key:         key,
+		URL:         s.objectURL(key),
+		Size:        info.Size(),
+		ContentType: contentType(file),
+		CreatedAt:   info.ModTime(),
+	}, nil
+}
+
+func contentType(file string) string {
+	if byExt := mime.TypeByExtension(filepath.Ext(file)); byExt != "" {
+		return byExt
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+
+	sample := make([]byte, 512)
+	n, _ := f.Read(sample)
+	return http.DetectContentType(sample[:n])
+}
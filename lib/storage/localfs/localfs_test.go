@@ -0,0 +1,135 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+func TestPath(t *testing.T) {
+	root := t.TempDir()
+	s, err := New(root, "http://localhost/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "file.jpg"},
+		{key: "instance/2024/file.jpg"},
+		{key: "", wantErr: true},
+		{key: "../file.jpg", wantErr: true},
+		{key: "instance/../../file.jpg", wantErr: true},
+		{key: "instance/../file.jpg", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "instance//file.jpg", wantErr: true},
+		{key: "instance/./file.jpg", wantErr: true},
+		{key: "instance/", wantErr: true},
+		{key: "..\\file.jpg", wantErr: true},
+		{key: tempPrefix + "123", wantErr: true},
+		{key: "instance/" + tempPrefix + "123/file.jpg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			file, err := s.path(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("path(%q) = %q, %v, want ErrInvalidKey", tt.key, file, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q): %v", tt.key, err)
+			}
+			if !strings.HasPrefix(file, root+string(filepath.Separator)) {
+				t.Errorf("path(%q) = %q, outside of %q", tt.key, file, root)
+			}
+		})
+	}
+}
+
+func TestDownloadOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(filepath.Join(dir, "media"), "http://localhost/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := s.Download(context.Background(), "../secret.txt"); !errors.Is(err, interfaces.ErrObjectNotFound) {
+		t.Errorf("Download outside root = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	s, err := New(t.TempDir(), "http://localhost/media/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, key, err := s.Upload(context.Background(), "instance/file.txt", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://localhost/media/instance/file.txt" || key != "instance/file.txt" {
+		t.Errorf("Upload = %q, %q", url, key)
+	}
+
+	if _, _, err := s.Upload(context.Background(), "../file.txt", "text/plain", strings.NewReader("hello")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Upload outside root = %v, want ErrInvalidKey", err)
+	}
+
+	reader, media, err := s.Download(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "hello" || media.Size != 5 || media.Key != key {
+		t.Errorf("Download = %q, %+v", content, media)
+	}
+}
+
+func TestListOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(filepath.Join(dir, "media"), "http://localhost/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Upload(context.Background(), "instance/file.txt", "text/plain", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, prefix := range []string{"../", "../secret", "/", "instance/../../", "..\\"} {
+		media, _, err := s.List(context.Background(), &models.StorageQuery{Prefix: prefix})
+		if err != nil || len(media) > 0 {
+			t.Errorf("List(%q) = %+v, %v, want nothing", prefix, media, err)
+		}
+	}
+
+	media, _, err := s.List(context.Background(), &models.StorageQuery{Prefix: "instance/"})
+	if err != nil || len(media) != 1 || media[0].Key != "instance/file.txt" {
+		t.Errorf("List(instance/) = %+v, %v", media, err)
+	}
+}
@@ -0,0 +1,198 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/storage/upload"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+var _ interfaces.Storage = (*S3)(nil)
+
+type S3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// New connects to AWS S3 or any S3 compatible server (MinIO, R2...). Without
+// static keys the credentials come from the AWS environment variables or the
+// instance IAM role.
+func New(bucket string) (*S3, error) {
+	creds := credentials.NewChainCredentials([]credentials.Provider{&credentials.EnvAWS{}, &credentials.IAM{}})
+	if env.Env.S3AccessKey != "" {
+		creds = credentials.NewStaticV4(env.Env.S3AccessKey, env.Env.S3SecretKey, "")
+	}
+
+	lookup := minio.BucketLookupAuto
+	if env.Env.S3PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(env.Env.S3Endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       env.Env.S3UseSSL,
+		Region:       env.Env.S3Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{
+		client:    client,
+		bucket:    bucket,
+		publicURL: publicURL(bucket),
+	}, nil
+}
+
+// publicURL is the prefix of object urls: S3_PUBLIC_URL when set, otherwise the
+// endpoint addressed the same way as the client
+func publicURL(bucket string) string {
+	if env.Env.S3PublicURL != "" {
+		return strings.TrimSuffix(env.Env.S3PublicURL, "/")
+	}
+
+	scheme := "http"
+	if env.Env.S3UseSSL {
+		scheme = "https"
+	}
+
+	if env.Env.S3PathStyle {
+		return fmt.Sprintf("%s://%s/%s", scheme, env.Env.S3Endpoint, bucket)
+	}
+	return fmt.Sprintf("%s://%s.%s", scheme, bucket, env.Env.S3Endpoint)
+}
+
+func (s *S3) UploadBase64(ctx context.Context, fileName, mimetype, b64 string) (string, error) {
+	file, newFileName, err := upload.FromBase64(b64, mimetype, fileName)
+	if err != nil {
+		return "", err
+	}
+
+	if mimetype == "" {
+		mimetype = mime.TypeByExtension(filepath.Ext(newFileName))
+	}
+
+	url, _, err := s.Upload(ctx, newFileName, mimetype, file)
+	if err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+// partSize bounds the buffer of uploads whose size isn't known, minio-go
+// defaults to parts sized for its 5 TiB maximum and allocates about 512 MiB
+const partSize = 16 << 20
+
+func (s *S3) Upload(ctx context.Context, fileName, mimetype string, file io.Reader) (string, string, error) {
+	if _, err := s.client.PutObject(ctx, s.bucket, fileName, file, objectSize(file), minio.PutObjectOptions{
+		ContentType: mimetype,
+		PartSize:    partSize,
+	}); err != nil {
+		return "", "", err
+	}
+
+	return s.objectURL(fileName), fileName, nil
+}
+
+// objectSize is the size of in-memory readers and files, -1 when unknown, so
+// small objects are sent in a single request
+func objectSize(file io.Reader) int64 {
+	switch f := file.(type) {
+	case interface{ Len() int }:
+		return int64(f.Len())
+	case *os.File:
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+
+	return -1
+}
+
+func (s *S3) objectURL(fileName string) string {
+	return s.publicURL + "/" + fileName
+}
+
+// List pages with the last key of the page as token. S3 has no end offset, the
+// listing stops at the first key past it.
+func (s *S3) List(ctx context.Context, query *models.StorageQuery) ([]models.StoredMedia, string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startAfter := query.PageToken
+	if startAfter == "" && query.StartOffset != "" {
+		// StartAfter is exclusive, StartOffset inclusive
+		startAfter = query.StartOffset[:len(query.StartOffset)-1]
+	}
+
+	var result []models.StoredMedia
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:     query.Prefix,
+		StartAfter: startAfter,
+		Recursive:  true,
+	}) {
+		if object.Err != nil {
+			return nil, "", object.Err
+		}
+		if object.Key < query.StartOffset {
+			continue
+		}
+		if query.EndOffset != "" && object.Key >= query.EndOffset {
+			return result, "", nil
+		}
+		if query.Limit > 0 && len(result) == query.Limit {
+			return result, result[len(result)-1].Key, nil
+		}
+
+		result = append(result, s.storedMedia(object))
+	}
+
+	return result, "", nil
+}
+
+func (s *S3) Download(ctx context.Context, key string) (io.ReadCloser, *models.StoredMedia, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, nil, interfaces.ErrObjectNotFound
+		}
+		return nil, nil, err
+	}
+
+	reader, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	media := s.storedMedia(info)
+	return reader, &media, nil
+}
+
+func (s *S3) storedMedia(info minio.ObjectInfo) models.StoredMedia {
+	return models.StoredMedia{
+		Key:         info.Key,
+		URL:         s.objectURL(info.Key),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		CreatedAt:   info.LastModified,
+	}
+}
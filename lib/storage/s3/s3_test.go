@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestObjectSize(t *testing.T) {
+	if got := objectSize(bytes.NewReader([]byte("hello"))); got != 5 {
+		t.Errorf("bytes.Reader size = %d, want 5", got)
+	}
+	if got := objectSize(strings.NewReader("hello")); got != 5 {
+		t.Errorf("strings.Reader size = %d, want 5", got)
+	}
+	if got := objectSize(io.MultiReader(strings.NewReader("hello"))); got != -1 {
+		t.Errorf("unknown reader size = %d, want -1", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "media")
+	if err := os.WriteFile(path, []byte("hello world"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if got := objectSize(file); got != 5 {
+		t.Errorf("file size = %d, want the 5 bytes left to read", got)
+	}
+}
@@ -0,0 +1,41 @@
+// Package upload holds the helpers shared by the storage backends
+package upload
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// FromBase64 decodes the file and names it <uuid><ext>, the extension comes from
+// fileName, then mimeType, then the detected content type
+func FromBase64(encodedData, mimeType, fileName string) (io.Reader, string, error) {
+	decodedData, err := base64.StdEncoding.DecodeString(encodedData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ext := filepath.Ext(fileName)
+	if ext == "" {
+		var dataSample []byte
+		if len(decodedData) > 512 {
+			dataSample = decodedData[:512]
+		} else {
+			dataSample = decodedData
+		}
+		detected := http.DetectContentType(dataSample)
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			ext = exts[0]
+		} else if exts, _ := mime.ExtensionsByType(detected); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+
+	filename := uuid.New().String() + ext
+	return bytes.NewReader(decodedData), filename, nil
+}
@@ -0,0 +1,106 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+// handleBanned runs after the logout, whatsmeow already deleted the session, so
+// the block is only kept on the instance
+func (s *Whatsmiau) handleBanned(id string, instance *models.Instance, e *events.LoggedOut, eventMap map[string]bool) {
+	s.blockAccount(id, instance, models.AccountBlock{
+		Status: Banned,
+		Code:   int(e.Reason),
+		Reason: e.Reason.String(),
+		At:     time.Now(),
+	}, eventMap)
+}
+
+// handleTemporaryBan keeps the session, the account can connect again once the
+// block expires, reconnecting before that only extends it
+func (s *Whatsmiau) handleTemporaryBan(id string, instance *models.Instance, e *events.TemporaryBan, eventMap map[string]bool) {
+	now := time.Now()
+	until := now.Add(e.Expire)
+	block := models.AccountBlock{
+		Status: TemporaryBlock,
+		Code:   int(e.Code),
+		Reason: e.Code.String(),
+		Until:  &until,
+		At:     now,
+	}
+
+	s.accountBlocks.Store(id, block)
+	s.blockAccount(id, instance, block, eventMap)
+}
+
+func (s *Whatsmiau) blockAccount(id string, instance *models.Instance, block models.AccountBlock, eventMap map[string]bool) {
+	zap.L().Warn("account blocked by whatsapp",
+		zap.String("id", id),
+		zap.String("status", block.Status),
+		zap.Int("code", block.Code),
+		zap.String("reason", block.Reason))
+
+	s.stopReconnect(id)
+	s.updateAccountBlock(id, &block)
+
+	if !eventMap["ACCOUNT_BLOCKED"] {
+		return
+	}
+
+	wookData := &WookEvent[WookAccountBlockedData]{
+		Instance: instance.ID,
+		Data: &WookAccountBlockedData{
+			Instance: instance.ID,
+			State:    Status(block.Status),
+			Code:     block.Code,
+			Reason:   block.Reason,
+			Until:    block.Until,
+		},
+		DateTime: block.At,
+		Event:    WookAccountBlocked,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// clearAccountBlock drops the block once the account connects again
+func (s *Whatsmiau) clearAccountBlock(id string, instance *models.Instance) {
+	s.accountBlocks.Delete(id)
+	if instance.Block == nil {
+		return
+	}
+
+	s.updateAccountBlock(id, &models.AccountBlock{})
+}
+
+func (s *Whatsmiau) updateAccountBlock(id string, block *models.AccountBlock) {
+	ctx, c := context.WithTimeout(context.Background(), time.Second*5)
+	defer c()
+
+	updated, err := s.repo.Update(ctx, id, &models.Instance{Block: block})
+	if err != nil {
+		zap.L().Error("failed to update instance account block", zap.String("id", id), zap.Error(err))
+		return
+	}
+
+	s.instanceCache.Store(id, *updated)
+}
+
+// accountBlock returns the active temporary block of a loaded client
+func (s *Whatsmiau) accountBlock(id string) (models.AccountBlock, bool) {
+	block, ok := s.accountBlocks.Load(id)
+	if !ok {
+		return block, false
+	}
+
+	if !block.Active(time.Now()) {
+		s.accountBlocks.Delete(id)
+		return block, false
+	}
+
+	return block, true
+}
@@ -0,0 +1,117 @@
+package whatsmiau
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+// GetBlocklist returns the contacts blocked by the account
+func (s *Whatsmiau) GetBlocklist(ctx context.Context, id string) ([]types.JID, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	blocklist, err := client.GetBlocklist(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return blocklist.JIDs, nil
+}
+
+// SetBlocked blocks or unblocks a contact, doing nothing when it is already in
+// the requested state. Changes emit blocklist.update.
+func (s *Whatsmiau) SetBlocked(ctx context.Context, id string, contact types.JID, blocked bool) error {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return err
+	}
+
+	jid, lid := s.GetJidLid(ctx, id, contact)
+	if parsed, err := types.ParseJID(jid); err == nil {
+		contact = parsed
+	}
+
+	current, err := client.GetBlocklist(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the list may hold either the phone number or the LID of the contact
+	isBlocked := slices.ContainsFunc(current.JIDs, func(item types.JID) bool {
+		item = item.ToNonAD()
+		return item.String() == jid || (lid != "" && item.String() == lid)
+	})
+	if isBlocked == blocked {
+		return nil
+	}
+
+	action := events.BlocklistChangeActionUnblock
+	if blocked {
+		action = events.BlocklistChangeActionBlock
+	}
+
+	if _, err := client.UpdateBlocklist(ctx, contact, action); err != nil {
+		return err
+	}
+
+	s.emitBlocklistUpdate(id, []events.BlocklistChange{{JID: contact, Action: action}})
+	return nil
+}
+
+// handleBlocklistEvent forwards changes made from other devices, a modify
+// action without changes only means the list must be fetched again
+func (s *Whatsmiau) handleBlocklistEvent(id string, instance *models.Instance, e *events.Blocklist, eventMap map[string]bool) {
+	if !eventMap["BLOCKLIST_UPDATE"] {
+		return
+	}
+
+	if len(e.Changes) == 0 {
+		zap.L().Debug("blocklist changed without details", zap.String("id", id), zap.String("action", string(e.Action)))
+		return
+	}
+
+	s.emit(s.blocklistUpdate(instance, e.Changes), instance)
+}
+
+func (s *Whatsmiau) emitBlocklistUpdate(id string, changes []events.BlocklistChange) {
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return
+	}
+
+	if !eventMapOf(instance)["BLOCKLIST_UPDATE"] {
+		return
+	}
+
+	s.emit(s.blocklistUpdate(instance, changes), instance)
+}
+
+func (s *Whatsmiau) blocklistUpdate(instance *models.Instance, changes []events.BlocklistChange) *WookEvent[WookBlocklistUpdateData] {
+	ctx, c := context.WithTimeout(context.Background(), time.Second*10)
+	defer c()
+
+	data := &WookBlocklistUpdateData{InstanceId: instance.ID}
+	for _, change := range changes {
+		jid, lid := s.GetJidLid(ctx, instance.ID, change.JID)
+		data.Changes = append(data.Changes, WookBlocklistChange{
+			Jid:     jid,
+			Lid:     lid,
+			Blocked: change.Action == events.BlocklistChangeActionBlock,
+		})
+	}
+
+	return &WookEvent[WookBlocklistUpdateData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: time.Now(),
+		Event:    WookBlocklistUpdate,
+	}
+}
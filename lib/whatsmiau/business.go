@@ -0,0 +1,137 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var ErrNotBusiness = errors.New("target is not a business account")
+
+// BusinessProfile adds the description and websites whatsmeow doesn't parse
+// from the business profile
+type BusinessProfile struct {
+	types.BusinessProfile
+	Description string
+	Websites    []string
+}
+
+// GetBusinessProfile returns the description, websites, address, email,
+// categories and opening hours of a business account
+func (s *Whatsmiau) GetBusinessProfile(ctx context.Context, id string, jid types.JID) (*BusinessProfile, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if pn, _ := s.GetJidLid(ctx, id, jid); pn != "" {
+		if parsed, err := types.ParseJID(pn); err == nil {
+			jid = parsed
+		}
+	}
+
+	profile, err := queryBusinessProfile(ctx, client, jid)
+	if err == nil && !profile.JID.IsEmpty() {
+		return profile, nil
+	}
+
+	// personal accounts come back as a profile without jid, tell them apart
+	// from failures by the verified name
+	users, infoErr := client.GetUserInfo(ctx, []types.JID{jid})
+	if infoErr == nil && users[jid].VerifiedName == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotBusiness, jid)
+	}
+
+	if err == nil {
+		err = fmt.Errorf("%w: %s", ErrNotBusiness, jid)
+	}
+	return nil, err
+}
+
+// queryBusinessProfile sends the query of client.GetBusinessProfile, parsing
+// the response here to keep the fields whatsmeow drops
+func queryBusinessProfile(ctx context.Context, client *whatsmeow.Client, jid types.JID) (*BusinessProfile, error) {
+	resp, err := client.DangerousInternals().SendIQ(ctx, whatsmeow.DangerousInfoQuery{
+		Namespace: "w:biz",
+		Type:      "get",
+		To:        types.ServerJID,
+		Content: []waBinary.Node{{
+			Tag:   "business_profile",
+			Attrs: waBinary.Attrs{"v": "244"},
+			Content: []waBinary.Node{{
+				Tag:   "profile",
+				Attrs: waBinary.Attrs{"jid": jid},
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := resp.GetOptionalChildByTag("business_profile")
+	if !ok {
+		return nil, &whatsmeow.ElementMissingError{Tag: "business_profile", In: "response to business profile query"}
+	}
+
+	return parseBusinessProfile(&node), nil
+}
+
+// parseBusinessProfile reads the profile like whatsmeow does, plus the
+// description and websites. The JID is empty for personal accounts.
+func parseBusinessProfile(node *waBinary.Node) *BusinessProfile {
+	text := func(node waBinary.Node) string {
+		content, _ := node.Content.([]byte)
+		return string(content)
+	}
+
+	profileNode := node.GetChildByTag("profile")
+	jid, _ := profileNode.AttrGetter().GetJID("jid", false)
+	profile := &BusinessProfile{
+		BusinessProfile: types.BusinessProfile{
+			JID:            jid,
+			Address:        text(profileNode.GetChildByTag("address")),
+			Email:          text(profileNode.GetChildByTag("email")),
+			Categories:     make([]types.Category, 0),
+			ProfileOptions: make(map[string]string),
+			BusinessHours:  make([]types.BusinessHoursConfig, 0),
+		},
+		Description: text(profileNode.GetChildByTag("description")),
+	}
+
+	for _, website := range profileNode.GetChildrenByTag("website") {
+		if url := text(website); url != "" {
+			profile.Websites = append(profile.Websites, url)
+		}
+	}
+
+	categories := profileNode.GetChildByTag("categories")
+	for _, category := range categories.GetChildrenByTag("category") {
+		profile.Categories = append(profile.Categories, types.Category{
+			ID:   category.AttrGetter().String("id"),
+			Name: text(category),
+		})
+	}
+
+	hours := profileNode.GetChildByTag("business_hours")
+	profile.BusinessHoursTimeZone = hours.AttrGetter().String("timezone")
+	for _, config := range hours.GetChildrenByTag("business_hours_config") {
+		attrs := config.AttrGetter()
+		profile.BusinessHours = append(profile.BusinessHours, types.BusinessHoursConfig{
+			DayOfWeek: attrs.String("day_of_week"),
+			Mode:      attrs.String("mode"),
+			OpenTime:  attrs.String("open_time"),
+			CloseTime: attrs.String("close_time"),
+		})
+	}
+
+	options := profileNode.GetChildByTag("profile_options")
+	for _, option := range options.GetChildren() {
+		profile.ProfileOptions[option.Tag] = text(option)
+	}
+
+	return profile
+}
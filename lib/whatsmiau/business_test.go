@@ -0,0 +1,60 @@
+package whatsmiau
+
+import (
+	"slices"
+	"testing"
+
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestParseBusinessProfile(t *testing.T) {
+	jid := types.NewJID("5511911111111", types.DefaultUserServer)
+	node := &waBinary.Node{
+		Tag: "business_profile",
+		Content: []waBinary.Node{{
+			Tag:   "profile",
+			Attrs: waBinary.Attrs{"jid": jid},
+			Content: []waBinary.Node{
+				{Tag: "description", Content: []byte("Fresh bread every day")},
+				{Tag: "website", Content: []byte("https://bakery.example")},
+				{Tag: "website", Content: []byte("https://shop.bakery.example")},
+				{Tag: "address", Content: []byte("Main street, 1")},
+				{Tag: "email", Content: []byte("hello@bakery.example")},
+				{Tag: "categories", Content: []waBinary.Node{
+					{Tag: "category", Attrs: waBinary.Attrs{"id": "1"}, Content: []byte("Bakery")},
+				}},
+				{Tag: "business_hours", Attrs: waBinary.Attrs{"timezone": "America/Sao_Paulo"}, Content: []waBinary.Node{
+					{Tag: "business_hours_config", Attrs: waBinary.Attrs{"day_of_week": "mon", "mode": "specific_hours", "open_time": "480", "close_time": "1080"}},
+				}},
+				{Tag: "profile_options", Content: []waBinary.Node{
+					{Tag: "commerce_experience", Content: []byte("catalog")},
+				}},
+			},
+		}},
+	}
+
+	profile := parseBusinessProfile(node)
+	if profile.JID != jid || profile.Description != "Fresh bread every day" ||
+		!slices.Equal(profile.Websites, []string{"https://bakery.example", "https://shop.bakery.example"}) {
+		t.Errorf("profile = %+v, want the jid, description and websites", profile)
+	}
+	if profile.Address != "Main street, 1" || profile.Email != "hello@bakery.example" || profile.BusinessHoursTimeZone != "America/Sao_Paulo" {
+		t.Errorf("profile = %+v, want the address, email and time zone", profile)
+	}
+	if len(profile.Categories) != 1 || profile.Categories[0] != (types.Category{ID: "1", Name: "Bakery"}) {
+		t.Errorf("categories = %+v", profile.Categories)
+	}
+	if len(profile.BusinessHours) != 1 || profile.BusinessHours[0].OpenTime != "480" || profile.BusinessHours[0].CloseTime != "1080" {
+		t.Errorf("business hours = %+v", profile.BusinessHours)
+	}
+	if profile.ProfileOptions["commerce_experience"] != "catalog" {
+		t.Errorf("options = %+v", profile.ProfileOptions)
+	}
+
+	// personal accounts answer with an empty profile
+	personal := parseBusinessProfile(&waBinary.Node{Tag: "business_profile", Content: []waBinary.Node{{Tag: "profile"}}})
+	if !personal.JID.IsEmpty() || personal.Description != "" || len(personal.Websites) > 0 {
+		t.Errorf("personal = %+v, want an empty profile", personal)
+	}
+}
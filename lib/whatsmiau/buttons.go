@@ -0,0 +1,120 @@
+package whatsmiau
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxButtons is how many reply buttons WhatsApp renders on a message
+const MaxButtons = 3
+
+var ErrInvalidButtons = errors.New("invalid buttons")
+
+type Button struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// SendButtons sends a text with up to MaxButtons reply buttons. Taps arrive as
+// buttonsResponseMessage with the id of the button. Returns the message id.
+func (s *Whatsmiau) SendButtons(ctx context.Context, id string, to types.JID, body string, buttons []Button) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := buildButtonsMessage(body, buttons)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	message.ButtonsMessage.ContextInfo = s.defaultExpirationContext(id, to)
+	res, err := s.sendReserved(ctx, id, client, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+func buildButtonsMessage(body string, buttons []Button) (*waE2E.Message, error) {
+	if len(buttons) == 0 || len(buttons) > MaxButtons {
+		return nil, fmt.Errorf("%w: between 1 and %d buttons are required, got %d", ErrInvalidButtons, MaxButtons, len(buttons))
+	}
+
+	seen := make(map[string]bool, len(buttons))
+	result := make([]*waE2E.ButtonsMessage_Button, 0, len(buttons))
+	for _, button := range buttons {
+		if button.ID == "" || button.Text == "" {
+			return nil, fmt.Errorf("%w: every button requires id and text", ErrInvalidButtons)
+		}
+		if seen[button.ID] {
+			return nil, fmt.Errorf("%w: duplicated button id %q", ErrInvalidButtons, button.ID)
+		}
+		seen[button.ID] = true
+
+		result = append(result, &waE2E.ButtonsMessage_Button{
+			ButtonID: proto.String(button.ID),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{
+				DisplayText: proto.String(button.Text),
+			},
+			Type: waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		})
+	}
+
+	return &waE2E.Message{
+		ButtonsMessage: &waE2E.ButtonsMessage{
+			ContentText: proto.String(body),
+			HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+			Buttons:     result,
+		},
+	}, nil
+}
+
+// parseButtonResponse normalizes the replies of the button flavours (legacy
+// buttons, template buttons and native flow quick replies) into one shape.
+// Returns nil when the message isn't a button reply.
+func parseButtonResponse(m *waE2E.Message) (*WookButtonsResponseMessageRaw, *waE2E.ContextInfo) {
+	switch {
+	case m.GetButtonsResponseMessage() != nil:
+		reply := m.GetButtonsResponseMessage()
+		return &WookButtonsResponseMessageRaw{
+			SelectedButtonId:    reply.GetSelectedButtonID(),
+			SelectedDisplayText: reply.GetSelectedDisplayText(),
+		}, reply.GetContextInfo()
+	case m.GetTemplateButtonReplyMessage() != nil:
+		reply := m.GetTemplateButtonReplyMessage()
+		return &WookButtonsResponseMessageRaw{
+			SelectedButtonId:    reply.GetSelectedID(),
+			SelectedDisplayText: reply.GetSelectedDisplayText(),
+		}, reply.GetContextInfo()
+	case m.GetInteractiveResponseMessage().GetNativeFlowResponseMessage() != nil:
+		reply := m.GetInteractiveResponseMessage()
+		var params struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(reply.GetNativeFlowResponseMessage().GetParamsJSON()), &params); err != nil || params.ID == "" {
+			// other native flows (forms, payments) aren't button taps
+			return nil, nil
+		}
+		return &WookButtonsResponseMessageRaw{
+			SelectedButtonId:    params.ID,
+			SelectedDisplayText: reply.GetBody().GetText(),
+		}, reply.GetContextInfo()
+	}
+
+	return nil, nil
+}
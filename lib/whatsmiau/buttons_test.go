@@ -0,0 +1,53 @@
+package whatsmiau
+
+import (
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+func TestBuildButtonsMessage(t *testing.T) {
+	message, err := buildButtonsMessage("pick one", []Button{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buttons := message.GetButtonsMessage()
+	if buttons == nil {
+		t.Fatal("missing buttonsMessage")
+	}
+	if buttons.GetContentText() != "pick one" || buttons.GetHeaderType() != waE2E.ButtonsMessage_EMPTY {
+		t.Errorf("content = %q, header = %v", buttons.GetContentText(), buttons.GetHeaderType())
+	}
+	if len(buttons.GetButtons()) != 2 {
+		t.Fatalf("got %d buttons, want 2", len(buttons.GetButtons()))
+	}
+	for i, want := range []Button{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}} {
+		button := buttons.GetButtons()[i]
+		if button.GetButtonID() != want.ID || button.GetButtonText().GetDisplayText() != want.Text || button.GetType() != waE2E.ButtonsMessage_Button_RESPONSE {
+			t.Errorf("button %d = %v, want %+v", i, button, want)
+		}
+	}
+}
+
+func TestBuildButtonsMessageInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		buttons []Button
+	}{
+		{name: "none"},
+		{name: "too many", buttons: []Button{{"1", "1"}, {"2", "2"}, {"3", "3"}, {"4", "4"}}},
+		{name: "missing id", buttons: []Button{{Text: "Yes"}}},
+		{name: "missing text", buttons: []Button{{ID: "yes"}}},
+		{name: "duplicated id", buttons: []Button{{"yes", "Yes"}, {"yes", "Sure"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildButtonsMessage("pick one", tt.buttons); !errors.Is(err, ErrInvalidButtons) {
+				t.Errorf("err = %v, want ErrInvalidButtons", err)
+			}
+		})
+	}
+}
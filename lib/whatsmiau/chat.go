@@ -1,10 +1,16 @@
 package whatsmiau
 
 import (
+	"errors"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/verbeux-ai/whatsmiau/env"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
 	"golang.org/x/net/context"
 )
 
@@ -15,18 +21,41 @@ type ReadMessageRequest struct {
 	Sender     *types.JID `json:"sender"`
 }
 
-func (s *Whatsmiau) ReadMessage(data *ReadMessageRequest) error {
+// MarkRead sends one read receipt for every message id, they must all belong to
+// the same chat and, in groups, to the same sender
+func (s *Whatsmiau) MarkRead(ctx context.Context, data *ReadMessageRequest) error {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return whatsmeow.ErrClientIsNil
 	}
 
-	sender := *data.RemoteJID
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	chat := s.resolveRecipient(ctx, data.InstanceID, *data.RemoteJID)
+	sender := chat
 	if data.Sender != nil {
-		sender = *data.Sender
+		sender = s.resolveRecipient(ctx, data.InstanceID, *data.Sender)
 	}
 
-	return client.MarkRead(context.TODO(), data.MessageIDs, time.Now(), *data.RemoteJID, sender)
+	return client.MarkRead(ctx, data.MessageIDs, time.Now(), chat, sender)
+}
+
+// autoMarkRead answers an inbound message with a read receipt, addressed like the
+// message itself so it doesn't need to go through LID resolution
+func (s *Whatsmiau) autoMarkRead(id string, e *events.Message) {
+	client, ok := s.clients.Load(id)
+	if !ok || !client.IsLoggedIn() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := client.MarkRead(ctx, []types.MessageID{e.Info.ID}, time.Now(), e.Info.Chat, e.Info.Sender); err != nil {
+		zap.L().Warn("failed to mark message as read", zap.String("id", id), zap.String("message", e.Info.ID), zap.Error(err))
+	}
 }
 
 type ChatPresenceRequest struct {
@@ -36,13 +65,20 @@ type ChatPresenceRequest struct {
 	Media      types.ChatPresenceMedia `json:"media"`
 }
 
-func (s *Whatsmiau) ChatPresence(data *ChatPresenceRequest) error {
+// ChatPresence sends composing, recording (composing with audio media) or paused
+// to a chat, LIDs are resolved to the phone number like message sends
+func (s *Whatsmiau) ChatPresence(ctx context.Context, data *ChatPresenceRequest) error {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return whatsmeow.ErrClientIsNil
 	}
 
-	return client.SendChatPresence(context.TODO(), *data.RemoteJID, data.Presence, data.Media)
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	to := s.resolveRecipient(ctx, data.InstanceID, *data.RemoteJID)
+	return client.SendChatPresence(ctx, to, data.Presence, data.Media)
 }
 
 type NumberExistsRequest struct {
@@ -57,30 +93,157 @@ type Exists struct {
 	Jid    string `json:"jid"`
 	Lid    string `json:"lid"`
 	Number string `json:"number"`
+	Error  string `json:"error,omitempty"` // set when the chunk holding this number failed
 }
 
+// NumberExists checks numbers in chunks processed by a bounded worker pool,
+// each chunk with its own timeout. Numbers of failed chunks are reported with
+// Error set; an error is only returned if every chunk failed.
 func (s *Whatsmiau) NumberExists(ctx context.Context, data *NumberExistsRequest) (NumberExistsResponse, error) {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resp, err := client.IsOnWhatsApp(context.TODO(), data.Numbers)
+	chunkSize := env.Env.NumberExistsChunkSize
+	if chunkSize <= 0 {
+		chunkSize = len(data.Numbers)
+	}
+
+	var chunks [][]string
+	for start := 0; start < len(data.Numbers); start += chunkSize {
+		end := min(start+chunkSize, len(data.Numbers))
+		chunks = append(chunks, data.Numbers[start:end])
+	}
+
+	results := make([][]Exists, len(chunks))
+	errs := make([]error, len(chunks))
+
+	workers := make(chan struct{}, max(env.Env.NumberExistsConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i], errs[i] = s.numberExistsChunk(ctx, client, data.InstanceID, chunk)
+		}()
+	}
+	wg.Wait()
+
+	var (
+		response NumberExistsResponse
+		failed   int
+	)
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			failed++
+			zap.L().Error("failed to check numbers chunk", zap.String("id", data.InstanceID), zap.Int("chunk", i), zap.Error(errs[i]))
+			for _, number := range chunk {
+				response = append(response, Exists{Number: number, Error: errs[i].Error()})
+			}
+			continue
+		}
+		response = append(response, results[i]...)
+	}
+
+	if failed > 0 && failed == len(chunks) {
+		return nil, errors.Join(errs...)
+	}
+
+	return response, nil
+}
+
+func (s *Whatsmiau) numberExistsChunk(ctx context.Context, client *whatsmeow.Client, id string, numbers []string) ([]Exists, error) {
+	ctx, c := context.WithTimeout(ctx, env.Env.NumberExistsChunkTimeout)
+	defer c()
+
+	resp, err := s.onWhatsApp(ctx, client, id, numbers)
 	if err != nil {
 		return nil, err
 	}
 
-	var results []Exists
+	results := make([]Exists, 0, len(resp))
 	for _, item := range resp {
-		jid, lid := s.GetJidLid(ctx, data.InstanceID, item.JID)
-
 		results = append(results, Exists{
 			Exists: item.IsIn,
-			Jid:    jid,
-			Lid:    lid,
-			Number: item.Query,
+			Jid:    item.JID,
+			Lid:    item.LID,
+			Number: item.Phone,
 		})
 	}
 
 	return results, nil
 }
+
+type OnWhatsAppResult struct {
+	Phone string `json:"phone"` // as given
+	JID   string `json:"jid,omitempty"`
+	LID   string `json:"lid,omitempty"`
+	IsIn  bool   `json:"isIn"`
+}
+
+// IsOnWhatsApp checks all phones in a single query, results keep the input
+// order. Phones may carry formatting like +55 (11) 99999-9999.
+func (s *Whatsmiau) IsOnWhatsApp(ctx context.Context, id string, phones []string) ([]OnWhatsAppResult, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	return s.onWhatsApp(ctx, client, id, phones)
+}
+
+func (s *Whatsmiau) onWhatsApp(ctx context.Context, client *whatsmeow.Client, id string, phones []string) ([]OnWhatsAppResult, error) {
+	query := make([]string, 0, len(phones))
+	for _, phone := range phones {
+		if digits := onlyDigits(phone); digits != "" {
+			query = append(query, "+"+digits)
+		}
+	}
+
+	results := make([]OnWhatsAppResult, len(phones))
+	for i, phone := range phones {
+		results[i].Phone = phone
+	}
+	if len(query) == 0 {
+		return results, nil
+	}
+
+	resp, err := client.IsOnWhatsApp(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// the server answers in any order and skips some queries, match them back by digits
+	byPhone := make(map[string]types.IsOnWhatsAppResponse, len(resp))
+	for _, item := range resp {
+		byPhone[onlyDigits(item.Query)] = item
+	}
+
+	for i, phone := range phones {
+		item, ok := byPhone[onlyDigits(phone)]
+		if !ok {
+			continue
+		}
+
+		results[i].IsIn = item.IsIn
+		results[i].JID, results[i].LID = s.GetJidLid(ctx, id, item.JID)
+	}
+
+	return results, nil
+}
+
+func onlyDigits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, value)
+}
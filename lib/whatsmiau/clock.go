@@ -0,0 +1,52 @@
+package whatsmiau
+
+import (
+	"errors"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/lib/metrics"
+	"go.uber.org/zap"
+)
+
+var ErrNoClockSample = errors.New("no message sent yet to sample the server clock")
+
+type ClockSkew struct {
+	InstanceID string        `json:"instance_id"`
+	Offset     time.Duration `json:"offset"`     // server time minus local time
+	RoundTrip  time.Duration `json:"round_trip"` // of the sampled request
+	SampledAt  time.Time     `json:"sampled_at"`
+}
+
+func (s *Whatsmiau) sampleClock(instanceID string, start, end, serverTime time.Time) {
+	if serverTime.IsZero() {
+		return
+	}
+
+	roundTrip := end.Sub(start)
+	// the server timestamp has second precision, so the offset is only accurate to ~1s
+	offset := serverTime.Sub(start.Add(roundTrip / 2))
+	s.clockSkews.Store(instanceID, ClockSkew{
+		InstanceID: instanceID,
+		Offset:     offset,
+		RoundTrip:  roundTrip,
+		SampledAt:  end,
+	})
+
+	metrics.ClockSkew.WithLabelValues(metrics.Instance(instanceID)).Set(offset.Seconds())
+	if threshold := env.Env.ClockSkewWarnThreshold; threshold > 0 && offset.Abs() > threshold {
+		metrics.ClockSkewExceeded.WithLabelValues(metrics.Instance(instanceID)).Inc()
+		zap.L().Warn("clock skew with whatsapp server above threshold", zap.String("instance", instanceID), zap.Duration("offset", offset), zap.Duration("threshold", threshold))
+	}
+}
+
+// ClockSkew reports the offset between the local clock and the WhatsApp server
+// clock measured on the last message sent by the instance
+func (s *Whatsmiau) ClockSkew(id string) (*ClockSkew, error) {
+	skew, ok := s.clockSkews.Load(id)
+	if !ok {
+		return nil, ErrNoClockSample
+	}
+
+	return &skew, nil
+}
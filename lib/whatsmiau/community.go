@@ -0,0 +1,128 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var ErrNotCommunity = errors.New("group is not a community")
+
+// GetSubGroups lists the groups linked to the community, its announcement
+// group included
+func (s *Whatsmiau) GetSubGroups(ctx context.Context, id string, community types.JID) ([]types.GroupLinkTarget, error) {
+	client, err := s.communityClient(ctx, id, community)
+	if err != nil {
+		return nil, err
+	}
+
+	subGroups, err := client.GetSubGroups(ctx, community)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]types.GroupLinkTarget, 0, len(subGroups))
+	for _, group := range subGroups {
+		result = append(result, *group)
+	}
+
+	return result, nil
+}
+
+// LinkGroup moves an existing group into the community, the instance must
+// admin both
+func (s *Whatsmiau) LinkGroup(ctx context.Context, id string, community, group types.JID) error {
+	client, err := s.communityClient(ctx, id, community)
+	if err != nil {
+		return err
+	}
+
+	if group.Server != types.GroupServer {
+		return ErrInvalidGroup
+	}
+
+	if err := client.LinkGroup(ctx, community, group); err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(id, community)
+	s.invalidateGroupInfo(id, group)
+	return nil
+}
+
+// UnlinkGroup removes the group from the community, the group stays as a
+// standalone group
+func (s *Whatsmiau) UnlinkGroup(ctx context.Context, id string, community, group types.JID) error {
+	client, err := s.communityClient(ctx, id, community)
+	if err != nil {
+		return err
+	}
+
+	if group.Server != types.GroupServer {
+		return ErrInvalidGroup
+	}
+
+	if err := client.UnlinkGroup(ctx, community, group); err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(id, community)
+	s.invalidateGroupInfo(id, group)
+	return nil
+}
+
+func (s *Whatsmiau) communityClient(ctx context.Context, id string, community types.JID) (*whatsmeow.Client, error) {
+	client, err := s.groupClient(id, community)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkCommunity(ctx, id, client, community); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// checkCommunity fails with ErrNotCommunity when the group isn't a community
+func (s *Whatsmiau) checkCommunity(ctx context.Context, id string, client *whatsmeow.Client, community types.JID) error {
+	info, err := s.groupInfo(ctx, id, client, community)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsParent {
+		return ErrNotCommunity
+	}
+
+	return nil
+}
+
+// communityOf returns the parent community of the group from the cached group
+// info, empty for standalone groups or when the info can't be fetched
+func (s *Whatsmiau) communityOf(ctx context.Context, id string, group types.JID) types.JID {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return types.EmptyJID
+	}
+
+	info, err := s.groupInfo(ctx, id, client, group)
+	if err != nil {
+		return types.EmptyJID
+	}
+
+	return info.LinkedParentJID
+}
+
+// invalidateLinkedGroup drops the cached info of the other side of a community
+// link or unlink
+func (s *Whatsmiau) invalidateLinkedGroup(id string, e *events.GroupInfo) {
+	for _, change := range []*types.GroupLinkChange{e.Link, e.Unlink} {
+		if change != nil {
+			s.invalidateGroupInfo(id, change.Group.JID)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var (
+	testCommunity = types.NewJID("120363000000000001", types.GroupServer)
+	testGroup     = types.NewJID("120363000000000002", types.GroupServer)
+)
+
+// newCommunityTestMiau has testCommunity and testGroup, linked to it, cached
+func newCommunityTestMiau(t *testing.T) *Whatsmiau {
+	t.Helper()
+	s := newTestMiau()
+	s.groupInfos = xsync.NewMap[string, *types.GroupInfo]()
+	s.clients.Store("instance", newTestClient(t))
+	s.groupInfos.Store(groupInfoKey("instance", testCommunity), &types.GroupInfo{
+		JID:         testCommunity,
+		GroupParent: types.GroupParent{IsParent: true},
+	})
+	s.groupInfos.Store(groupInfoKey("instance", testGroup), &types.GroupInfo{
+		JID:               testGroup,
+		GroupLinkedParent: types.GroupLinkedParent{LinkedParentJID: testCommunity},
+	})
+	return s
+}
+
+func TestCheckCommunity(t *testing.T) {
+	s := newCommunityTestMiau(t)
+	client, _ := s.clients.Load("instance")
+
+	if err := s.checkCommunity(context.Background(), "instance", client, testCommunity); err != nil {
+		t.Errorf("community err = %v", err)
+	}
+	if err := s.checkCommunity(context.Background(), "instance", client, testGroup); !errors.Is(err, ErrNotCommunity) {
+		t.Errorf("group err = %v, want ErrNotCommunity", err)
+	}
+}
+
+func TestGroupUpdateCommunity(t *testing.T) {
+	s := newCommunityTestMiau(t)
+	other := types.NewJID("120363000000000003", types.GroupServer)
+
+	tests := []struct {
+		name string
+		evt  *events.GroupInfo
+		want string
+	}{
+		{
+			name: "linked to a community",
+			evt: &events.GroupInfo{JID: other, Link: &types.GroupLinkChange{
+				Type:  types.GroupLinkChangeTypeParent,
+				Group: types.GroupLinkTarget{JID: testCommunity},
+			}},
+			want: testCommunity.String(),
+		},
+		{
+			// the cached info still has the community until it's invalidated
+			name: "unlinked from the community",
+			evt: &events.GroupInfo{JID: testGroup, Unlink: &types.GroupLinkChange{
+				Type:  types.GroupLinkChangeTypeParent,
+				Group: types.GroupLinkTarget{JID: testCommunity},
+			}},
+		},
+		{
+			name: "renamed in a community",
+			evt:  &events.GroupInfo{JID: testGroup, Name: &types.GroupName{Name: "Team"}},
+			want: testCommunity.String(),
+		},
+	}
+
+	instance := &models.Instance{ID: "instance"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := s.normalizeEvent(context.Background(), "instance", instance, tt.evt)
+			if event == nil {
+				t.Fatal("normalizeEvent() = nil")
+			}
+			if got := event.Payload.(*models.GroupUpdatePayload).Community; got != tt.want {
+				t.Errorf("community = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
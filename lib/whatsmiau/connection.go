@@ -0,0 +1,153 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+func (s *Whatsmiau) handleConnectedEvent(id string, instance *models.Instance, eventMap map[string]bool) {
+	// presence is reset by the server on every new session, even transient ones
+	s.startAlwaysOnline(id, instance)
+	s.clearAccountBlock(id, instance)
+
+	if timer, ok := s.disconnectTimers.LoadAndDelete(id); ok && timer.Stop() {
+		// the disconnect was shorter than the debounce window, consumers never saw it
+		zap.L().Debug("suppressed transient disconnect", zap.String("id", id))
+		return
+	}
+
+	s.emitConnectionUpdate(instance, eventMap, Connected, "")
+}
+
+// handleDisconnectedEvent only emits after the client stayed disconnected for
+// the whole debounce window, so flapping networks don't spam consumers
+func (s *Whatsmiau) handleDisconnectedEvent(id string, instance *models.Instance, eventMap map[string]bool) {
+	s.stopAlwaysOnline(id)
+	s.startReconnect(id)
+
+	window := env.Env.ConnectionDebounceWindow
+	if window <= 0 {
+		s.emitConnectionUpdate(instance, eventMap, Closed, "")
+		return
+	}
+
+	s.disconnectTimers.LoadOrCompute(id, func() (*time.Timer, bool) {
+		return time.AfterFunc(window, func() {
+			s.disconnectTimers.Delete(id)
+			s.emitConnectionUpdate(instance, eventMap, Closed, "")
+		}), false
+	})
+}
+
+// handleStreamReplacedEvent runs when another connection took over the session,
+// whatsmeow disconnects without a Disconnected event and reconnecting would
+// only kick the other connection out
+func (s *Whatsmiau) handleStreamReplacedEvent(id string, instance *models.Instance, eventMap map[string]bool) {
+	s.stopAlwaysOnline(id)
+	s.stopReconnect(id)
+	s.cancelDisconnectTimer(id)
+	s.emitConnectionUpdate(instance, eventMap, Closed, "stream_replaced")
+}
+
+// handleLoggedOut cleans up like Logout when the device is unlinked from the
+// phone or by the server, a failed device deletion still drops the client so
+// the instance can pair again
+func (s *Whatsmiau) handleLoggedOut(id string, instance *models.Instance, e *events.LoggedOut, eventMap map[string]bool) {
+	if client, ok := s.clients.Load(id); ok {
+		if err := s.deleteDeviceIfExists(context.Background(), client); err != nil {
+			zap.L().Error("failed to delete device for instance", zap.String("instance", id), zap.Error(err))
+		}
+	}
+
+	s.clients.Delete(id)
+	s.qrCache.Delete(id)
+	s.stopAlwaysOnline(id)
+	s.forgetPresenceSubs(id)
+	s.stopReconnect(id)
+	s.cancelDisconnectTimer(id)
+	s.emitConnectionUpdate(instance, eventMap, LoggedOut, e.Reason.String())
+}
+
+// cancelDisconnectTimer drops a debounced disconnect, the caller emits the
+// final state itself
+func (s *Whatsmiau) cancelDisconnectTimer(id string) {
+	if timer, ok := s.disconnectTimers.LoadAndDelete(id); ok {
+		timer.Stop()
+	}
+}
+
+func (s *Whatsmiau) emitConnectionUpdate(instance *models.Instance, eventMap map[string]bool, state Status, reason string) {
+	if !eventMap["CONNECTION_UPDATE"] {
+		return
+	}
+
+	wookData := &WookEvent[WookConnectionUpdateData]{
+		Instance: instance.ID,
+		Data: &WookConnectionUpdateData{
+			Instance: instance.ID,
+			State:    state,
+			Reason:   reason,
+		},
+		DateTime: time.Now(),
+		Event:    WookConnectionUpdate,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// emitQrCodeScanned fills the gap between the QR code being scanned and the
+// session being ready, which can take a few seconds while keys are exchanged.
+// The connection.update with state open follows once the client connects.
+func (s *Whatsmiau) emitQrCodeScanned(id string, jid string) {
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return
+	}
+
+	if !eventMapOf(instance)["QRCODE_SCANNED"] {
+		return
+	}
+
+	wookData := &WookEvent[WookQrCodeScannedData]{
+		Instance: instance.ID,
+		Data: &WookQrCodeScannedData{
+			Instance:  instance.ID,
+			RemoteJid: jid,
+			State:     Connecting,
+		},
+		DateTime: time.Now(),
+		Event:    WookQrCodeScanned,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// emitQrCodeError tells consumers the QR code login couldn't start, so the user
+// can be prompted to connect again
+func (s *Whatsmiau) emitQrCodeError(id string, err error) {
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return
+	}
+
+	if !eventMapOf(instance)["QRCODE_ERROR"] {
+		return
+	}
+
+	wookData := &WookEvent[WookQrCodeErrorData]{
+		Instance: instance.ID,
+		Data: &WookQrCodeErrorData{
+			Instance: instance.ID,
+			Error:    err.Error(),
+		},
+		DateTime: time.Now(),
+		Event:    WookQrCodeError,
+	}
+
+	s.emit(wookData, instance)
+}
@@ -6,5 +6,18 @@ const (
 	Connected  = "open"
 	Connecting = "connecting"
 	QrCode     = "qr-code"
+	Pairing    = "pairing" // pairing code issued, waiting for the user to type it
 	Closed     = "closed"
+	LoggedOut  = "logged_out"
+
+	Banned         = "banned"
+	TemporaryBlock = "temporary_block"
+)
+
+// Origin flags messages sent by the account itself
+type Origin string
+
+const (
+	OriginSelf   Origin = "self"   // sent through this instance API
+	OriginDevice Origin = "device" // sent from the phone or another linked device
 )
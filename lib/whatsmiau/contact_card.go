@@ -0,0 +1,160 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidContact = errors.New("invalid contact")
+
+type VCard struct {
+	FullName     string       `json:"full_name"` // display name, built from the first and last name when empty
+	FirstName    string       `json:"first_name"`
+	LastName     string       `json:"last_name"`
+	Organization string       `json:"organization"`
+	Phones       []VCardPhone `json:"phones"`
+	Emails       []string     `json:"emails"`
+}
+
+type VCardPhone struct {
+	Number string `json:"number"` // digits with country code, ex: 5511999999999
+	Type   string `json:"type"`   // vCard TEL type, defaults to CELL
+}
+
+// SendContact sends one or more contact cards, several contacts go in a single
+// contacts array message. Returns the message id.
+func (s *Whatsmiau) SendContact(ctx context.Context, id string, to types.JID, contacts []VCard) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := buildContactMessage(contacts)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	contextInfo := s.defaultExpirationContext(id, to)
+	if message.ContactMessage != nil {
+		message.ContactMessage.ContextInfo = contextInfo
+	} else {
+		message.ContactsArrayMessage.ContextInfo = contextInfo
+	}
+
+	res, err := s.sendReserved(ctx, id, client, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+func buildContactMessage(contacts []VCard) (*waE2E.Message, error) {
+	if len(contacts) == 0 {
+		return nil, fmt.Errorf("%w: at least one contact is required", ErrInvalidContact)
+	}
+
+	messages := make([]*waE2E.ContactMessage, 0, len(contacts))
+	for i, contact := range contacts {
+		card, err := contact.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("contact %d: %w", i, err)
+		}
+
+		messages = append(messages, &waE2E.ContactMessage{
+			DisplayName: proto.String(contact.displayName()),
+			Vcard:       proto.String(card),
+		})
+	}
+
+	if len(messages) == 1 {
+		return &waE2E.Message{ContactMessage: messages[0]}, nil
+	}
+
+	return &waE2E.Message{
+		ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+			DisplayName: proto.String(fmt.Sprintf("%d contacts", len(messages))),
+			Contacts:    messages,
+		},
+	}, nil
+}
+
+// Encode renders the contact as a vCard 3.0. Phones carry the waid parameter,
+// which makes the app show the message and add buttons.
+func (c VCard) Encode() (string, error) {
+	name := c.displayName()
+	if name == "" {
+		return "", fmt.Errorf("%w: a name is required", ErrInvalidContact)
+	}
+
+	if len(c.Phones) == 0 {
+		return "", fmt.Errorf("%w: at least one phone is required", ErrInvalidContact)
+	}
+
+	card := vcard.Card{}
+	card.SetValue(vcard.FieldVersion, "3.0")
+	card.SetValue(vcard.FieldFormattedName, name)
+	card.SetName(&vcard.Name{
+		FamilyName: escapeComponent(c.LastName),
+		GivenName:  escapeComponent(c.FirstName),
+	})
+	if c.Organization != "" {
+		card.SetValue(vcard.FieldOrganization, escapeComponent(c.Organization))
+	}
+
+	for _, phone := range c.Phones {
+		digits := onlyDigits(phone.Number)
+		if digits == "" {
+			return "", fmt.Errorf("%w: invalid phone %q", ErrInvalidContact, phone.Number)
+		}
+
+		kind := phone.Type
+		if kind == "" {
+			kind = vcard.TypeCell
+		}
+
+		card.Add(vcard.FieldTelephone, &vcard.Field{
+			Value:  "+" + digits,
+			Params: vcard.Params{vcard.ParamType: {strings.ToUpper(kind)}, "waid": {digits}},
+		})
+	}
+
+	for _, email := range c.Emails {
+		card.AddValue(vcard.FieldEmail, email)
+	}
+
+	var buf strings.Builder
+	if err := vcard.NewEncoder(&buf).Encode(card); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func (c VCard) displayName() string {
+	if c.FullName != "" {
+		return c.FullName
+	}
+
+	return strings.TrimSpace(c.FirstName + " " + c.LastName)
+}
+
+// escapeComponent replaces the separator of structured vCard values like N and
+// ORG, the encoder doesn't escape it and would split the value
+func escapeComponent(value string) string {
+	return strings.ReplaceAll(value, ";", ",")
+}
@@ -0,0 +1,97 @@
+package whatsmiau
+
+import (
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+type InstanceDebug struct {
+	ID          string        `json:"id"`
+	Status      Status        `json:"status"`
+	Webhook     *WebhookDebug `json:"webhook,omitempty"`
+	Proxy       *ProxyDebug   `json:"proxy,omitempty"`
+	ActiveProxy string        `json:"activeProxy,omitempty"` // url the client dials through, password redacted, may come from proxyPool
+}
+
+// ProxyDebug identifies the proxy without leaking it, the username is kept
+// partially since vendors usually encode the account or session there
+type ProxyDebug struct {
+	Protocol string `json:"protocol"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Username string `json:"username,omitempty"`
+	NoMedia  bool   `json:"noMedia"`
+}
+
+type WebhookDebug struct {
+	Url                 string     `json:"url,omitempty"`
+	BreakerState        string     `json:"breakerState"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+}
+
+// DebugInstance reports internal state useful to operators troubleshooting an instance
+func (s *Whatsmiau) DebugInstance(id string) (*InstanceDebug, error) {
+	status, err := s.Status(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InstanceDebug{
+		ID:     id,
+		Status: status,
+	}
+
+	instanceFound := s.getInstanceCached(id)
+	if instanceFound == nil {
+		return result, nil
+	}
+
+	result.Proxy = proxyDebug(instanceFound.InstanceProxy)
+	result.ActiveProxy = s.GetActiveProxy(id)
+	if len(instanceFound.Webhook.Url) == 0 {
+		return result, nil
+	}
+
+	webhook := &WebhookDebug{
+		Url:          instanceFound.Webhook.Url,
+		BreakerState: breakerClosed.String(),
+	}
+	if breaker, ok := s.breakers.Load(instanceFound.Webhook.Url); ok {
+		state, failures, openedAt := breaker.snapshot()
+		webhook.BreakerState = state.String()
+		webhook.ConsecutiveFailures = failures
+		if state != breakerClosed {
+			webhook.OpenedAt = &openedAt
+		}
+	}
+	result.Webhook = webhook
+
+	return result, nil
+}
+
+// proxyDebug mirrors configProxy, which is what the client was configured with
+func proxyDebug(proxy models.InstanceProxy) *ProxyDebug {
+	if len(proxy.ProxyHost) <= 0 {
+		return nil
+	}
+
+	return &ProxyDebug{
+		Protocol: proxy.ProxyProtocol,
+		Host:     proxy.ProxyHost,
+		Port:     proxy.ProxyPort,
+		Username: maskSecret(proxy.ProxyUsername),
+		NoMedia:  env.Env.ProxyNoMedia,
+	}
+}
+
+func maskSecret(value string) string {
+	if len(value) <= 4 {
+		return strings.Repeat("*", len(value))
+	}
+
+	return value[:2] + strings.Repeat("*", len(value)-4) + value[len(value)-2:]
+}
@@ -0,0 +1,166 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type DeleteMessageRequest struct {
+	InstanceID  string     `json:"instance_id"`
+	RemoteJID   *types.JID `json:"remote_jid"`
+	MessageID   string     `json:"message_id"`
+	ForEveryone bool       `json:"for_everyone"` // false only hides the message on our devices
+	// used to revoke messages missing from the message store
+	FromMe      bool       `json:"from_me"`
+	Participant *types.JID `json:"participant"` // sender of a group message revoked by an admin
+}
+
+type DeleteMessageResponse struct {
+	ID        string    `json:"id,omitempty"` // revoke message id, empty when deleted for me
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeleteMessage revokes a message for everyone or deletes it only on our
+// devices. The message key is read from the message store (STORE_MESSAGES);
+// revokes can also be built from FromMe/Participant, deleting for me can't as
+// it needs the original timestamp.
+func (s *Whatsmiau) DeleteMessage(ctx context.Context, data *DeleteMessageRequest) (*DeleteMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if client.Store == nil || client.Store.ID == nil {
+		return nil, ErrNotLoggedIn
+	}
+
+	chat := s.resolveRecipient(ctx, data.InstanceID, *data.RemoteJID)
+	stored, err := s.getStoredMessage(ctx, data.InstanceID, data.MessageID)
+	if err != nil && !errors.Is(err, ErrMessageNotFound) {
+		return nil, err
+	}
+	if stored != nil && !s.inChat(ctx, data.InstanceID, stored, *data.RemoteJID) {
+		return nil, ErrMessageNotFound
+	}
+
+	fromMe, sender := data.FromMe, types.EmptyJID
+	if data.Participant != nil {
+		sender = data.Participant.ToNonAD()
+	}
+	if stored != nil {
+		fromMe = stored.FromMe
+		if parsed, err := types.ParseJID(stored.Sender); err == nil {
+			sender = parsed.ToNonAD()
+		}
+	} else if !data.ForEveryone || (!fromMe && sender.IsEmpty()) {
+		return nil, ErrMessageNotFound
+	}
+
+	var result DeleteMessageResponse
+	if data.ForEveryone {
+		revokeSender := sender
+		if fromMe {
+			revokeSender = types.EmptyJID
+		}
+
+		res, err := s.sendMessage(ctx, data.InstanceID, client, chat, client.BuildRevoke(chat, revokeSender, data.MessageID))
+		if err != nil {
+			return nil, err
+		}
+		result = DeleteMessageResponse{ID: res.ID, CreatedAt: res.Timestamp}
+	} else {
+		if fromMe {
+			sender = client.Store.ID.ToNonAD()
+		}
+		if err := client.SendAppState(ctx, buildDeleteForMe(chat, sender, data.MessageID, fromMe, stored.Timestamp)); err != nil {
+			return nil, err
+		}
+		result = DeleteMessageResponse{CreatedAt: time.Now()}
+	}
+
+	s.emitMessageDeleted(ctx, data.InstanceID, chat, data.MessageID, fromMe, sender, !data.ForEveryone)
+	return &result, nil
+}
+
+func (s *Whatsmiau) getStoredMessage(ctx context.Context, instanceID, messageID string) (*models.StoredMessage, error) {
+	if !env.Env.StoreMessages {
+		return nil, ErrMessageNotFound
+	}
+
+	stored, err := s.messages.Get(ctx, instanceID, messageID)
+	if err != nil {
+		if errors.Is(err, messages.ErrorNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// buildDeleteForMe mirrors appstate.BuildStar, whatsmeow has no builder for it.
+// The index is the same as the star one, the sender is "0" outside of groups.
+func buildDeleteForMe(chat, sender types.JID, messageID types.MessageID, fromMe bool, timestamp time.Time) appstate.PatchInfo {
+	isFromMe := "0"
+	if fromMe {
+		isFromMe = "1"
+	}
+	senderJID := sender.String()
+	if chat.Server != types.GroupServer || sender.IsEmpty() {
+		senderJID = "0"
+	}
+
+	return appstate.PatchInfo{
+		Type: appstate.WAPatchRegularHigh,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexDeleteMessageForMe, chat.String(), messageID, isFromMe, senderJID},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				DeleteMessageForMeAction: &waSyncAction.DeleteMessageForMeAction{
+					DeleteMedia:      proto.Bool(true),
+					MessageTimestamp: proto.Int64(timestamp.UnixMilli()),
+				},
+			},
+		}},
+	}
+}
+
+func (s *Whatsmiau) emitMessageDeleted(ctx context.Context, id string, chat types.JID, messageID string, fromMe bool, sender types.JID, forMe bool) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !eventMapOf(instance)["MESSAGES_DELETE"] {
+		return
+	}
+
+	jid, lid := s.GetJidLid(ctx, id, chat)
+	key := &WookKey{
+		RemoteJid: jid,
+		RemoteLid: lid,
+		FromMe:    fromMe,
+		Id:        messageID,
+	}
+	if chat.Server == types.GroupServer && !sender.IsEmpty() {
+		key.Participant = sender.String()
+	}
+
+	s.emit(&WookEvent[WookMessageDeleteData]{
+		Instance: instance.ID,
+		Data: &WookMessageDeleteData{
+			Key:        key,
+			ForMe:      forMe,
+			InstanceId: instance.ID,
+			Origin:     OriginSelf,
+		},
+		DateTime: time.Now(),
+		Event:    WookMessagesDelete,
+	}, instance)
+}
@@ -0,0 +1,40 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestDeleteMessageFromAnotherChat(t *testing.T) {
+	previous := env.Env.StoreMessages
+	env.Env.StoreMessages = true
+	t.Cleanup(func() { env.Env.StoreMessages = previous })
+
+	s, _ := newLidTestMiau(t, time.Hour)
+	s.messages = memoryMessages{"instance:A1": {
+		InstanceID: "instance",
+		ID:         "A1",
+		Chat:       testPN.String(),
+		Sender:     testOwnJID.String(),
+		FromMe:     true,
+		Timestamp:  time.Now(),
+	}}
+
+	other := types.NewJID("5511922222222", types.DefaultUserServer)
+	for _, forEveryone := range []bool{true, false} {
+		_, err := s.DeleteMessage(context.Background(), &DeleteMessageRequest{
+			InstanceID:  "instance",
+			RemoteJID:   &other,
+			MessageID:   "A1",
+			ForEveryone: forEveryone,
+		})
+		if !errors.Is(err, ErrMessageNotFound) {
+			t.Errorf("for everyone %t err = %v, want ErrMessageNotFound", forEveryone, err)
+		}
+	}
+}
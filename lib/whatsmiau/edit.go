@@ -0,0 +1,209 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// editWindow is how long WhatsApp clients show edits of a message, whatsmeow
+// allows a few more minutes
+const editWindow = 15 * time.Minute
+
+var (
+	ErrEditWindowExpired = errors.New("message can no longer be edited")
+	ErrMessageNotFound   = errors.New("message not found")
+	ErrNotOwnMessage     = errors.New("message was not sent by this instance")
+	ErrNotMediaMessage   = errors.New("message has no caption to edit")
+	ErrNotTextMessage    = errors.New("message has no text to edit")
+)
+
+type EditMediaCaptionRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	MessageID  string     `json:"message_id"`
+	Caption    string     `json:"caption"`
+}
+
+type EditMessageResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EditMediaCaption edits the caption of an image, video or document we sent.
+// The original message is read from the recent message cache or the message
+// store (STORE_MESSAGES) to check it's ours, in the chat, has a caption and is
+// still inside the 15 minutes edit window.
+func (s *Whatsmiau) EditMediaCaption(ctx context.Context, data *EditMediaCaptionRequest) (*EditMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	original, message, err := s.getOwnMessage(ctx, data.InstanceID, *data.RemoteJID, data.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := captionEdit(original, message, data.Caption)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, client.BuildEdit(*data.RemoteJID, data.MessageID, content))
+	if err != nil {
+		return nil, err
+	}
+
+	jid, lid := s.GetJidLid(ctx, data.InstanceID, *data.RemoteJID)
+	s.emitMessageEdited(data.InstanceID, &WookMessageEditedData{
+		Key: &WookKey{
+			RemoteJid: jid,
+			RemoteLid: lid,
+			FromMe:    true,
+			Id:        data.MessageID,
+		},
+		Text:       data.Caption,
+		EditId:     res.ID,
+		InstanceId: data.InstanceID,
+		Origin:     OriginSelf,
+	})
+
+	return &EditMessageResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+type EditMessageRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	MessageID  string     `json:"message_id"`
+	Text       string     `json:"text"`
+}
+
+// EditMessage replaces the text of a text message we sent, with the same
+// checks as EditMediaCaption: the message must be known, ours, in the chat and
+// inside the 15 minutes edit window
+func (s *Whatsmiau) EditMessage(ctx context.Context, data *EditMessageRequest) (*EditMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	original, _, err := s.getOwnMessage(ctx, data.InstanceID, *data.RemoteJID, data.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var content *waE2E.Message
+	switch original.Type {
+	case "conversation":
+		content = &waE2E.Message{Conversation: proto.String(data.Text)}
+	case "extendedTextMessage":
+		content = &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: proto.String(data.Text)}}
+	default:
+		return nil, ErrNotTextMessage
+	}
+
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, client.BuildEdit(*data.RemoteJID, data.MessageID, content))
+	if err != nil {
+		return nil, err
+	}
+
+	jid, lid := s.GetJidLid(ctx, data.InstanceID, *data.RemoteJID)
+	s.emitMessageEdited(data.InstanceID, &WookMessageEditedData{
+		Key: &WookKey{
+			RemoteJid: jid,
+			RemoteLid: lid,
+			FromMe:    true,
+			Id:        data.MessageID,
+		},
+		Text:       data.Text,
+		EditId:     res.ID,
+		InstanceId: data.InstanceID,
+		Origin:     OriginSelf,
+	})
+
+	return &EditMessageResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// getOwnMessage loads a message we sent to chat that can still be edited, with
+// its content when it's still in the recent message cache
+func (s *Whatsmiau) getOwnMessage(ctx context.Context, instanceID string, chat types.JID, messageID string) (*models.StoredMessage, *waE2E.Message, error) {
+	original, message, err := s.originalMessage(ctx, instanceID, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !s.inChat(ctx, instanceID, original, chat) {
+		return nil, nil, ErrMessageNotFound
+	}
+
+	if !original.FromMe {
+		return nil, nil, ErrNotOwnMessage
+	}
+
+	if time.Since(original.Timestamp) > editWindow {
+		return nil, nil, ErrEditWindowExpired
+	}
+
+	return original, message, nil
+}
+
+// captionEdit builds the edited content for a media message, reusing the
+// original content when available so clients keep rendering the same media. The
+// original is cloned, it's shared with the recent message cache.
+func captionEdit(original *models.StoredMessage, message *waE2E.Message, caption string) (*waE2E.Message, error) {
+	if message == nil {
+		message = &waE2E.Message{}
+	} else {
+		message = proto.Clone(message).(*waE2E.Message)
+	}
+
+	switch original.Type {
+	case "imageMessage":
+		if message.ImageMessage == nil {
+			message = &waE2E.Message{ImageMessage: &waE2E.ImageMessage{}}
+		}
+		message.ImageMessage.Caption = proto.String(caption)
+		return &waE2E.Message{ImageMessage: message.ImageMessage}, nil
+	case "videoMessage":
+		if message.VideoMessage == nil {
+			message = &waE2E.Message{VideoMessage: &waE2E.VideoMessage{}}
+		}
+		message.VideoMessage.Caption = proto.String(caption)
+		return &waE2E.Message{VideoMessage: message.VideoMessage}, nil
+	case "documentMessage":
+		if message.DocumentMessage == nil {
+			message = &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{}}
+		}
+		message.DocumentMessage.Caption = proto.String(caption)
+		return &waE2E.Message{DocumentMessage: message.DocumentMessage}, nil
+	}
+
+	return nil, ErrNotMediaMessage
+}
+
+func (s *Whatsmiau) emitMessageEdited(id string, data *WookMessageEditedData) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !eventMapOf(instance)["MESSAGES_EDITED"] {
+		return
+	}
+
+	s.emit(&WookEvent[WookMessageEditedData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: time.Now(),
+		Event:    WookMessagesEdited,
+	}, instance)
+}
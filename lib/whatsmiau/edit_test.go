@@ -0,0 +1,71 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCaptionEditKeepsOriginal(t *testing.T) {
+	original := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:    proto.String("before"),
+		DirectPath: proto.String("/v/t62/image"),
+	}}
+
+	edited, err := captionEdit(&models.StoredMessage{Type: "imageMessage"}, original, "after")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.GetImageMessage().GetCaption() != "after" || edited.GetImageMessage().GetDirectPath() != "/v/t62/image" {
+		t.Errorf("edited = %v, want the same media with the new caption", edited)
+	}
+	if original.GetImageMessage().GetCaption() != "before" {
+		t.Errorf("original caption = %q, the cached message was changed", original.GetImageMessage().GetCaption())
+	}
+
+	if _, err := captionEdit(&models.StoredMessage{Type: "conversation"}, nil, "after"); !errors.Is(err, ErrNotMediaMessage) {
+		t.Errorf("text err = %v, want ErrNotMediaMessage", err)
+	}
+}
+
+func TestGetOwnMessage(t *testing.T) {
+	previous := env.Env
+	env.Env.StoreMessages = false
+	env.Env.RecentMessageCacheTTL = time.Hour
+	t.Cleanup(func() { env.Env = previous })
+
+	s := newTestMiau()
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+	other := types.NewJID("5511922222222", types.DefaultUserServer)
+	remember := func(id string, fromMe bool, at time.Time) {
+		s.rememberMessage("instance", types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: testOwnJID, IsFromMe: fromMe},
+			ID:            id,
+			Timestamp:     at,
+		}, &waE2E.Message{Conversation: proto.String("hello")})
+	}
+	remember("OWN", true, time.Now())
+	remember("THEIRS", false, time.Now())
+	remember("OLD", true, time.Now().Add(-editWindow-time.Minute))
+
+	ctx := context.Background()
+	if _, _, err := s.getOwnMessage(ctx, "instance", chat, "OWN"); err != nil {
+		t.Errorf("own message err = %v", err)
+	}
+	if _, _, err := s.getOwnMessage(ctx, "instance", other, "OWN"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("other chat err = %v, want ErrMessageNotFound", err)
+	}
+	if _, _, err := s.getOwnMessage(ctx, "instance", chat, "THEIRS"); !errors.Is(err, ErrNotOwnMessage) {
+		t.Errorf("received message err = %v, want ErrNotOwnMessage", err)
+	}
+	if _, _, err := s.getOwnMessage(ctx, "instance", chat, "OLD"); !errors.Is(err, ErrEditWindowExpired) {
+		t.Errorf("old message err = %v, want ErrEditWindowExpired", err)
+	}
+}
@@ -12,7 +12,8 @@ import (
 	"time"
 
 	"github.com/emersion/go-vcard"
-	"github.com/google/uuid"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/lib/metrics"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
@@ -21,11 +22,17 @@ import (
 	"go.mau.fi/whatsmeow/types/events"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
 )
 
 type emitter struct {
-	url  string
-	data any
+	instance   string
+	url        string
+	headers    map[string]string
+	secret     string
+	template   string
+	data       any
+	enqueuedAt time.Time
 }
 
 func (s *Whatsmiau) getInstance(id string) *models.Instance {
@@ -78,62 +85,242 @@ func (s *Whatsmiau) getInstanceCached(id string) *models.Instance {
 
 func (s *Whatsmiau) startEmitter() {
 	for event := range s.emitter {
+		latency := time.Since(event.enqueuedAt)
+		metrics.EmitterLatency.Observe(latency.Seconds())
+		if threshold := env.Env.EmitterLatencyWarnThreshold; threshold > 0 && latency > threshold {
+			zap.L().Warn("emitter is falling behind", zap.String("instance", event.instance), zap.Duration("latency", latency), zap.Int("queued", len(s.emitter)))
+		}
+
 		data, err := json.Marshal(event.data)
 		if err != nil {
 			zap.L().Error("failed to marshal event", zap.Error(err))
-			return
+			continue
 		}
 
-		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, event.url, bytes.NewReader(data))
+		payloads, err := fitPayload(data, env.Env.EmitterMaxPayloadSize)
 		if err != nil {
-			zap.L().Error("failed to create request", zap.Error(err))
-			return
+			zap.L().Error("failed to fit event payload", zap.String("instance", event.instance), zap.Int("size", len(data)), zap.Error(err))
+			s.sendToDeadLetter(event, data, err.Error())
+			continue
+		}
+
+		for _, payload := range payloads {
+			s.emitPayload(event, payload)
 		}
+	}
+
+	close(s.emitterDone)
+}
 
-		req.Header.Set("Content-Type", "application/json")
-		resp, err := s.httpClient.Do(req)
+func (s *Whatsmiau) emitPayload(event emitter, data []byte) {
+	if len(event.template) > 0 {
+		rendered, err := s.renderPayload(event.instance, event.template, data)
 		if err != nil {
-			zap.L().Error("failed to send request", zap.Error(err))
+			zap.L().Error("failed to render event payload", zap.String("instance", event.instance), zap.Error(err))
+			s.sendToDeadLetter(event, data, err.Error())
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			res, err := io.ReadAll(resp.Body)
-			if err != nil {
-				zap.L().Error("failed to read response body", zap.Error(err))
-			} else {
-				zap.L().Error("error doing request", zap.Any("response", string(res)), zap.String("url", event.url))
-			}
+		// fitPayload sized the event, the template may have made it bigger
+		if maxSize := env.Env.EmitterMaxPayloadSize; maxSize > 0 && len(rendered) > maxSize {
+			err := fmt.Errorf("rendered event is %d bytes, max is %d", len(rendered), maxSize)
+			zap.L().Error("failed to fit event payload", zap.String("instance", event.instance), zap.Error(err))
+			s.sendToDeadLetter(event, data, err.Error())
+			return
 		}
+		data = rendered
+	}
+
+	if s.queueBehindRetries(event, data) {
+		return
+	}
+
+	if err := s.attemptDelivery(event, data); err != nil {
+		s.retryDelivery(event, data, err)
 	}
 }
 
-func (s *Whatsmiau) emit(body any, url string) {
-	s.emitter <- emitter{url, body}
+// attemptDelivery posts the payload once through the destination breaker, an
+// open breaker fails without sending
+func (s *Whatsmiau) attemptDelivery(event emitter, data []byte) error {
+	breaker := s.getBreaker(event.url)
+	defer s.reportBreaker(event.instance, breaker)
+	if !breaker.allow() {
+		return errBreakerOpen
+	}
+
+	if err := s.deliver(event, data); err != nil {
+		zap.L().Error("failed to deliver event", zap.String("instance", event.instance), zap.String("url", event.url), zap.Error(err))
+		metrics.WebhookDeliveries.WithLabelValues(metrics.Instance(event.instance), "failure").Inc()
+		breaker.failure()
+		return err
+	}
+
+	metrics.WebhookDeliveries.WithLabelValues(metrics.Instance(event.instance), "success").Inc()
+	breaker.success()
+	return nil
+}
+
+func (s *Whatsmiau) deliver(event emitter, data []byte) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, event.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, value := range event.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, event.secret, data)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		res, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("status %d, failed to read response body: %w", resp.StatusCode, err)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(res))
+	}
+
+	return nil
+}
+
+func (s *Whatsmiau) sendToDeadLetter(event emitter, data []byte, reason string) {
+	ctx, c := context.WithTimeout(context.Background(), time.Second*5)
+	defer c()
+
+	if err := s.deadLetters.Push(ctx, event.instance, &models.DeadLetter{
+		InstanceID: event.instance,
+		Url:        event.url,
+		Payload:    data,
+		Reason:     reason,
+		FailedAt:   time.Now(),
+	}); err != nil {
+		zap.L().Error("failed to push event to dead letter", zap.String("instance", event.instance), zap.Error(err))
+	}
+}
+
+func (s *Whatsmiau) reportBreaker(id string, breaker *circuitBreaker) {
+	state, _, _ := breaker.snapshot()
+	metrics.WebhookBreakerState.WithLabelValues(metrics.Instance(id)).Set(float64(state))
+}
+
+func (s *Whatsmiau) emit(body any, instance *models.Instance) {
+	if _, ok := body.(*models.Event); !ok && instance.Webhook.Format == models.WebhookFormatEvent {
+		return
+	}
+
+	url := webhookURL(body, instance)
+	if len(url) == 0 {
+		return
+	}
+
+	s.emitMu.RLock()
+	defer s.emitMu.RUnlock()
+	if s.closing.Load() {
+		zap.L().Warn("dropping event emitted during shutdown", zap.String("instance", instance.ID), zap.String("event", eventKey(body)))
+		return
+	}
+
+	var payloadTemplate string
+	if instance.Webhook.Template != nil {
+		payloadTemplate = *instance.Webhook.Template
+	}
+
+	metrics.EventsEmitted.WithLabelValues(metrics.Instance(instance.ID), eventKey(body)).Inc()
+	s.emitter <- emitter{instance.ID, url, instance.Webhook.Headers, webhookSecret(instance), payloadTemplate, body, time.Now()}
+}
+
+// eventMapOf is the set of webhook.events the instance subscribed to, events
+// emitted outside of Handle check it like the handlers check their eventMap
+func eventMapOf(instance *models.Instance) map[string]bool {
+	eventMap := make(map[string]bool, len(instance.Webhook.Events))
+	for _, event := range instance.Webhook.Events {
+		eventMap[event] = true
+	}
+
+	return eventMap
+}
+
+// webhookURL is the route configured for the event type, falling back to the
+// instance webhook url and then to WEBHOOK_URL
+func webhookURL(body any, instance *models.Instance) string {
+	if len(instance.Webhook.Routes) > 0 {
+		if url := instance.Webhook.Routes[eventKey(body)]; len(url) > 0 {
+			return url
+		}
+	}
+
+	if len(instance.Webhook.Url) > 0 {
+		return instance.Webhook.Url
+	}
+
+	return env.Env.WebhookURL
+}
+
+// eventKey is the webhook.events name of the body, ex: MESSAGES_UPSERT
+func eventKey(body any) string {
+	switch event := body.(type) {
+	case interface{ wook() Wook }:
+		return event.wook().eventKey()
+	case *models.Event:
+		return event.Type.Key()
+	}
+
+	return ""
 }
 
 func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 	return func(evt any) {
+		if s.closing.Load() || s.filteredInbound(id, evt) {
+			return
+		}
+
 		s.handlerSemaphore <- struct{}{}
+		inFlight := s.handlerStarted(id)
 		go func() {
 			defer func() { <-s.handlerSemaphore }()
+			defer inFlight.done()
 			instance := s.getInstanceCached(id)
 			if instance == nil {
 				zap.L().Warn("no instance found for event", zap.String("instance", id))
 				return
 			}
 
-			eventMap := make(map[string]bool)
-			for _, event := range instance.Webhook.Events {
-				eventMap[event] = true
+			eventMap := eventMapOf(instance)
+
+			// messages are normalized in handleMessageEvent, after duplicates are dropped
+			if _, ok := evt.(*events.Message); !ok {
+				s.emitNormalized(id, instance, evt, eventMap)
 			}
 
 			switch e := evt.(type) {
 			case *events.LoggedOut:
-				s.handleLoggedOut(id)
+				s.handleLoggedOut(id, instance, e, eventMap)
+				if e.Reason == events.ConnectFailureUnknownLogout {
+					s.handleBanned(id, instance, e, eventMap)
+				}
+			case *events.TemporaryBan:
+				s.handleTemporaryBan(id, instance, e, eventMap)
+			case *events.Connected:
+				s.handleConnectedEvent(id, instance, eventMap)
+			case *events.Disconnected:
+				s.handleDisconnectedEvent(id, instance, eventMap)
+			case *events.StreamReplaced:
+				s.handleStreamReplacedEvent(id, instance, eventMap)
+			case *events.KeepAliveTimeout:
+				s.handleKeepAliveTimeout(id, e.LastSuccess)
 			case *events.Message:
+				s.refreshLidMapping(id, e.Info)
 				s.handleMessageEvent(id, instance, e, eventMap)
+			case *events.UndecryptableMessage:
+				s.handleUndecryptableEvent(id, instance, e, eventMap)
+			case *events.MediaRetry:
+				s.handleMediaRetry(id, e)
 			case *events.Receipt:
 				s.handleReceiptEvent(id, instance, e, eventMap)
 			case *events.BusinessName:
@@ -141,13 +328,30 @@ func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 			case *events.Contact:
 				s.handleContactEvent(id, instance, e, eventMap)
 			case *events.Picture:
+				s.invalidateProfilePicture(id, e.JID)
 				s.handlePictureEvent(id, instance, e, eventMap)
 			case *events.HistorySync:
 				s.handleHistorySyncEvent(id, instance, e, eventMap)
 			case *events.GroupInfo:
+				s.invalidateGroupInfo(id, e.JID)
+				s.invalidateLinkedGroup(id, e)
 				s.handleGroupInfoEvent(id, instance, e, eventMap)
 			case *events.PushName:
 				s.handlePushNameEvent(id, instance, e, eventMap)
+			case *events.ChatPresence:
+				s.handleChatPresenceEvent(id, instance, e, eventMap)
+			case *events.Presence:
+				s.handlePresenceEvent(id, instance, e, eventMap)
+			case *events.PrivacySettings:
+				s.handlePrivacySettingsEvent(id, instance, e, eventMap)
+			case *events.Blocklist:
+				s.handleBlocklistEvent(id, instance, e, eventMap)
+			case *events.NewsletterJoin:
+				s.invalidateNewsletterInfo(id, e.ID)
+			case *events.NewsletterLeave:
+				s.invalidateNewsletterInfo(id, e.ID)
+			case *events.NewsletterMuteChange:
+				s.invalidateNewsletterInfo(id, e.ID)
 			default:
 				zap.L().Debug("unknown event", zap.String("type", fmt.Sprintf("%T", evt)), zap.Any("raw", evt))
 			}
@@ -155,18 +359,42 @@ func (s *Whatsmiau) Handle(id string) whatsmeow.EventHandler {
 	}
 }
 
-func (s *Whatsmiau) handleLoggedOut(id string) {
-	client, ok := s.clients.Load(id)
-	if ok {
-		if err := s.deleteDeviceIfExists(context.Background(), client); err != nil {
-			zap.L().Error("failed to delete device for instance", zap.String("instance", id), zap.Error(err))
-			return
-		}
+func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *events.Message, eventMap map[string]bool) {
+	if keep := e.Message.GetKeepInChatMessage(); keep != nil {
+		s.handleKeepInChatEvent(id, instance, e, keep, eventMap)
+		return
 	}
 
-	s.clients.Delete(id)
-}
-func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *events.Message, eventMap map[string]bool) {
+	if !s.storeMessage(id, e.Info, e.Message) {
+		zap.L().Debug("duplicated message ignored", zap.String("id", id), zap.String("message", e.Info.ID))
+		return
+	}
+	s.rememberMessage(id, e.Info, e.Message)
+
+	if poll := pollCreation(e.Message); poll != nil {
+		s.cachePollOptions(id, e.Info.ID, pollOptionNames(poll))
+	}
+
+	if e.Message.GetPollUpdateMessage() != nil {
+		s.handlePollVote(id, instance, e, eventMap)
+	}
+
+	history := isHistoryMessage(e)
+	if !history && shouldAutoMarkRead(e, instance) {
+		go s.autoMarkRead(id, e)
+	}
+
+	if protocol := e.Message.GetProtocolMessage(); protocol.GetType() == waE2E.ProtocolMessage_REVOKE {
+		s.handleRevokeEvent(id, instance, e, protocol, eventMap)
+		return
+	}
+
+	if invite := e.Message.GetGroupInviteMessage(); invite != nil && !history {
+		s.handleGroupInvite(id, instance, e, invite, eventMap)
+	}
+
+	s.emitNormalized(id, instance, e, eventMap)
+
 	if !eventMap["MESSAGES_UPSERT"] {
 		return
 	}
@@ -179,6 +407,10 @@ func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *
 		return
 	}
 
+	if canIgnoreFromMe(e, instance) {
+		return
+	}
+
 	messageData := s.convertEventMessage(id, instance, e)
 	if messageData == nil {
 		zap.L().Error("failed to convert event", zap.String("id", id), zap.String("type", fmt.Sprintf("%T", e)), zap.Any("raw", e))
@@ -186,6 +418,19 @@ func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *
 	}
 
 	messageData.InstanceId = instance.ID
+	messageData.History = history
+	if instance.Webhook.Raw != nil && *instance.Webhook.Raw {
+		if raw, err := proto.Marshal(e.Message); err != nil {
+			zap.L().Warn("failed to marshal raw message", zap.String("id", id), zap.Error(err))
+		} else {
+			messageData.Raw = base64.StdEncoding.EncodeToString(raw)
+		}
+	}
+	if s.isSelfSent(id, e.Info.ID) {
+		messageData.Origin = OriginSelf
+	} else if e.Info.IsFromMe {
+		messageData.Origin = OriginDevice
+	}
 
 	dateTime := time.Unix(int64(messageData.MessageTimestamp), 0)
 	wookMessage := &WookEvent[WookMessageData]{
@@ -204,7 +449,148 @@ func (s *Whatsmiau) handleMessageEvent(id string, instance *models.Instance, e *
 		zap.L().Debug("message event", zap.String("instance", id), zap.Any("data", wookMessage.Data))
 	}
 
-	s.emit(wookMessage, instance.Webhook.Url)
+	s.emit(wookMessage, instance)
+}
+
+func (s *Whatsmiau) handleKeepInChatEvent(id string, instance *models.Instance, e *events.Message, keep *waE2E.KeepInChatMessage, eventMap map[string]bool) {
+	if !eventMap["MESSAGES_KEEP"] {
+		return
+	}
+
+	if canIgnoreGroup(e, instance) {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*10)
+	defer c()
+
+	jid, lid := s.GetJidLid(ctx, id, e.Info.Chat)
+	keptBy, _ := s.GetJidLid(ctx, id, e.Info.Sender)
+
+	key := &WookKey{
+		RemoteJid: jid,
+		RemoteLid: lid,
+	}
+	if k := keep.GetKey(); k != nil {
+		key.FromMe = k.GetFromMe()
+		key.Id = k.GetID()
+		key.Participant = k.GetParticipant()
+	}
+
+	wookData := &WookEvent[WookMessageKeepData]{
+		Instance: instance.ID,
+		Data: &WookMessageKeepData{
+			Key:        key,
+			Keep:       keep.GetKeepType() == waE2E.KeepType_KEEP_FOR_ALL,
+			KeptBy:     keptBy,
+			InstanceId: instance.ID,
+		},
+		DateTime: e.Info.Timestamp,
+		Event:    WookMessagesKeep,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// handleRevokeEvent is triggered when a message is deleted for everyone, by its
+// sender or by a group admin
+func (s *Whatsmiau) handleRevokeEvent(id string, instance *models.Instance, e *events.Message, protocol *waE2E.ProtocolMessage, eventMap map[string]bool) {
+	if !eventMap["MESSAGES_DELETE"] {
+		return
+	}
+
+	if canIgnoreGroup(e, instance) {
+		return
+	}
+
+	if canIgnoreMessage(e) {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*10)
+	defer c()
+
+	jid, lid := s.GetJidLid(ctx, id, e.Info.Chat)
+	revokedBy, _ := s.GetJidLid(ctx, id, e.Info.Sender)
+
+	key := &WookKey{
+		RemoteJid: jid,
+		RemoteLid: lid,
+	}
+	if k := protocol.GetKey(); k != nil {
+		key.FromMe = k.GetFromMe()
+		key.Id = k.GetID()
+		key.Participant = k.GetParticipant()
+	}
+
+	wookData := &WookEvent[WookMessageDeleteData]{
+		Instance: instance.ID,
+		Data: &WookMessageDeleteData{
+			Key:        key,
+			RevokedBy:  revokedBy,
+			InstanceId: instance.ID,
+		},
+		DateTime: e.Info.Timestamp,
+		Event:    WookMessagesDelete,
+	}
+	if s.isSelfSent(id, e.Info.ID) {
+		wookData.Data.Origin = OriginSelf
+	} else if e.Info.IsFromMe {
+		wookData.Data.Origin = OriginDevice
+	}
+
+	s.emit(wookData, instance)
+}
+
+// handleUndecryptableEvent surfaces messages that failed to decrypt, whatsmeow
+// already sent the retry receipt asking the sender to encrypt them again, so a
+// messages.upsert with the same id may still follow
+func (s *Whatsmiau) handleUndecryptableEvent(id string, instance *models.Instance, e *events.UndecryptableMessage, eventMap map[string]bool) {
+	zap.L().Warn("undecryptable message",
+		zap.String("id", id),
+		zap.String("message", e.Info.ID),
+		zap.String("sender", e.Info.Sender.String()),
+		zap.Bool("unavailable", e.IsUnavailable))
+
+	if !eventMap["MESSAGES_UNDECRYPTABLE"] {
+		return
+	}
+
+	if instance.GroupsIgnore && e.Info.IsGroup {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*10)
+	defer c()
+
+	jid, lid := s.GetJidLid(ctx, id, e.Info.Chat)
+	sender, _ := s.GetJidLid(ctx, id, e.Info.Sender)
+
+	key := &WookKey{
+		RemoteJid: jid,
+		RemoteLid: lid,
+		FromMe:    e.Info.IsFromMe,
+		Id:        e.Info.ID,
+	}
+	if e.Info.IsGroup {
+		key.Participant = sender
+	}
+
+	wookData := &WookEvent[WookMessageUndecryptableData]{
+		Instance: instance.ID,
+		Data: &WookMessageUndecryptableData{
+			Key:             key,
+			Sender:          sender,
+			IsUnavailable:   e.IsUnavailable,
+			UnavailableType: string(e.UnavailableType),
+			DecryptFailMode: string(e.DecryptFailMode),
+			InstanceId:      instance.ID,
+		},
+		DateTime: e.Info.Timestamp,
+		Event:    WookMessagesUndecryptable,
+	}
+
+	s.emit(wookData, instance)
 }
 
 func (s *Whatsmiau) handleReceiptEvent(id string, instance *models.Instance, e *events.Receipt, eventMap map[string]bool) {
@@ -229,7 +615,7 @@ func (s *Whatsmiau) handleReceiptEvent(id string, instance *models.Instance, e *
 			Event:    WookMessagesUpdate,
 		}
 
-		s.emit(wookData, instance.Webhook.Url)
+		s.emit(wookData, instance)
 	}
 }
 
@@ -251,7 +637,7 @@ func (s *Whatsmiau) handleBusinessNameEvent(id string, instance *models.Instance
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(wookData, instance)
 }
 
 func (s *Whatsmiau) handleContactEvent(id string, instance *models.Instance, e *events.Contact, eventMap map[string]bool) {
@@ -276,7 +662,7 @@ func (s *Whatsmiau) handleContactEvent(id string, instance *models.Instance, e *
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(wookData, instance)
 }
 
 func (s *Whatsmiau) handlePictureEvent(id string, instance *models.Instance, e *events.Picture, eventMap map[string]bool) {
@@ -296,10 +682,14 @@ func (s *Whatsmiau) handlePictureEvent(id string, instance *models.Instance, e *
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(wookData, instance)
 }
 
 func (s *Whatsmiau) handleHistorySyncEvent(id string, instance *models.Instance, e *events.HistorySync, eventMap map[string]bool) {
+	if isOnDemandHistorySync(e) {
+		s.handleOnDemandHistory(id, instance, e, eventMap)
+	}
+
 	if !eventMap["CONTACTS_UPSERT"] {
 		return
 	}
@@ -316,7 +706,7 @@ func (s *Whatsmiau) handleHistorySyncEvent(id string, instance *models.Instance,
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(wookData, instance)
 }
 
 func (s *Whatsmiau) handleGroupInfoEvent(id string, instance *models.Instance, e *events.GroupInfo, eventMap map[string]bool) {
@@ -341,11 +731,14 @@ func (s *Whatsmiau) handleGroupInfoEvent(id string, instance *models.Instance, e
 		Event:    WookContactsUpsert,
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	s.emit(wookData, instance)
 }
 
+// handlePushNameEvent is called after whatsmeow already saved the new push name
+// on the contact store, so later lookups see it without a new message
 func (s *Whatsmiau) handlePushNameEvent(id string, instance *models.Instance, e *events.PushName, eventMap map[string]bool) {
-	if !eventMap["CONTACTS_UPSERT"] {
+	renamed := len(e.OldPushName) > 0 && e.OldPushName != e.NewPushName
+	if !eventMap["CONTACTS_UPSERT"] && !(renamed && eventMap["CONTACTS_UPDATE"]) {
 		return
 	}
 
@@ -359,14 +752,73 @@ func (s *Whatsmiau) handlePushNameEvent(id string, instance *models.Instance, e
 		return
 	}
 
-	wookData := &WookEvent[WookContactUpsertData]{
+	if eventMap["CONTACTS_UPSERT"] {
+		wookData := &WookEvent[WookContactUpsertData]{
+			Instance: instance.ID,
+			Data:     &WookContactUpsertData{*data},
+			DateTime: time.Now(),
+			Event:    WookContactsUpsert,
+		}
+
+		s.emit(wookData, instance)
+	}
+
+	if renamed && eventMap["CONTACTS_UPDATE"] {
+		wookData := &WookEvent[WookContactUpdateData]{
+			Instance: instance.ID,
+			Data:     &WookContactUpdateData{{WookContact: *data, OldPushName: e.OldPushName}},
+			DateTime: time.Now(),
+			Event:    WookContactsUpdate,
+		}
+
+		s.emit(wookData, instance)
+	}
+}
+
+func (s *Whatsmiau) handleChatPresenceEvent(id string, instance *models.Instance, e *events.ChatPresence, eventMap map[string]bool) {
+	if !eventMap["PRESENCE_UPDATE"] {
+		return
+	}
+
+	if canIgnoreGroup(e, instance) {
+		return
+	}
+
+	data := s.convertChatPresence(id, e)
+	if data == nil {
+		return
+	}
+
+	wookData := &WookEvent[WookPresenceUpdateData]{
 		Instance: instance.ID,
-		Data:     &WookContactUpsertData{*data},
+		Data:     data,
 		DateTime: time.Now(),
-		Event:    WookContactsUpsert,
+		Event:    WookPresenceUpdate,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// handlePresenceEvent emits the online status of contacts subscribed through
+// SubscribePresence
+func (s *Whatsmiau) handlePresenceEvent(id string, instance *models.Instance, e *events.Presence, eventMap map[string]bool) {
+	if !eventMap["PRESENCE_UPDATE"] {
+		return
+	}
+
+	data := s.convertPresence(id, e)
+	if data == nil {
+		return
 	}
 
-	s.emit(wookData, instance.Webhook.Url)
+	wookData := &WookEvent[WookPresenceUpdateData]{
+		Instance: instance.ID,
+		Data:     data,
+		DateTime: time.Now(),
+		Event:    WookPresenceUpdate,
+	}
+
+	s.emit(wookData, instance)
 }
 
 // parseWAMessage converts a raw waE2E.Message into our internal representation.
@@ -400,11 +852,19 @@ func (s *Whatsmiau) parseWAMessage(m *waE2E.Message) (string, *WookMessageRaw, *
 			selectedRowID = ssr.GetSelectedRowID()
 		}
 		raw.ListResponseMessage = &WookListMessageRaw{
-			ListType: listType,
+			Title:       lr.GetTitle(),
+			Description: lr.GetDescription(),
+			ListType:    listType,
 			SingleSelectReply: &WookListMessageRawListSingleSelectReply{
 				SelectedRowId: selectedRowID,
 			},
 		}
+		// stanzaId points to the list the row was picked from
+		ci = lr.GetContextInfo()
+	} else if br, brContext := parseButtonResponse(m); br != nil {
+		messageType = "buttonsResponseMessage"
+		raw.ButtonsResponseMessage = br
+		ci = brContext
 	} else if img := m.GetImageMessage(); img != nil {
 		messageType = "imageMessage"
 		ci = img.GetContextInfo()
@@ -507,6 +967,31 @@ func (s *Whatsmiau) parseWAMessage(m *waE2E.Message) (string, *WookMessageRaw, *
 			Contacts:    contacts,
 		}
 		ci = contactArray.GetContextInfo()
+	} else if loc := m.GetLocationMessage(); loc != nil {
+		messageType = "locationMessage"
+		raw.LocationMessage = &WookLocationMessageRaw{
+			DegreesLatitude:  loc.GetDegreesLatitude(),
+			DegreesLongitude: loc.GetDegreesLongitude(),
+			Name:             loc.GetName(),
+			Address:          loc.GetAddress(),
+			Url:              loc.GetURL(),
+			IsLive:           loc.GetIsLive(),
+			AccuracyInMeters: int(loc.GetAccuracyInMeters()),
+			Comment:          loc.GetComment(),
+			JpegThumbnail:    b64(loc.GetJPEGThumbnail()),
+		}
+		ci = loc.GetContextInfo()
+	} else if live := m.GetLiveLocationMessage(); live != nil {
+		messageType = "liveLocationMessage"
+		raw.LocationMessage = &WookLocationMessageRaw{
+			DegreesLatitude:  live.GetDegreesLatitude(),
+			DegreesLongitude: live.GetDegreesLongitude(),
+			IsLive:           true,
+			AccuracyInMeters: int(live.GetAccuracyInMeters()),
+			Comment:          live.GetCaption(),
+			JpegThumbnail:    b64(live.GetJPEGThumbnail()),
+		}
+		ci = live.GetContextInfo()
 	} else if conv := strings.TrimSpace(m.GetConversation()); conv != "" {
 		messageType = "conversation"
 		raw.Conversation = conv
@@ -647,21 +1132,24 @@ func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, ev
 	switch messageType {
 	case "imageMessage":
 		if img := m.GetImageMessage(); img != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, img, img.GetMimetype(), "")
+			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, img, img.GetMimetype(), "", img.GetFileLength())
 		}
 	case "audioMessage":
 		if aud := m.GetAudioMessage(); aud != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, aud, aud.GetMimetype(), "")
+			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, aud, aud.GetMimetype(), "", aud.GetFileLength())
 		}
 	case "documentMessage":
 		if doc := m.GetDocumentMessage(); doc != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, doc, doc.GetMimetype(), doc.GetFileName())
+			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, doc, doc.GetMimetype(), doc.GetFileName(), doc.GetFileLength())
 		}
 	case "videoMessage":
 		if vid := m.GetVideoMessage(); vid != nil {
-			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, vid, vid.GetMimetype(), "")
+			raw.MediaURL, raw.Base64 = s.uploadMessageFile(ctx, instance, client, vid, vid.GetMimetype(), "", vid.GetFileLength())
 		}
 	}
+	if len(raw.MediaURL) > 0 {
+		s.storeMediaURL(ctx, id, e.Info.ID, raw.MediaURL)
+	}
 
 	// Map MessageContextInfo (quoted, mentions, disappearing mode, external ad reply)
 	var messageContext WookMessageContextInfo
@@ -714,6 +1202,7 @@ func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, ev
 	return &WookMessageData{
 		Key:              key,
 		PushName:         strings.TrimSpace(e.Info.PushName),
+		VerifiedBizName:  s.verifiedName(ctx, id, client, e.Info),
 		Status:           status,
 		Message:          raw,
 		ContextInfo:      &messageContext,
@@ -724,6 +1213,13 @@ func (s *Whatsmiau) convertEventMessage(id string, instance *models.Instance, ev
 	}
 }
 
+func origin(self bool) Origin {
+	if self {
+		return OriginSelf
+	}
+	return ""
+}
+
 func (s *Whatsmiau) convertEventReceipt(id string, evt *events.Receipt) []WookMessageUpdateData {
 	var status WookMessageUpdateStatus
 	switch evt.Type {
@@ -749,19 +1245,38 @@ func (s *Whatsmiau) convertEventReceipt(id string, evt *events.Receipt) []WookMe
 			Participant: participantJid,
 			Status:      status,
 			InstanceId:  id,
+			Origin:      origin(s.isSelfSent(id, messageID)),
 		})
 	}
 
 	return result
 }
 
-func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Instance, client *whatsmeow.Client, fileMessage whatsmeow.DownloadableMessage, mimetype, fileName string) (string, string) {
+// uploadMessageFile downloads inbound media to the storage and, when the
+// instance asks for it or there is no storage and the file is small, inlines it
+// as base64. Files bigger than MEDIA_DOWNLOAD_MAX_SIZE are left on WhatsApp.
+func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Instance, client *whatsmeow.Client, fileMessage whatsmeow.DownloadableMessage, mimetype, fileName string, size uint64) (string, string) {
 	var (
 		b64Result string
 		urlResult string
 		ext       string
 	)
 
+	if !autoDownloadMedia(instance) {
+		return "", ""
+	}
+
+	if maxSize := env.Env.MediaDownloadMaxSize; maxSize > 0 && size > maxSize {
+		zap.L().Debug("media too big to download", zap.String("instance", instance.ID), zap.Uint64("size", size))
+		return "", ""
+	}
+
+	inline := (instance.Webhook.Base64 != nil && *instance.Webhook.Base64) ||
+		(s.fileStorage == nil && size <= env.Env.MediaInlineMaxSize)
+	if !inline && s.fileStorage == nil {
+		return "", ""
+	}
+
 	tmpFile, err := os.CreateTemp("", "file-*")
 	if err != nil {
 		panic(err)
@@ -778,7 +1293,7 @@ func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Inst
 	}
 
 	ext = extractExtFromFile(fileName, mimetype, tmpFile)
-	if instance.Webhook.Base64 != nil && *instance.Webhook.Base64 {
+	if inline {
 		data, err := io.ReadAll(tmpFile)
 		if err != nil {
 			zap.L().Error("failed to read image", zap.Error(err))
@@ -791,7 +1306,7 @@ func (s *Whatsmiau) uploadMessageFile(ctx context.Context, instance *models.Inst
 			zap.L().Error("failed to seek image", zap.Error(err))
 		}
 
-		urlResult, _, err = s.fileStorage.Upload(ctx, uuid.NewString()+"."+ext, mimetype, tmpFile)
+		urlResult, _, err = s.fileStorage.Upload(ctx, mediaKey(instance.ID, time.Now())+"."+ext, mimetype, tmpFile)
 		if err != nil {
 			zap.L().Error("failed to upload image", zap.Error(err))
 		}
@@ -940,6 +1455,58 @@ func (s *Whatsmiau) convertBusinessName(id string, evt *events.BusinessName) *Wo
 	}
 }
 
+func (s *Whatsmiau) convertPresence(id string, evt *events.Presence) *WookPresenceUpdateData {
+	jid, _ := s.GetJidLid(context.Background(), id, evt.From.ToNonAD())
+	if !s.presenceSubscribed(id, jid) {
+		return nil
+	}
+
+	presence := WookPresence{LastKnownPresence: "available"}
+	if evt.Unavailable {
+		presence.LastKnownPresence = "unavailable"
+		if !evt.LastSeen.IsZero() {
+			presence.LastSeen = evt.LastSeen.Unix()
+		}
+	}
+
+	return &WookPresenceUpdateData{
+		Id: jid,
+		Presences: map[string]WookPresence{
+			jid: presence,
+		},
+	}
+}
+
+// convertChatPresence only converts typing and recording of contacts subscribed
+// through SubscribePresence, like convertPresence
+func (s *Whatsmiau) convertChatPresence(id string, evt *events.ChatPresence) *WookPresenceUpdateData {
+	senderJid, _ := s.GetJidLid(context.Background(), id, evt.Sender.ToNonAD())
+	if !s.presenceSubscribed(id, senderJid) {
+		return nil
+	}
+	chatJid, _ := s.GetJidLid(context.Background(), id, evt.Chat)
+
+	presence := WookPresence{
+		LastKnownPresence: string(evt.State),
+	}
+
+	// WhatsApp sends "recording audio" as a composing state with audio media
+	if evt.State == types.ChatPresenceComposing {
+		presence.Media = "text"
+		if evt.Media == types.ChatPresenceMediaAudio {
+			presence.LastKnownPresence = "recording"
+			presence.Media = "audio"
+		}
+	}
+
+	return &WookPresenceUpdateData{
+		Id: chatJid,
+		Presences: map[string]WookPresence{
+			senderJid: presence,
+		},
+	}
+}
+
 func (s *Whatsmiau) getPic(id string, jid types.JID) (string, string, error) {
 	client, ok := s.clients.Load(id)
 	if !ok || client == nil {
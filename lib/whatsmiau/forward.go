@@ -0,0 +1,163 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrUnsupportedForward = errors.New("message type can't be forwarded")
+
+// ForwardMessage re-sends a recent message (RECENT_MESSAGE_CACHE_TTL), or an
+// older text kept by STORE_MESSAGES, from the chat from to the chat to, flagged
+// as forwarded. Media is downloaded and uploaded again,
+// so the forward keeps working after the original media expires. Returns the
+// id of the new message.
+func (s *Whatsmiau) ForwardMessage(ctx context.Context, id string, from types.JID, messageID string, to types.JID) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	stored, original, err := s.originalMessage(ctx, id, messageID)
+	if err != nil {
+		return "", err
+	}
+	if original == nil {
+		return "", ErrMessageContentExpired
+	}
+
+	jid, lid := s.GetJidLid(ctx, id, from.ToNonAD())
+	if stored.Chat != jid && stored.Chat != lid {
+		return "", ErrMessageNotFound
+	}
+
+	// media is downloaded and uploaded before taking a slot, the transfer
+	// shouldn't hold up event handling
+	message, err := s.buildForward(ctx, id, client, stored, original)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	_, _, originalContext := s.parseWAMessage(original)
+	contextInfo := &waE2E.ContextInfo{
+		IsForwarded:     proto.Bool(true),
+		ForwardingScore: proto.Uint32(originalContext.GetForwardingScore() + 1),
+	}
+	if expiration := s.defaultExpirationContext(id, to); expiration != nil {
+		contextInfo.Expiration = expiration.Expiration
+	}
+	setForwardContextInfo(message, contextInfo)
+
+	res, err := s.sendReserved(ctx, id, client, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+// buildForward copies the content of the original message without its context,
+// quotes and mentions don't make sense in another chat
+func (s *Whatsmiau) buildForward(ctx context.Context, id string, client *whatsmeow.Client, stored *models.StoredMessage, original *waE2E.Message) (*waE2E.Message, error) {
+	switch {
+	case original.GetConversation() != "":
+		return &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(original.GetConversation()),
+		}}, nil
+	case original.GetExtendedTextMessage() != nil:
+		text := proto.Clone(original.GetExtendedTextMessage()).(*waE2E.ExtendedTextMessage)
+		return &waE2E.Message{ExtendedTextMessage: text}, nil
+	case original.GetLocationMessage() != nil:
+		location := proto.Clone(original.GetLocationMessage()).(*waE2E.LocationMessage)
+		return &waE2E.Message{LocationMessage: location}, nil
+	case original.GetContactMessage() != nil:
+		contact := proto.Clone(original.GetContactMessage()).(*waE2E.ContactMessage)
+		return &waE2E.Message{ContactMessage: contact}, nil
+	case original.GetContactsArrayMessage() != nil:
+		contacts := proto.Clone(original.GetContactsArrayMessage()).(*waE2E.ContactsArrayMessage)
+		return &waE2E.Message{ContactsArrayMessage: contacts}, nil
+	}
+
+	media, _, _ := downloadableMedia(original)
+	if media == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedForward, stored.Type)
+	}
+
+	content, err := s.downloadStored(ctx, id, client, stored, media)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media to forward: %w", err)
+	}
+
+	uploaded, err := client.Upload(ctx, content, whatsmeow.GetMediaType(media))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload forwarded media: %w", err)
+	}
+
+	return reuploadedMedia(original, &uploaded), nil
+}
+
+// reuploadedMedia clones the media message pointing it to the new upload, the
+// caption, mimetype and dimensions are kept
+func reuploadedMedia(original *waE2E.Message, uploaded *whatsmeow.UploadResponse) *waE2E.Message {
+	switch {
+	case original.GetImageMessage() != nil:
+		image := proto.Clone(original.GetImageMessage()).(*waE2E.ImageMessage)
+		image.URL, image.DirectPath = proto.String(uploaded.URL), proto.String(uploaded.DirectPath)
+		image.MediaKey, image.FileSHA256, image.FileEncSHA256 = uploaded.MediaKey, uploaded.FileSHA256, uploaded.FileEncSHA256
+		image.FileLength = proto.Uint64(uploaded.FileLength)
+		return &waE2E.Message{ImageMessage: image}
+	case original.GetVideoMessage() != nil:
+		video := proto.Clone(original.GetVideoMessage()).(*waE2E.VideoMessage)
+		video.URL, video.DirectPath = proto.String(uploaded.URL), proto.String(uploaded.DirectPath)
+		video.MediaKey, video.FileSHA256, video.FileEncSHA256 = uploaded.MediaKey, uploaded.FileSHA256, uploaded.FileEncSHA256
+		video.FileLength = proto.Uint64(uploaded.FileLength)
+		return &waE2E.Message{VideoMessage: video}
+	case original.GetAudioMessage() != nil:
+		audio := proto.Clone(original.GetAudioMessage()).(*waE2E.AudioMessage)
+		audio.URL, audio.DirectPath = proto.String(uploaded.URL), proto.String(uploaded.DirectPath)
+		audio.MediaKey, audio.FileSHA256, audio.FileEncSHA256 = uploaded.MediaKey, uploaded.FileSHA256, uploaded.FileEncSHA256
+		audio.FileLength = proto.Uint64(uploaded.FileLength)
+		return &waE2E.Message{AudioMessage: audio}
+	case original.GetStickerMessage() != nil:
+		sticker := proto.Clone(original.GetStickerMessage()).(*waE2E.StickerMessage)
+		sticker.URL, sticker.DirectPath = proto.String(uploaded.URL), proto.String(uploaded.DirectPath)
+		sticker.MediaKey, sticker.FileSHA256, sticker.FileEncSHA256 = uploaded.MediaKey, uploaded.FileSHA256, uploaded.FileEncSHA256
+		sticker.FileLength = proto.Uint64(uploaded.FileLength)
+		return &waE2E.Message{StickerMessage: sticker}
+	}
+
+	document := proto.Clone(original.GetDocumentMessage()).(*waE2E.DocumentMessage)
+	document.URL, document.DirectPath = proto.String(uploaded.URL), proto.String(uploaded.DirectPath)
+	document.MediaKey, document.FileSHA256, document.FileEncSHA256 = uploaded.MediaKey, uploaded.FileSHA256, uploaded.FileEncSHA256
+	document.FileLength = proto.Uint64(uploaded.FileLength)
+	return &waE2E.Message{DocumentMessage: document}
+}
+
+func setForwardContextInfo(message *waE2E.Message, info *waE2E.ContextInfo) {
+	switch {
+	case message.ExtendedTextMessage != nil:
+		message.ExtendedTextMessage.ContextInfo = info
+	case message.LocationMessage != nil:
+		message.LocationMessage.ContextInfo = info
+	case message.ContactMessage != nil:
+		message.ContactMessage.ContextInfo = info
+	case message.ContactsArrayMessage != nil:
+		message.ContactsArrayMessage.ContextInfo = info
+	default:
+		setMediaContextInfo(message, info)
+	}
+}
@@ -0,0 +1,217 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrNoParticipants = errors.New("at least one participant is required")
+	ErrInvalidGroup   = errors.New("jid is not a group")
+	ErrNotGroupAdmin  = errors.New("instance is not an admin of the group")
+)
+
+type ParticipantAction string
+
+type GroupSetting string
+
+const (
+	GroupSettingAnnounce GroupSetting = "announce" // only admins send messages
+	GroupSettingLocked   GroupSetting = "locked"   // only admins edit the group info
+)
+
+const (
+	ParticipantAdd     ParticipantAction = "add"
+	ParticipantRemove  ParticipantAction = "remove"
+	ParticipantPromote ParticipantAction = "promote"
+	ParticipantDemote  ParticipantAction = "demote"
+)
+
+type CreateGroupRequest struct {
+	InstanceID   string      `json:"instance_id"`
+	Name         string      `json:"name"`
+	Participants []types.JID `json:"participants"`
+}
+
+// CreateGroup creates a group with the instance as super admin. Participants
+// that couldn't be added are still returned, with Error set to the code
+// WhatsApp reported for them.
+func (s *Whatsmiau) CreateGroup(ctx context.Context, data *CreateGroupRequest) (*types.GroupInfo, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if len(data.Participants) == 0 {
+		return nil, ErrNoParticipants
+	}
+
+	return client.CreateGroup(ctx, whatsmeow.ReqCreateGroup{
+		Name:         data.Name,
+		Participants: s.resolveRecipients(ctx, data.InstanceID, data.Participants),
+	})
+}
+
+func (s *Whatsmiau) resolveRecipients(ctx context.Context, id string, jids []types.JID) []types.JID {
+	result := make([]types.JID, 0, len(jids))
+	for _, jid := range jids {
+		result = append(result, s.resolveRecipient(ctx, id, jid))
+	}
+
+	return result
+}
+
+type UpdateGroupParticipantsRequest struct {
+	InstanceID   string            `json:"instance_id"`
+	Group        types.JID         `json:"group"`
+	Action       ParticipantAction `json:"action"`
+	Participants []types.JID       `json:"participants"`
+}
+
+// UpdateGroupParticipants adds, removes, promotes or demotes participants. The
+// change is applied per participant, the ones that failed come back with Error
+// set, ex: 403 when they don't allow being added, 409 when already there.
+func (s *Whatsmiau) UpdateGroupParticipants(ctx context.Context, data *UpdateGroupParticipantsRequest) ([]types.GroupParticipant, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if len(data.Participants) == 0 {
+		return nil, ErrNoParticipants
+	}
+
+	if _, err := s.requireGroupAdmin(ctx, data.InstanceID, client, data.Group); err != nil {
+		return nil, err
+	}
+
+	result, err := client.UpdateGroupParticipants(ctx, data.Group, s.resolveRecipients(ctx, data.InstanceID, data.Participants), whatsmeow.ParticipantChange(data.Action))
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGroupInfo(data.InstanceID, data.Group)
+	return result, nil
+}
+
+// requireGroupAdmin fails with ErrNotGroupAdmin unless the account is an admin
+// of the group, so callers get a clear error instead of a 403 from the server
+func (s *Whatsmiau) requireGroupAdmin(ctx context.Context, id string, client *whatsmeow.Client, group types.JID) (*types.GroupInfo, error) {
+	if group.Server != types.GroupServer {
+		return nil, ErrInvalidGroup
+	}
+
+	info, err := s.groupInfo(ctx, id, client, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isGroupAdmin(client, info) {
+		return nil, ErrNotGroupAdmin
+	}
+
+	return info, nil
+}
+
+func isGroupAdmin(client *whatsmeow.Client, info *types.GroupInfo) bool {
+	if client.Store.ID == nil {
+		return false
+	}
+
+	own, ownLID := client.Store.ID.User, client.Store.GetLID().User
+	for _, participant := range info.Participants {
+		if participant.JID.User != own && participant.PhoneNumber.User != own && (ownLID == "" || participant.LID.User != ownLID) {
+			continue
+		}
+
+		return participant.IsAdmin || participant.IsSuperAdmin
+	}
+
+	return false
+}
+
+type SetGroupSettingRequest struct {
+	InstanceID string       `json:"instance_id"`
+	Group      types.JID    `json:"group"`
+	Setting    GroupSetting `json:"setting"`
+	Enabled    bool         `json:"enabled"`
+}
+
+func (s *Whatsmiau) SetGroupSetting(ctx context.Context, data *SetGroupSettingRequest) error {
+	client, err := s.groupClient(data.InstanceID, data.Group)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.requireGroupAdmin(ctx, data.InstanceID, client, data.Group); err != nil {
+		return err
+	}
+
+	switch data.Setting {
+	case GroupSettingAnnounce:
+		err = client.SetGroupAnnounce(ctx, data.Group, data.Enabled)
+	case GroupSettingLocked:
+		err = client.SetGroupLocked(ctx, data.Group, data.Enabled)
+	default:
+		return fmt.Errorf("unknown group setting: %s", data.Setting)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(data.InstanceID, data.Group)
+	return nil
+}
+
+// LeaveGroup leaves the group and emits groups.left, the account stays in the
+// group list of the phone until it is deleted there
+func (s *Whatsmiau) LeaveGroup(ctx context.Context, id string, group types.JID) error {
+	client, err := s.groupClient(id, group)
+	if err != nil {
+		return err
+	}
+
+	if err := client.LeaveGroup(ctx, group); err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(id, group)
+	s.emitGroupLeft(id, group)
+	return nil
+}
+
+func (s *Whatsmiau) emitGroupLeft(id string, group types.JID) {
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return
+	}
+
+	if !eventMapOf(instance)["GROUPS_LEFT"] {
+		return
+	}
+
+	wookData := &WookEvent[WookGroupLeftData]{
+		Instance: instance.ID,
+		Data: &WookGroupLeftData{
+			GroupJid:   group.String(),
+			InstanceId: instance.ID,
+		},
+		DateTime: time.Now(),
+		Event:    WookGroupsLeft,
+	}
+
+	s.emit(wookData, instance)
+}
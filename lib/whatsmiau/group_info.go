@@ -0,0 +1,159 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GetGroupInfo returns the group metadata and participants, cached for
+// GROUP_INFO_CACHE_TTL. Changes made through the API or seen in group events
+// drop the cached entry.
+func (s *Whatsmiau) GetGroupInfo(ctx context.Context, id string, group types.JID) (*types.GroupInfo, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	return s.groupInfo(ctx, id, client, group)
+}
+
+func (s *Whatsmiau) groupInfo(ctx context.Context, id string, client *whatsmeow.Client, group types.JID) (*types.GroupInfo, error) {
+	if group.Server != types.GroupServer {
+		return nil, ErrInvalidGroup
+	}
+
+	key := groupInfoKey(id, group)
+	if info, ok := s.groupInfos.Load(key); ok {
+		return info, nil
+	}
+
+	info, err := client.GetGroupInfo(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl := env.Env.GroupInfoCacheTTL; ttl > 0 {
+		s.groupInfos.Store(key, info)
+		time.AfterFunc(ttl, func() {
+			// a newer entry may have been stored since, it has its own timer
+			s.groupInfos.Compute(key, func(cached *types.GroupInfo, loaded bool) (*types.GroupInfo, xsync.ComputeOp) {
+				if loaded && cached == info {
+					return nil, xsync.DeleteOp
+				}
+				return cached, xsync.CancelOp
+			})
+		})
+	}
+
+	return info, nil
+}
+
+func (s *Whatsmiau) invalidateGroupInfo(id string, group types.JID) {
+	s.groupInfos.Delete(groupInfoKey(id, group))
+}
+
+func groupInfoKey(id string, group types.JID) string {
+	return id + ":" + group.ToNonAD().String()
+}
+
+type SetGroupNameRequest struct {
+	InstanceID string    `json:"instance_id"`
+	Group      types.JID `json:"group"`
+	Name       string    `json:"name"`
+}
+
+func (s *Whatsmiau) SetGroupName(ctx context.Context, data *SetGroupNameRequest) error {
+	client, err := s.groupClient(data.InstanceID, data.Group)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetGroupName(ctx, data.Group, data.Name); err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(data.InstanceID, data.Group)
+	return nil
+}
+
+type SetGroupDescriptionRequest struct {
+	InstanceID  string    `json:"instance_id"`
+	Group       types.JID `json:"group"`
+	Description string    `json:"description"` // empty removes it
+}
+
+func (s *Whatsmiau) SetGroupDescription(ctx context.Context, data *SetGroupDescriptionRequest) error {
+	client, err := s.groupClient(data.InstanceID, data.Group)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetGroupDescription(ctx, data.Group, data.Description); err != nil {
+		return err
+	}
+
+	s.invalidateGroupInfo(data.InstanceID, data.Group)
+	return nil
+}
+
+type SetGroupPhotoRequest struct {
+	InstanceID string      `json:"instance_id"`
+	Group      types.JID   `json:"group"`
+	Photo      *MediaInput `json:"photo"` // nil removes it
+}
+
+// SetGroupPhoto replaces the group picture, see profilePhoto for how the image
+// is converted. Returns the new picture id.
+func (s *Whatsmiau) SetGroupPhoto(ctx context.Context, data *SetGroupPhotoRequest) (string, error) {
+	client, err := s.groupClient(data.InstanceID, data.Group)
+	if err != nil {
+		return "", err
+	}
+
+	var avatar []byte
+	if data.Photo != nil {
+		content, err := s.readMediaInput(ctx, data.InstanceID, data.Photo)
+		if err != nil {
+			return "", err
+		}
+
+		if avatar, err = profilePhoto(content); err != nil {
+			return "", err
+		}
+	}
+
+	pictureID, err := client.SetGroupPhoto(ctx, data.Group, avatar)
+	if err != nil {
+		return "", err
+	}
+
+	s.invalidateGroupInfo(data.InstanceID, data.Group)
+	return pictureID, nil
+}
+
+// groupClient loads the logged in client for a group change
+func (s *Whatsmiau) groupClient(id string, group types.JID) (*whatsmeow.Client, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if group.Server != types.GroupServer {
+		return nil, ErrInvalidGroup
+	}
+
+	return client, nil
+}
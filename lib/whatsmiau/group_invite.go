@@ -0,0 +1,102 @@
+package whatsmiau
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+// handleGroupInvite joins the group of an invite message when the instance has
+// groupAutoJoin configured, the inviter is whitelisted and the account is below
+// the max groups. The invite is still emitted as a regular message.
+func (s *Whatsmiau) handleGroupInvite(id string, instance *models.Instance, e *events.Message, invite *waE2E.GroupInviteMessage, eventMap map[string]bool) {
+	config := instance.GroupAutoJoin
+	if config == nil || len(config.Inviters) == 0 || e.Info.IsFromMe {
+		return
+	}
+
+	if expiration := invite.GetInviteExpiration(); expiration > 0 && time.Unix(expiration, 0).Before(time.Now()) {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*30)
+	defer c()
+
+	inviter, _ := s.GetJidLid(ctx, id, e.Info.Sender)
+	if !allowedInviter(config.Inviters, inviter) {
+		zap.L().Debug("group invite from not allowed inviter ignored", zap.String("id", id), zap.String("inviter", inviter))
+		return
+	}
+
+	groupJID, err := types.ParseJID(invite.GetGroupJID())
+	if err != nil {
+		zap.L().Warn("invalid group jid on invite", zap.String("id", id), zap.String("group", invite.GetGroupJID()), zap.Error(err))
+		return
+	}
+
+	maxGroups := config.MaxGroups
+	if maxGroups <= 0 {
+		maxGroups = env.Env.GroupAutoJoinMaxGroups
+	}
+	if maxGroups > 0 {
+		groups, err := client.GetJoinedGroups(ctx)
+		if err != nil {
+			zap.L().Error("failed to get joined groups", zap.String("id", id), zap.Error(err))
+			return
+		}
+		if len(groups) >= maxGroups {
+			zap.L().Warn("group invite ignored, max groups reached", zap.String("id", id), zap.String("group", groupJID.String()), zap.Int("max", maxGroups))
+			return
+		}
+	}
+
+	if err := client.JoinGroupWithInvite(ctx, groupJID, e.Info.Sender, invite.GetInviteCode(), invite.GetInviteExpiration()); err != nil {
+		zap.L().Error("failed to auto join group", zap.String("id", id), zap.String("group", groupJID.String()), zap.Error(err))
+		return
+	}
+
+	zap.L().Info("group auto joined", zap.String("id", id), zap.String("group", groupJID.String()), zap.String("inviter", inviter))
+	if !eventMap["GROUPS_AUTO_JOINED"] {
+		return
+	}
+
+	wookData := &WookEvent[WookGroupAutoJoinedData]{
+		Instance: instance.ID,
+		Data: &WookGroupAutoJoinedData{
+			GroupJid:   groupJID.String(),
+			GroupName:  invite.GetGroupName(),
+			InvitedBy:  inviter,
+			InstanceId: instance.ID,
+		},
+		DateTime: time.Now(),
+		Event:    WookGroupsAutoJoined,
+	}
+
+	s.emit(wookData, instance)
+}
+
+// allowedInviter matches the inviter against numbers or JIDs of the whitelist
+func allowedInviter(inviters []string, inviter string) bool {
+	user, _, _ := strings.Cut(inviter, "@")
+	return slices.ContainsFunc(inviters, func(allowed string) bool {
+		if strings.Contains(allowed, "@") {
+			allowedUser, _, _ := strings.Cut(allowed, "@")
+			return allowedUser == user
+		}
+
+		return onlyDigits(allowed) == user
+	})
+}
@@ -0,0 +1,116 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrInvalidInviteLink = errors.New("invalid group invite link")
+	ErrInviteLinkExpired = errors.New("group invite link was revoked or expired")
+)
+
+// GetGroupInviteLink returns the chat.whatsapp.com link of the group, reset
+// revokes the current one and returns a new link
+func (s *Whatsmiau) GetGroupInviteLink(ctx context.Context, id string, group types.JID, reset bool) (string, error) {
+	client, err := s.groupClient(id, group)
+	if err != nil {
+		return "", err
+	}
+
+	return client.GetGroupInviteLink(ctx, group, reset)
+}
+
+// GetGroupInfoFromLink previews the group of an invite link without joining it
+func (s *Whatsmiau) GetGroupInfoFromLink(ctx context.Context, id string, link string) (*types.GroupInfo, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	code, err := ParseInviteCode(link)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := client.GetGroupInfoFromLink(ctx, code)
+	if err != nil {
+		return nil, inviteLinkError(err)
+	}
+
+	return info, nil
+}
+
+// JoinGroupWithLink joins the group of an invite link, returning its JID
+func (s *Whatsmiau) JoinGroupWithLink(ctx context.Context, id string, link string) (types.JID, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return types.EmptyJID, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return types.EmptyJID, ErrNotLoggedIn
+	}
+
+	code, err := ParseInviteCode(link)
+	if err != nil {
+		return types.EmptyJID, err
+	}
+
+	group, err := client.JoinGroupWithLink(ctx, code)
+	if err != nil {
+		return types.EmptyJID, inviteLinkError(err)
+	}
+
+	s.invalidateGroupInfo(id, group)
+	return group, nil
+}
+
+// ParseInviteCode extracts the invite code of a chat.whatsapp.com link, with or
+// without scheme, or returns the bare code as is
+func ParseInviteCode(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	code := link
+	if strings.Contains(link, "/") {
+		if !strings.Contains(link, "://") {
+			link = "https://" + link
+		}
+
+		parsed, err := url.Parse(link)
+		if err != nil || !strings.EqualFold(parsed.Hostname(), "chat.whatsapp.com") {
+			return "", fmt.Errorf("%w: %s", ErrInvalidInviteLink, link)
+		}
+
+		// links shared from the app sometimes carry tracking params or a trailing slash
+		code = strings.Trim(parsed.Path, "/")
+	}
+
+	if code == "" || strings.ContainsFunc(code, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidInviteLink, link)
+	}
+
+	return code, nil
+}
+
+func inviteLinkError(err error) error {
+	switch {
+	case errors.Is(err, whatsmeow.ErrInviteLinkRevoked):
+		return fmt.Errorf("%w: %w", ErrInviteLinkExpired, err)
+	case errors.Is(err, whatsmeow.ErrInviteLinkInvalid):
+		return fmt.Errorf("%w: %w", ErrInvalidInviteLink, err)
+	}
+
+	return err
+}
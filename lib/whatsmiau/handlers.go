@@ -0,0 +1,76 @@
+package whatsmiau
+
+import (
+	"sync"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.uber.org/zap"
+)
+
+// inFlightHandlers counts the running event handlers of an instance, idle is
+// closed while none is running
+type inFlightHandlers struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func newInFlightHandlers() *inFlightHandlers {
+	idle := make(chan struct{})
+	close(idle)
+	return &inFlightHandlers{idle: idle}
+}
+
+func (h *inFlightHandlers) start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count == 0 {
+		h.idle = make(chan struct{})
+	}
+	h.count++
+}
+
+func (h *inFlightHandlers) done() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count--
+	if h.count == 0 {
+		close(h.idle)
+	}
+}
+
+func (h *inFlightHandlers) pending() (<-chan struct{}, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.idle, h.count
+}
+
+func (s *Whatsmiau) handlerStarted(id string) *inFlightHandlers {
+	handlers, _ := s.inFlight.LoadOrCompute(id, func() (*inFlightHandlers, bool) {
+		return newInFlightHandlers(), false
+	})
+	handlers.start()
+	return handlers
+}
+
+// waitHandlers blocks until the in-flight handlers of the instance finish or
+// HANDLER_DRAIN_TIMEOUT passes, so the client isn't removed under their feet.
+// The client must already be disconnected so no new events arrive.
+func (s *Whatsmiau) waitHandlers(id string) {
+	handlers, ok := s.inFlight.Load(id)
+	if !ok {
+		return
+	}
+
+	idle, _ := handlers.pending()
+	timer := time.NewTimer(env.Env.HandlerDrainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-idle:
+	case <-timer.C:
+		_, count := handlers.pending()
+		zap.L().Warn("timeout waiting in-flight handlers", zap.String("id", id), zap.Int("pending", count))
+	}
+}
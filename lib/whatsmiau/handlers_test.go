@@ -0,0 +1,93 @@
+package whatsmiau
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+)
+
+func TestWaitHandlers(t *testing.T) {
+	previous := env.Env.HandlerDrainTimeout
+	env.Env.HandlerDrainTimeout = time.Second
+	t.Cleanup(func() { env.Env.HandlerDrainTimeout = previous })
+
+	s := &Whatsmiau{inFlight: xsync.NewMap[string, *inFlightHandlers]()}
+	s.waitHandlers("unknown") // never handled an event
+
+	first, second := s.handlerStarted("a"), s.handlerStarted("a")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		first.done()
+		time.Sleep(20 * time.Millisecond)
+		second.done()
+	}()
+
+	started := time.Now()
+	s.waitHandlers("a")
+	if elapsed := time.Since(started); elapsed < 40*time.Millisecond || elapsed >= time.Second {
+		t.Fatalf("waited %s, want until both handlers finished", elapsed)
+	}
+
+	// idle again, a new handler must block the next wait
+	s.handlerStarted("a")
+	env.Env.HandlerDrainTimeout = 50 * time.Millisecond
+	started = time.Now()
+	s.waitHandlers("a")
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Fatalf("waited %s, want the drain timeout", elapsed)
+	}
+}
+
+func TestDisconnectWaitsHandlers(t *testing.T) {
+	previous := env.Env.HandlerDrainTimeout
+	env.Env.HandlerDrainTimeout = time.Second
+	t.Cleanup(func() { env.Env.HandlerDrainTimeout = previous })
+
+	s := newTestMiau()
+	s.inFlight = xsync.NewMap[string, *inFlightHandlers]()
+	s.qrCache = xsync.NewMap[string, string]()
+	s.presenceLoops = xsync.NewMap[string, context.CancelFunc]()
+	s.reconnectLoops = xsync.NewMap[string, *reconnectLoop]()
+	s.onlineInstances = xsync.NewMap[string, struct{}]()
+	// without a device id logging out doesn't touch the database
+	s.clients.Store("a", whatsmeow.NewClient(&store.Device{}, nil))
+
+	// logout drops the client, so it runs last
+	for _, step := range []struct {
+		name string
+		stop func() error
+	}{
+		{"disconnect", func() error { return s.Disconnect("a") }},
+		{"logout", func() error { return s.Logout(context.Background(), "a") }},
+	} {
+		handler := s.handlerStarted("a")
+		loaded := make(chan bool, 1)
+		go func() {
+			time.Sleep(30 * time.Millisecond)
+			// the handler still reaches the client it was started with
+			_, ok := s.clients.Load("a")
+			loaded <- ok
+			handler.done()
+		}()
+
+		started := time.Now()
+		if err := step.stop(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if elapsed := time.Since(started); elapsed < 30*time.Millisecond || elapsed >= time.Second {
+			t.Errorf("%s returned after %s, want once the handler finished", step.name, elapsed)
+		}
+		if !<-loaded {
+			t.Errorf("%s dropped the client under a running handler", step.name)
+		}
+	}
+
+	if _, ok := s.clients.Load("a"); ok {
+		t.Error("client still loaded after logout")
+	}
+}
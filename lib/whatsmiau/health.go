@@ -0,0 +1,52 @@
+package whatsmiau
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	SubsystemDatabase = "database"
+	SubsystemEmitter  = "emitter"
+)
+
+var ErrEmitterStopped = errors.New("event emitter stopped")
+
+// HealthError names the subsystem that failed the health check
+type HealthError struct {
+	Subsystem string `json:"subsystem"`
+	Err       error  `json:"-"`
+}
+
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Subsystem, e.Err)
+}
+
+func (e *HealthError) Unwrap() error {
+	return e.Err
+}
+
+// Health checks the service itself, independent of any instance: the device
+// store database answers and the emitter goroutine is running. It fails once
+// shutdown starts. Use Status for the readiness of a single instance.
+func (s *Whatsmiau) Health(ctx context.Context) error {
+	if s.closing.Load() {
+		return &HealthError{Subsystem: SubsystemEmitter, Err: ErrShuttingDown}
+	}
+
+	select {
+	case <-s.emitterDone:
+		return &HealthError{Subsystem: SubsystemEmitter, Err: ErrEmitterStopped}
+	default:
+	}
+
+	// an indexed lookup of a device that never exists, nil without error
+	if _, err := s.container.GetDevice(ctx, types.EmptyJID); err != nil {
+		return &HealthError{Subsystem: SubsystemDatabase, Err: err}
+	}
+
+	return nil
+}
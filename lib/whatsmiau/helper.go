@@ -9,20 +9,25 @@ import (
 	"io"
 	"math"
 	"mime"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
+	"golang.org/x/net/proxy"
 )
 
 func b64(b []byte) string {
@@ -40,13 +45,14 @@ func i64(n int64) string {
 	return strconv.FormatInt(n, 10)
 }
 
-func (s *Whatsmiau) getCtx(ctx context.Context, url string) (*http.Response, error) {
+func (s *Whatsmiau) getCtx(ctx context.Context, instanceID, url string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
+	s.setLocale(req, instanceID)
 
-	res, err := s.httpClient.Do(req)
+	res, err := s.fetchClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +60,13 @@ func (s *Whatsmiau) getCtx(ctx context.Context, url string) (*http.Response, err
 	return res, nil
 }
 
+// setLocale asks for content in the instance locale, if it has one
+func (s *Whatsmiau) setLocale(req *http.Request, instanceID string) {
+	if instanceFound := s.getInstanceCached(instanceID); instanceFound != nil && len(instanceFound.Locale) > 0 {
+		req.Header.Set("Accept-Language", instanceFound.Locale)
+	}
+}
+
 // Returns audioConverted, waveform, duration and an error
 func convertAudio(data []byte, bars int) ([]byte, []byte, float64, error) {
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
@@ -83,10 +96,15 @@ func convertAudio(data []byte, bars int) ([]byte, []byte, float64, error) {
 		"pipe:1",
 	).Output()
 	if err != nil {
+		// only a failed decode is the input's fault, not ffmpeg failing to run
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, nil, 0, fmt.Errorf("%w: %s", ErrInvalidAudio, bytes.TrimSpace(exitErr.Stderr))
+		}
 		return nil, nil, 0, fmt.Errorf("failed running ffmpeg: %w", err)
 	}
 	if len(out) < 2 {
-		return nil, nil, 0, errors.New("no audio data after decoding")
+		return nil, nil, 0, fmt.Errorf("%w: no audio data after decoding", ErrInvalidAudio)
 	}
 
 	// Also convert to Ogg/Opus for stable playback/sharing
@@ -251,6 +269,49 @@ func extractExtFromFile(fileName, mimeType string, file *os.File) string {
 	return strings.TrimPrefix(ext, ".")
 }
 
+// canIgnoreFromMe returns true if own messages shouldn't be emitted, by the
+// instance setting or EMIT_FROM_ME when the instance doesn't set it
+func canIgnoreFromMe(msg *events.Message, instance *models.Instance) bool {
+	if !msg.Info.IsFromMe {
+		return false
+	}
+
+	if instance.EmitFromMe != nil {
+		return !*instance.EmitFromMe
+	}
+
+	return !env.Env.EmitFromMe
+}
+
+// autoDownloadMedia returns true if inbound media should be fetched, by the
+// instance setting or AUTO_DOWNLOAD_MEDIA when the instance doesn't set it
+func autoDownloadMedia(instance *models.Instance) bool {
+	if instance.AutoDownloadMedia != nil {
+		return *instance.AutoDownloadMedia
+	}
+
+	return env.Env.AutoDownloadMedia
+}
+
+// shouldAutoMarkRead returns true if an inbound message should get a read
+// receipt, by the instance setting or AUTO_MARK_READ when the instance doesn't
+// set it. Status updates are only read with readStatus.
+func shouldAutoMarkRead(msg *events.Message, instance *models.Instance) bool {
+	if msg.Info.IsFromMe {
+		return false
+	}
+
+	if msg.Info.Chat == types.StatusBroadcastJID && !instance.ReadStatus {
+		return false
+	}
+
+	if instance.AutoMarkRead != nil {
+		return *instance.AutoMarkRead
+	}
+
+	return env.Env.AutoMarkRead
+}
+
 func canIgnoreMessage(msg *events.Message) bool {
 	return strings.Contains(msg.Info.Chat.String(), "status")
 }
@@ -305,31 +366,86 @@ func canIgnoreGroup(evt interface{}, instance *models.Instance) bool {
 		}
 
 		jid = pushName.JID.String()
+	case *events.ChatPresence:
+		presence, ok := evt.(*events.ChatPresence)
+		if !ok {
+			return false
+		}
+
+		jid = presence.Chat.String()
 	}
 
 	return strings.HasSuffix(jid, "@g.us")
 }
 
-func configProxy(client *whatsmeow.Client, instanceProxy models.InstanceProxy) {
+// configProxy points the client's websocket and media transports at the
+// instance proxy
+func configProxy(client *whatsmeow.Client, instanceProxy models.InstanceProxy) error {
 	if len(instanceProxy.ProxyHost) <= 0 {
-		return
+		return nil
 	}
 
-	var jid string
-	if client.Store.ID != nil {
-		jid = client.Store.ID.String()
+	proxyUrl, err := ProxyURL(instanceProxy)
+	if err != nil {
+		return err
 	}
 
+	return setClientProxy(client, proxyUrl)
+}
+
+// setClientProxy configures the client transports, SOCKS5 proxies get their own
+// dialer since they can't go through http.Transport.Proxy
+func setClientProxy(client *whatsmeow.Client, proxyUrl *url.URL) error {
 	opts := whatsmeow.SetProxyOptions{
 		NoMedia: env.Env.ProxyNoMedia,
 	}
 
-	proxyUrl := mountProxyUrl(instanceProxy)
-	if err := client.SetProxyAddress(proxyUrl, opts); err != nil {
-		zap.L().Error("failed to set proxy address", zap.Error(err), zap.Any("instanceProxy", instanceProxy), zap.Any("jid", jid))
+	switch proxyUrl.Scheme {
+	case "http", "https":
+		client.SetProxy(http.ProxyURL(proxyUrl), opts)
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(proxyUrl, &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create socks5 dialer: %w", err)
+		}
+		client.SetSOCKSProxy(dialer, opts)
 	}
+
+	return nil
 }
 
-func mountProxyUrl(proxy models.InstanceProxy) string {
-	return fmt.Sprintf("%s://%s:%s@%s:%s", proxy.ProxyProtocol, proxy.ProxyUsername, proxy.ProxyPassword, proxy.ProxyHost, proxy.ProxyPort)
+// ProxyURL builds the proxy url of an instance, escaping the credentials and
+// rejecting protocols the client can't dial through
+func ProxyURL(instanceProxy models.InstanceProxy) (*url.URL, error) {
+	scheme := strings.ToLower(instanceProxy.ProxyProtocol)
+	if !IsSupportedProxyScheme(scheme) {
+		return nil, fmt.Errorf("unsupported proxy protocol %q, expected http, https, socks5 or socks5h", instanceProxy.ProxyProtocol)
+	}
+
+	if len(instanceProxy.ProxyHost) <= 0 || len(instanceProxy.ProxyPort) <= 0 {
+		return nil, fmt.Errorf("proxy host and port are required")
+	}
+
+	u := &url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(instanceProxy.ProxyHost, instanceProxy.ProxyPort),
+	}
+	if len(instanceProxy.ProxyUsername) > 0 {
+		u.User = url.UserPassword(instanceProxy.ProxyUsername, instanceProxy.ProxyPassword)
+	}
+
+	return u, nil
+}
+
+// IsSupportedProxyScheme reports whether the lowercase scheme can be used as an instance proxy
+func IsSupportedProxyScheme(scheme string) bool {
+	switch scheme {
+	case "http", "https", "socks5", "socks5h":
+		return true
+	}
+
+	return false
 }
@@ -0,0 +1,29 @@
+package whatsmiau
+
+import (
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var testOwnJID = types.NewJID("5511900000000", types.DefaultUserServer)
+
+// newTestMiau has no clients loaded, so GetJidLid returns the addresses as is
+func newTestMiau() *Whatsmiau {
+	return &Whatsmiau{
+		clients:        xsync.NewMap[string, *whatsmeow.Client](),
+		lidMappings:    xsync.NewMap[string, *lidMapping](),
+		recentMessages: xsync.NewMap[string, *recentMessage](),
+		presenceSubs:   xsync.NewMap[string, types.JID](),
+	}
+}
+
+// newTestClient is logged in as testOwnJID, without a socket or a database
+func newTestClient(t *testing.T) *whatsmeow.Client {
+	t.Helper()
+	own := testOwnJID
+	return whatsmeow.NewClient(&store.Device{ID: &own}, nil)
+}
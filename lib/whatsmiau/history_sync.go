@@ -0,0 +1,167 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waHistorySync"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrHistorySyncUnavailable = errors.New("on-demand history sync is unavailable")
+	ErrHistorySyncInProgress  = errors.New("history sync already requested for this chat")
+)
+
+// RequestHistory asks the phone for up to count messages of the chat sent before
+// beforeMessageID, or before the newest stored message of the chat when empty.
+// The anchor must be in the message store, the request needs its timestamp.
+// Messages arrive later as an on-demand history sync and are handled like new
+// ones, already stored messages are skipped. One request per chat runs at a
+// time, until the phone answers or HISTORY_SYNC_TIMEOUT passes.
+func (s *Whatsmiau) RequestHistory(ctx context.Context, id string, chat types.JID, count int, beforeMessageID string) error {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() || client.Store.ID == nil {
+		return fmt.Errorf("%w: %w", ErrHistorySyncUnavailable, ErrNotLoggedIn)
+	}
+
+	if !env.Env.StoreMessages {
+		return fmt.Errorf("%w: %w", ErrHistorySyncUnavailable, ErrMessageStoreDisabled)
+	}
+
+	anchor, err := s.historyAnchor(ctx, id, chat, beforeMessageID)
+	if err != nil {
+		return err
+	}
+
+	anchorChat, err := types.ParseJID(anchor.Chat)
+	if err != nil {
+		return fmt.Errorf("invalid stored chat: %w", err)
+	}
+
+	key := s.historyRequestKey(ctx, id, anchorChat)
+	pending := &historyRequest{at: time.Now()}
+	if _, loaded := s.historyRequests.LoadOrStore(key, pending); loaded {
+		return ErrHistorySyncInProgress
+	}
+	time.AfterFunc(env.Env.HistorySyncTimeout, func() {
+		s.forgetHistoryRequest(key, pending)
+	})
+
+	request := client.BuildHistorySyncRequest(&types.MessageInfo{
+		MessageSource: types.MessageSource{
+			Chat:     anchorChat,
+			IsFromMe: anchor.FromMe,
+		},
+		ID:        anchor.ID,
+		Timestamp: anchor.Timestamp,
+	}, count)
+
+	if _, err := client.SendMessage(ctx, client.Store.ID.ToNonAD(), request, whatsmeow.SendRequestExtra{Peer: true}); err != nil {
+		s.forgetHistoryRequest(key, pending)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Whatsmiau) historyAnchor(ctx context.Context, id string, chat types.JID, beforeMessageID string) (*models.StoredMessage, error) {
+	if len(beforeMessageID) > 0 {
+		return s.getStoredMessage(ctx, id, beforeMessageID)
+	}
+
+	chatJid, _ := s.GetJidLid(ctx, id, chat)
+	newest, err := s.messages.ListChat(ctx, id, chatJid, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(newest) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	return &newest[0], nil
+}
+
+// historyRequest is compared by pointer, a zero sized struct could share its
+// address with other values
+type historyRequest struct {
+	at time.Time
+}
+
+// forgetHistoryRequest ends the request unless the phone already answered it
+// and a newer one for the same chat took its place
+func (s *Whatsmiau) forgetHistoryRequest(key string, pending *historyRequest) {
+	s.historyRequests.Compute(key, func(cached *historyRequest, loaded bool) (*historyRequest, xsync.ComputeOp) {
+		if loaded && cached == pending {
+			return nil, xsync.DeleteOp
+		}
+		return cached, xsync.CancelOp
+	})
+}
+
+// historyRequestKey identifies the chat by its phone number when known, the
+// phone may answer for the lid of a chat requested by number or the other way
+func (s *Whatsmiau) historyRequestKey(ctx context.Context, id string, chat types.JID) string {
+	jid, _ := s.GetJidLid(ctx, id, chat)
+	return id + ":" + jid
+}
+
+// handleOnDemandHistory replays the messages of an on-demand sync oldest first
+// through the message handler, so they are stored and emitted like live ones
+// but flagged as history, see isHistoryMessage
+func (s *Whatsmiau) handleOnDemandHistory(id string, instance *models.Instance, e *events.HistorySync, eventMap map[string]bool) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return
+	}
+
+	for _, conversation := range e.Data.GetConversations() {
+		chat, err := types.ParseJID(conversation.GetID())
+		if err != nil {
+			zap.L().Warn("invalid history sync conversation", zap.String("id", id), zap.String("chat", conversation.GetID()), zap.Error(err))
+			continue
+		}
+		s.historyRequests.Delete(s.historyRequestKey(context.Background(), id, chat))
+
+		parsed := make([]*events.Message, 0, len(conversation.GetMessages()))
+		for _, message := range conversation.GetMessages() {
+			evt, err := client.ParseWebMessage(chat, message.GetMessage())
+			if err != nil {
+				zap.L().Warn("failed to parse history sync message", zap.String("id", id), zap.String("chat", chat.String()), zap.Error(err))
+				continue
+			}
+			parsed = append(parsed, evt)
+		}
+		sort.Slice(parsed, func(i, j int) bool {
+			return parsed[i].Info.Timestamp.Before(parsed[j].Info.Timestamp)
+		})
+
+		for _, evt := range parsed {
+			s.handleMessageEvent(id, instance, evt, eventMap)
+		}
+	}
+}
+
+func isOnDemandHistorySync(e *events.HistorySync) bool {
+	return e.Data.GetSyncType() == waHistorySync.HistorySync_ON_DEMAND
+}
+
+// isHistoryMessage reports messages replayed from a history sync, parsed from
+// web messages. They are emitted with the history flag but not marked read and
+// their group invites aren't auto-joined, they already happened on the phone.
+func isHistoryMessage(e *events.Message) bool {
+	return e.SourceWebMsg != nil
+}
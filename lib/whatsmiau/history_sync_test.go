@@ -0,0 +1,59 @@
+package whatsmiau
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow/proto/waWeb"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestHistoryRequestKey(t *testing.T) {
+	previous := env.Env.LidCacheTTL
+	env.Env.LidCacheTTL = time.Hour
+	t.Cleanup(func() { env.Env.LidCacheTTL = previous })
+
+	s := newTestMiau()
+	s.clients.Store("instance", newTestClient(t))
+	pn := types.NewJID("5511911111111", types.DefaultUserServer)
+	lid := types.NewJID("123456789", types.HiddenUserServer)
+	s.cacheLidMapping("instance", pn, lid)
+
+	ctx := context.Background()
+	if byPN, byLID := s.historyRequestKey(ctx, "instance", pn), s.historyRequestKey(ctx, "instance", lid); byPN != byLID {
+		t.Errorf("key by number = %q, by lid = %q, want the same", byPN, byLID)
+	}
+	if got := s.historyRequestKey(ctx, "instance", pn); got != "instance:"+pn.String() {
+		t.Errorf("key = %q, want it by phone number", got)
+	}
+}
+
+func TestForgetHistoryRequestKeepsNewer(t *testing.T) {
+	s := &Whatsmiau{historyRequests: xsync.NewMap[string, *historyRequest]()}
+	stale, newer := &historyRequest{at: time.Now()}, &historyRequest{at: time.Now()}
+
+	// the phone answered the stale request and a new one was made
+	s.historyRequests.Store("instance:chat", newer)
+	s.forgetHistoryRequest("instance:chat", stale)
+	if cached, ok := s.historyRequests.Load("instance:chat"); !ok || cached != newer {
+		t.Fatal("the timer of a stale request removed the newer one")
+	}
+
+	s.forgetHistoryRequest("instance:chat", newer)
+	if _, ok := s.historyRequests.Load("instance:chat"); ok {
+		t.Fatal("request wasn't forgotten")
+	}
+}
+
+func TestIsHistoryMessage(t *testing.T) {
+	if isHistoryMessage(&events.Message{}) {
+		t.Error("live message reported as history")
+	}
+	if !isHistoryMessage(&events.Message{SourceWebMsg: &waWeb.WebMessageInfo{}}) {
+		t.Error("message parsed from a history sync not reported as history")
+	}
+}
@@ -0,0 +1,61 @@
+package whatsmiau
+
+import (
+	"slices"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Inbound categories an instance can drop with ignoreInbound
+const (
+	InboundStatus     = "status"     // status broadcasts
+	InboundNewsletter = "newsletter" // channels
+	InboundBroadcast  = "broadcast"  // broadcast lists
+	InboundGroup      = "group"
+	InboundPresence   = "presence"
+	InboundReceipt    = "receipt"
+)
+
+// filteredInbound tells whether the event belongs to a category the instance
+// ignores. It runs before the handler semaphore, so it only reads the instance
+// cache: on a miss nothing is filtered and the handler fills the cache.
+func (s *Whatsmiau) filteredInbound(id string, evt any) bool {
+	instance, ok := s.instanceCache.Load(id)
+	if !ok || len(instance.IgnoreInbound) == 0 {
+		return false
+	}
+
+	var chat types.JID
+	switch e := evt.(type) {
+	case *events.Message:
+		chat = e.Info.Chat
+	case *events.Receipt:
+		if slices.Contains(instance.IgnoreInbound, InboundReceipt) {
+			return true
+		}
+		chat = e.Chat
+	case *events.ChatPresence:
+		if slices.Contains(instance.IgnoreInbound, InboundPresence) {
+			return true
+		}
+		chat = e.Chat
+	case *events.Presence:
+		return slices.Contains(instance.IgnoreInbound, InboundPresence)
+	default:
+		return false
+	}
+
+	switch {
+	case chat == types.StatusBroadcastJID:
+		return slices.Contains(instance.IgnoreInbound, InboundStatus)
+	case chat.Server == types.BroadcastServer:
+		return slices.Contains(instance.IgnoreInbound, InboundBroadcast)
+	case chat.Server == types.NewsletterServer:
+		return slices.Contains(instance.IgnoreInbound, InboundNewsletter)
+	case chat.Server == types.GroupServer:
+		return slices.Contains(instance.IgnoreInbound, InboundGroup)
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+package whatsmiau
+
+import (
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// lidMapping is the other address of a contact, the lid of a phone number or
+// the phone number of a lid
+type lidMapping struct {
+	jid string
+}
+
+func (s *Whatsmiau) cachedLidMapping(id string, jid types.JID) (string, bool) {
+	mapping, ok := s.lidMappings.Load(lidMappingKey(id, jid))
+	if !ok {
+		return "", false
+	}
+
+	return mapping.jid, true
+}
+
+// cacheLidMapping stores both directions of a pn/lid pair for LID_CACHE_TTL.
+// A full cache stores nothing until entries expire, lookups then go to the store.
+func (s *Whatsmiau) cacheLidMapping(id string, pn, lid types.JID) {
+	ttl := env.Env.LidCacheTTL
+	if ttl <= 0 || pn.IsEmpty() || lid.IsEmpty() {
+		return
+	}
+
+	if limit := env.Env.LidCacheSize; limit > 0 && s.lidMappings.Size() >= limit {
+		return
+	}
+
+	s.storeLidMapping(lidMappingKey(id, pn), &lidMapping{jid: lid.ToNonAD().String()}, ttl)
+	s.storeLidMapping(lidMappingKey(id, lid), &lidMapping{jid: pn.ToNonAD().String()}, ttl)
+}
+
+func (s *Whatsmiau) storeLidMapping(key string, mapping *lidMapping, ttl time.Duration) {
+	s.lidMappings.Store(key, mapping)
+	time.AfterFunc(ttl, func() {
+		// a newer entry may have been stored since, it has its own timer
+		s.lidMappings.Compute(key, func(cached *lidMapping, loaded bool) (*lidMapping, xsync.ComputeOp) {
+			if loaded && cached == mapping {
+				return nil, xsync.DeleteOp
+			}
+			return cached, xsync.CancelOp
+		})
+	})
+}
+
+// refreshLidMapping caches the pn/lid pair a message carries in its sender
+// alt, replacing entries that may be stale
+func (s *Whatsmiau) refreshLidMapping(id string, info types.MessageInfo) {
+	sender, alt := info.Sender, info.SenderAlt
+	switch {
+	case sender.Server == types.DefaultUserServer && alt.Server == types.HiddenUserServer:
+		s.cacheLidMapping(id, sender, alt)
+	case sender.Server == types.HiddenUserServer && alt.Server == types.DefaultUserServer:
+		s.cacheLidMapping(id, alt, sender)
+	}
+}
+
+func lidMappingKey(id string, jid types.JID) string {
+	return id + ":" + jid.ToNonAD().String()
+}
@@ -0,0 +1,96 @@
+package whatsmiau
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	testPN  = types.NewJID("5511911111111", types.DefaultUserServer)
+	testLID = types.NewJID("123456789", types.HiddenUserServer)
+)
+
+// countingLIDs knows the testPN/testLID pair and counts the lookups reaching it
+type countingLIDs struct {
+	store.LIDStore
+	hits atomic.Int64
+}
+
+func (c *countingLIDs) GetLIDForPN(_ context.Context, pn types.JID) (types.JID, error) {
+	c.hits.Add(1)
+	if pn.User == testPN.User {
+		return testLID, nil
+	}
+	return types.EmptyJID, nil
+}
+
+func (c *countingLIDs) GetPNForLID(_ context.Context, lid types.JID) (types.JID, error) {
+	c.hits.Add(1)
+	if lid.User == testLID.User {
+		return testPN, nil
+	}
+	return types.EmptyJID, nil
+}
+
+func newLidTestMiau(tb testing.TB, ttl time.Duration) (*Whatsmiau, *countingLIDs) {
+	tb.Helper()
+	previous := env.Env.LidCacheTTL
+	env.Env.LidCacheTTL = ttl
+	tb.Cleanup(func() { env.Env.LidCacheTTL = previous })
+
+	lids := &countingLIDs{}
+	own := testOwnJID
+	s := newTestMiau()
+	s.clients.Store("instance", whatsmeow.NewClient(&store.Device{ID: &own, LIDs: lids}, nil))
+	return s, lids
+}
+
+func TestGetJidLidCachesStoreLookups(t *testing.T) {
+	s, lids := newLidTestMiau(t, time.Hour)
+	ctx := context.Background()
+
+	for range 10 {
+		if jid, lid := s.GetJidLid(ctx, "instance", testPN); jid != testPN.String() || lid != testLID.String() {
+			t.Fatalf("GetJidLid(pn) = %q, %q", jid, lid)
+		}
+		if jid, lid := s.GetJidLid(ctx, "instance", testLID); jid != testPN.String() || lid != testLID.String() {
+			t.Fatalf("GetJidLid(lid) = %q, %q", jid, lid)
+		}
+	}
+
+	// the first lookup caches both directions
+	if hits := lids.hits.Load(); hits != 1 {
+		t.Errorf("store hits = %d, want 1", hits)
+	}
+
+	unknown := types.NewJID("5511922222222", types.DefaultUserServer)
+	if jid, lid := s.GetJidLid(ctx, "instance", unknown); jid != unknown.String() || lid != "" {
+		t.Errorf("GetJidLid(unknown) = %q, %q, want the number without lid", jid, lid)
+	}
+}
+
+func benchmarkGetJidLid(b *testing.B, ttl time.Duration) {
+	s, lids := newLidTestMiau(b, ttl)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for range b.N {
+		s.GetJidLid(ctx, "instance", testPN)
+	}
+	b.ReportMetric(float64(lids.hits.Load())/float64(b.N), "store-hits/op")
+}
+
+func BenchmarkGetJidLidCached(b *testing.B) {
+	benchmarkGetJidLid(b, time.Hour)
+}
+
+func BenchmarkGetJidLidUncached(b *testing.B) {
+	benchmarkGetJidLid(b, 0)
+}
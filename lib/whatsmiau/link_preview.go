@@ -0,0 +1,307 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"google.golang.org/protobuf/proto"
+)
+
+type FetchLinkPreviewRequest struct {
+	InstanceID string `json:"instance_id"`
+	Url        string `json:"url"`
+}
+
+type LinkPreview struct {
+	Url          string `json:"url"`
+	CanonicalUrl string `json:"canonical_url,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Image        string `json:"image,omitempty"`
+	SiteName     string `json:"site_name,omitempty"`
+}
+
+// FetchLinkPreview downloads the page head and extracts OpenGraph/Twitter card
+// metadata. The fetch is bounded by LINK_PREVIEW_TIMEOUT and LINK_PREVIEW_MAX_BYTES,
+// pages and redirects to private or loopback addresses are refused.
+func (s *Whatsmiau) FetchLinkPreview(ctx context.Context, data *FetchLinkPreviewRequest) (*LinkPreview, error) {
+	pageUrl, err := url.Parse(data.Url)
+	if err != nil || (pageUrl.Scheme != "http" && pageUrl.Scheme != "https") {
+		return nil, fmt.Errorf("invalid url: %s", data.Url)
+	}
+
+	ctx, c := context.WithTimeout(ctx, env.Env.LinkPreviewTimeout)
+	defer c()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	s.setLocale(req, data.InstanceID)
+
+	res, err := s.fetchClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("failed to fetch link preview, status %d", res.StatusCode)
+	}
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, fmt.Errorf("unsupported link preview content type: %s", contentType)
+	}
+
+	preview := parseLinkPreview(io.LimitReader(res.Body, env.Env.LinkPreviewMaxBytes))
+	preview.Url = res.Request.URL.String()
+	preview.Image = resolveUrl(res.Request.URL, preview.Image)
+	preview.CanonicalUrl = resolveUrl(res.Request.URL, preview.CanonicalUrl)
+
+	return preview, nil
+}
+
+// parseLinkPreview reads html until the end of <head>, og: tags take priority over twitter: ones
+func parseLinkPreview(body io.Reader) *LinkPreview {
+	var (
+		preview   LinkPreview
+		twitter   LinkPreview
+		htmlTitle string
+		inTitle   bool
+	)
+
+	tokenizer := html.NewTokenizer(body)
+loop:
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			break loop
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "head" {
+				break loop
+			}
+			inTitle = false
+		case html.TextToken:
+			if inTitle && htmlTitle == "" {
+				htmlTitle = strings.TrimSpace(string(tokenizer.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				break loop
+			case "title":
+				inTitle = true
+			case "meta", "link":
+				attrs := map[string]string{}
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = tokenizer.TagAttr()
+					attrs[strings.ToLower(string(key))] = string(val)
+				}
+
+				if string(name) == "link" {
+					if strings.EqualFold(attrs["rel"], "canonical") {
+						preview.CanonicalUrl = attrs["href"]
+					}
+					continue
+				}
+
+				property := attrs["property"]
+				if property == "" {
+					property = attrs["name"]
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch strings.ToLower(property) {
+				case "og:title":
+					preview.Title = content
+				case "og:description":
+					preview.Description = content
+				case "og:image", "og:image:url":
+					if preview.Image == "" {
+						preview.Image = content
+					}
+				case "og:url":
+					preview.CanonicalUrl = content
+				case "og:site_name":
+					preview.SiteName = content
+				case "twitter:title":
+					twitter.Title = content
+				case "twitter:description":
+					twitter.Description = content
+				case "twitter:image", "twitter:image:src":
+					twitter.Image = content
+				case "description":
+					if twitter.Description == "" {
+						twitter.Description = content
+					}
+				}
+			}
+		}
+	}
+
+	if preview.Title == "" {
+		preview.Title = twitter.Title
+	}
+	if preview.Title == "" {
+		preview.Title = htmlTitle
+	}
+	if preview.Description == "" {
+		preview.Description = twitter.Description
+	}
+	if preview.Image == "" {
+		preview.Image = twitter.Image
+	}
+
+	return &preview
+}
+
+func resolveUrl(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+
+	return base.ResolveReference(parsed).String()
+}
+
+const (
+	linkThumbnailSize     = 300     // longest side of the thumbnail attached to sent texts
+	linkThumbnailMaxBytes = 5 << 20 // bigger preview images are skipped
+)
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// shouldGenerateLinkPreview resolves the send option, then the instance
+// setting, then GENERATE_LINK_PREVIEW
+func (s *Whatsmiau) shouldGenerateLinkPreview(instanceID string, opts *SendTextOptions) bool {
+	if opts != nil && opts.GenerateLinkPreview != nil {
+		return *opts.GenerateLinkPreview
+	}
+
+	if instanceFound := s.getInstanceCached(instanceID); instanceFound != nil && instanceFound.GenerateLinkPreview != nil {
+		return *instanceFound.GenerateLinkPreview
+	}
+
+	return env.Env.GenerateLinkPreview
+}
+
+// textLinkPreview builds the preview of the first url in the text, bounded by
+// LINK_PREVIEW_TIMEOUT. Texts without urls or whose page can't be fetched get
+// nil and are sent as they are; a failed thumbnail only drops the image.
+func (s *Whatsmiau) textLinkPreview(ctx context.Context, instanceID string, client *whatsmeow.Client, text string) *waE2E.ExtendedTextMessage {
+	matched := strings.TrimRight(urlPattern.FindString(text), ".,;:!?)]}'")
+	if matched == "" {
+		return nil
+	}
+
+	ctx, c := context.WithTimeout(ctx, env.Env.LinkPreviewTimeout)
+	defer c()
+
+	preview, err := s.FetchLinkPreview(ctx, &FetchLinkPreviewRequest{InstanceID: instanceID, Url: matched})
+	if err != nil {
+		zap.L().Debug("sending text without link preview", zap.String("instance", instanceID), zap.String("url", matched), zap.Error(err))
+		return nil
+	}
+
+	if preview.Title == "" && preview.Description == "" {
+		return nil
+	}
+
+	message := &waE2E.ExtendedTextMessage{
+		MatchedText: proto.String(matched),
+		Title:       proto.String(preview.Title),
+		Description: proto.String(preview.Description),
+		PreviewType: waE2E.ExtendedTextMessage_NONE.Enum(),
+	}
+	if preview.Image == "" {
+		return message
+	}
+
+	thumbnail, width, height, err := s.linkThumbnail(ctx, instanceID, preview.Image)
+	if err != nil {
+		zap.L().Debug("sending link preview without thumbnail", zap.String("instance", instanceID), zap.String("image", preview.Image), zap.Error(err))
+		return message
+	}
+
+	message.JPEGThumbnail = thumbnail
+	message.ThumbnailWidth, message.ThumbnailHeight = proto.Uint32(uint32(width)), proto.Uint32(uint32(height))
+	message.PreviewType = waE2E.ExtendedTextMessage_IMAGE.Enum()
+
+	// the uploaded copy lets clients render the large preview, the inline one is enough otherwise
+	uploaded, err := client.Upload(ctx, thumbnail, whatsmeow.MediaLinkThumbnail)
+	if err != nil {
+		zap.L().Debug("failed to upload link thumbnail", zap.String("instance", instanceID), zap.Error(err))
+		return message
+	}
+
+	message.ThumbnailDirectPath = proto.String(uploaded.DirectPath)
+	message.ThumbnailSHA256 = uploaded.FileSHA256
+	message.ThumbnailEncSHA256 = uploaded.FileEncSHA256
+	message.MediaKey = uploaded.MediaKey
+	message.MediaKeyTimestamp = proto.Int64(time.Now().Unix())
+
+	return message
+}
+
+// linkThumbnail downloads the preview image and scales it down to a JPEG of at
+// most linkThumbnailSize on the longest side
+func (s *Whatsmiau) linkThumbnail(ctx context.Context, instanceID, imageUrl string) ([]byte, int, int, error) {
+	res, err := s.getCtx(ctx, instanceID, imageUrl)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, 0, 0, fmt.Errorf("failed to download preview image, status %d", res.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(res.Body, linkThumbnailMaxBytes+1))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(content) > linkThumbnailMaxBytes {
+		return nil, 0, 0, fmt.Errorf("preview image bigger than %d bytes", linkThumbnailMaxBytes)
+	}
+
+	img, err := decodeImage(content)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, 0, 0, fmt.Errorf("empty preview image")
+	}
+	if longest := max(width, height); longest > linkThumbnailSize {
+		width, height = max(width*linkThumbnailSize/longest, 1), max(height*linkThumbnailSize/longest, 1)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleImage(img, bounds, width, height), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, err
+	}
+
+	return buf.Bytes(), width, height, nil
+}
@@ -0,0 +1,135 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// limits enforced by the WhatsApp clients, lists above them aren't rendered
+const (
+	MaxListSections = 10
+	MaxListRows     = 10 // across all sections
+)
+
+var (
+	ErrInvalidList         = errors.New("invalid list")
+	ErrTooManyListSections = fmt.Errorf("%w: more than %d sections", ErrInvalidList, MaxListSections)
+	ErrTooManyListRows     = fmt.Errorf("%w: more than %d rows", ErrInvalidList, MaxListRows)
+)
+
+type ListMessage struct {
+	Title       string        `json:"title"`
+	Description string        `json:"description"` // body text
+	ButtonText  string        `json:"button_text"` // label of the button opening the menu
+	FooterText  string        `json:"footer_text"`
+	Sections    []ListSection `json:"sections"`
+}
+
+type ListSection struct {
+	Title string    `json:"title"` // required when there is more than one section
+	Rows  []ListRow `json:"rows"`
+}
+
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// SendList sends a menu opened by ButtonText, the selected row arrives as
+// listResponseMessage with its id in singleSelectReply.selectedRowId. Returns
+// the message id.
+func (s *Whatsmiau) SendList(ctx context.Context, id string, to types.JID, list ListMessage) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := buildListMessage(&list)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	message.ListMessage.ContextInfo = s.defaultExpirationContext(id, to)
+	res, err := s.sendReserved(ctx, id, client, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+func buildListMessage(list *ListMessage) (*waE2E.Message, error) {
+	if list.ButtonText == "" {
+		return nil, fmt.Errorf("%w: button text is required", ErrInvalidList)
+	}
+	if len(list.Sections) == 0 {
+		return nil, fmt.Errorf("%w: at least one section is required", ErrInvalidList)
+	}
+	if len(list.Sections) > MaxListSections {
+		return nil, ErrTooManyListSections
+	}
+
+	var (
+		rows     int
+		seen     = make(map[string]bool)
+		sections = make([]*waE2E.ListMessage_Section, 0, len(list.Sections))
+	)
+	for i, section := range list.Sections {
+		if len(section.Rows) == 0 {
+			return nil, fmt.Errorf("%w: section %d has no rows", ErrInvalidList, i)
+		}
+		if section.Title == "" && len(list.Sections) > 1 {
+			return nil, fmt.Errorf("%w: section %d requires a title", ErrInvalidList, i)
+		}
+
+		rows += len(section.Rows)
+		if rows > MaxListRows {
+			return nil, ErrTooManyListRows
+		}
+
+		result := &waE2E.ListMessage_Section{
+			Title: proto.String(section.Title),
+			Rows:  make([]*waE2E.ListMessage_Row, 0, len(section.Rows)),
+		}
+		for _, row := range section.Rows {
+			if row.ID == "" || row.Title == "" {
+				return nil, fmt.Errorf("%w: every row requires id and title", ErrInvalidList)
+			}
+			if seen[row.ID] {
+				return nil, fmt.Errorf("%w: duplicated row id %q", ErrInvalidList, row.ID)
+			}
+			seen[row.ID] = true
+
+			result.Rows = append(result.Rows, &waE2E.ListMessage_Row{
+				RowID:       proto.String(row.ID),
+				Title:       proto.String(row.Title),
+				Description: proto.String(row.Description),
+			})
+		}
+		sections = append(sections, result)
+	}
+
+	return &waE2E.Message{
+		ListMessage: &waE2E.ListMessage{
+			Title:       proto.String(list.Title),
+			Description: proto.String(list.Description),
+			ButtonText:  proto.String(list.ButtonText),
+			FooterText:  proto.String(list.FooterText),
+			ListType:    waE2E.ListMessage_SINGLE_SELECT.Enum(),
+			Sections:    sections,
+		},
+	}, nil
+}
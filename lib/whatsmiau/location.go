@@ -0,0 +1,101 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidLocation = errors.New("invalid location")
+
+type RequestLocationRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	Body       string     `json:"body"`
+}
+
+type RequestLocationResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestLocation sends an interactive message with a "send location" button.
+// The shared location arrives as a regular locationMessage whose contextInfo
+// stanzaId points to this message.
+func (s *Whatsmiau) RequestLocation(ctx context.Context, data *RequestLocationRequest) (*RequestLocationResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, &waE2E.Message{
+		InteractiveMessage: &waE2E.InteractiveMessage{
+			Body: &waE2E.InteractiveMessage_Body{
+				Text: proto.String(data.Body),
+			},
+			InteractiveMessage: &waE2E.InteractiveMessage_NativeFlowMessage_{
+				NativeFlowMessage: &waE2E.InteractiveMessage_NativeFlowMessage{
+					Buttons: []*waE2E.InteractiveMessage_NativeFlowMessage_NativeFlowButton{{
+						Name:             proto.String("send_location"),
+						ButtonParamsJSON: proto.String("{}"),
+					}},
+				},
+			},
+			ContextInfo: s.defaultExpirationContext(data.InstanceID, *data.RemoteJID),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestLocationResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
+
+// SendLocation sends a fixed location pin, name and address are optional.
+// Returns the message id.
+func (s *Whatsmiau) SendLocation(ctx context.Context, id string, to types.JID, lat, lng float64, name, address string) (string, error) {
+	// negated so NaN, which fails every comparison, is out of range too
+	if !(lat >= -90 && lat <= 90) || !(lng >= -180 && lng <= 180) {
+		return "", fmt.Errorf("%w: coordinates out of range (%f, %f)", ErrInvalidLocation, lat, lng)
+	}
+
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	location := &waE2E.LocationMessage{
+		DegreesLatitude:  proto.Float64(lat),
+		DegreesLongitude: proto.Float64(lng),
+		ContextInfo:      s.defaultExpirationContext(id, to),
+	}
+	if name != "" {
+		location.Name = proto.String(name)
+	}
+	if address != "" {
+		location.Address = proto.String(address)
+	}
+
+	res, err := s.sendReserved(ctx, id, client, to, &waE2E.Message{LocationMessage: location})
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
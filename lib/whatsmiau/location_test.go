@@ -0,0 +1,32 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSendLocationInvalidCoordinates(t *testing.T) {
+	s := newTestMiau()
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+
+	for _, coordinates := range [][2]float64{
+		{91, 0},
+		{0, -181},
+		{math.NaN(), 0},
+		{0, math.NaN()},
+		{math.Inf(1), 0},
+		{0, math.Inf(-1)},
+	} {
+		if _, err := s.SendLocation(context.Background(), "instance", chat, coordinates[0], coordinates[1], "", ""); !errors.Is(err, ErrInvalidLocation) {
+			t.Errorf("SendLocation(%v) err = %v, want ErrInvalidLocation", coordinates, err)
+		}
+	}
+
+	if _, err := s.SendLocation(context.Background(), "instance", chat, -23.5, -46.6, "", ""); errors.Is(err, ErrInvalidLocation) {
+		t.Errorf("valid coordinates rejected: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+type LogoutAllDevicesResponse struct {
+	LoggedOut        []string `json:"logged_out"`        // instances of this server linked to the account
+	RemainingDevices []string `json:"remaining_devices"` // other companions, only the phone can unlink them
+}
+
+// LogoutAllDevices logs out every instance of this server linked to the same
+// account as id. WhatsApp only lets the primary phone unlink companions, so
+// devices we don't own (WhatsApp Web, desktop, other integrations) are reported
+// in RemainingDevices instead. Like Logout, it can't be undone: each instance
+// needs a new QR code scan.
+func (s *Whatsmiau) LogoutAllDevices(ctx context.Context, id string) (*LogoutAllDevicesResponse, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if client.Store.ID == nil {
+		return nil, whatsmeow.ErrNotLoggedIn
+	}
+	account := client.Store.ID.ToNonAD()
+
+	// fetched before logging out, the session is required for the query
+	devices, err := client.GetUserDevices(ctx, []types.JID{account})
+	if err != nil {
+		zap.L().Warn("failed to list account devices", zap.String("id", id), zap.Error(err))
+	}
+
+	owned := make(map[string]types.JID)
+	s.clients.Range(func(instanceID string, c *whatsmeow.Client) bool {
+		if c.Store.ID != nil && c.Store.ID.User == account.User {
+			owned[instanceID] = *c.Store.ID
+		}
+		return true
+	})
+
+	result := &LogoutAllDevicesResponse{
+		LoggedOut:        []string{},
+		RemainingDevices: []string{},
+	}
+
+	var errs []error
+	for instanceID := range owned {
+		if err := s.Logout(ctx, instanceID); err != nil {
+			zap.L().Error("failed to logout instance", zap.String("id", instanceID), zap.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		result.LoggedOut = append(result.LoggedOut, instanceID)
+	}
+
+	for _, device := range devices {
+		isOwned := false
+		for _, ownedDevice := range owned {
+			if ownedDevice.Device == device.Device {
+				isOwned = true
+				break
+			}
+		}
+		if device.Device == 0 || isOwned { // 0 is the phone
+			continue
+		}
+		result.RemainingDevices = append(result.RemainingDevices, device.String())
+	}
+
+	slices.Sort(result.LoggedOut)
+	s.emitLoggedOutAll(id, account.String(), result)
+
+	return result, errors.Join(errs...)
+}
+
+func (s *Whatsmiau) emitLoggedOutAll(id string, account string, result *LogoutAllDevicesResponse) {
+	instance := s.getInstance(id)
+	if instance == nil || !eventMapOf(instance)["LOGOUT_ALL_DEVICES"] {
+		return
+	}
+
+	wookData := &WookEvent[WookLogoutAllDevicesData]{
+		Instance: instance.ID,
+		Data: &WookLogoutAllDevicesData{
+			Account:          account,
+			LoggedOut:        result.LoggedOut,
+			RemainingDevices: result.RemainingDevices,
+			InstanceId:       instance.ID,
+		},
+		DateTime: time.Now(),
+		Event:    WookLogoutAllDevices,
+	}
+
+	s.emit(wookData, instance)
+}
@@ -0,0 +1,182 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/proto/waMmsRetry"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMediaExpired        = errors.New("media expired and wasn't re-uploaded by the sender")
+	ErrNoDownloadableMedia = errors.New("message has no downloadable media")
+)
+
+type DownloadMediaRequest struct {
+	InstanceID string     `json:"instance_id"`
+	RemoteJID  *types.JID `json:"remote_jid"`
+	MessageID  string     `json:"message_id"`
+}
+
+type DownloadMediaResponse struct {
+	Data     []byte `json:"data"`
+	Mimetype string `json:"mimetype"`
+	FileName string `json:"fileName,omitempty"`
+}
+
+// DownloadMedia fetches and decrypts the media of a recent or stored message.
+// Expired media, which WhatsApp drops from its servers after a while, is
+// requested again from the sender and downloaded from the new path once it
+// answers, waiting up to MEDIA_RETRY_TIMEOUT.
+func (s *Whatsmiau) DownloadMedia(ctx context.Context, data *DownloadMediaRequest) (*DownloadMediaResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	stored, message, err := s.originalMessage(ctx, data.InstanceID, data.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	jid, lid := s.GetJidLid(ctx, data.InstanceID, data.RemoteJID.ToNonAD())
+	if stored.Chat != jid && stored.Chat != lid {
+		return nil, ErrMessageNotFound
+	}
+
+	if message == nil {
+		return nil, ErrMessageContentExpired
+	}
+
+	media, mimetype, fileName := downloadableMedia(message)
+	if media == nil {
+		return nil, ErrNoDownloadableMedia
+	}
+
+	content, err := s.downloadStored(ctx, data.InstanceID, client, stored, media)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadMediaResponse{
+		Data:     content,
+		Mimetype: mimetype,
+		FileName: fileName,
+	}, nil
+}
+
+// downloadStored downloads the media of a stored message, asking the sender to
+// re-upload it when expired
+func (s *Whatsmiau) downloadStored(ctx context.Context, id string, client *whatsmeow.Client, stored *models.StoredMessage, media whatsmeow.DownloadableMessage) ([]byte, error) {
+	content, err := client.Download(ctx, media)
+	if !isMediaExpired(err) {
+		return content, err
+	}
+
+	chat, _ := types.ParseJID(stored.Chat)
+	sender, _ := types.ParseJID(stored.Sender)
+	return s.downloadRetried(ctx, id, client, &types.MessageInfo{
+		MessageSource: types.MessageSource{
+			Chat:     chat,
+			Sender:   sender,
+			IsFromMe: stored.FromMe,
+			IsGroup:  chat.Server == types.GroupServer,
+		},
+		ID:        stored.ID,
+		Timestamp: stored.Timestamp,
+	}, media)
+}
+
+func downloadableMedia(message *waE2E.Message) (whatsmeow.DownloadableMessage, string, string) {
+	switch {
+	case message.GetImageMessage() != nil:
+		return message.GetImageMessage(), message.GetImageMessage().GetMimetype(), ""
+	case message.GetVideoMessage() != nil:
+		return message.GetVideoMessage(), message.GetVideoMessage().GetMimetype(), ""
+	case message.GetAudioMessage() != nil:
+		return message.GetAudioMessage(), message.GetAudioMessage().GetMimetype(), ""
+	case message.GetDocumentMessage() != nil:
+		doc := message.GetDocumentMessage()
+		return doc, doc.GetMimetype(), doc.GetFileName()
+	case message.GetStickerMessage() != nil:
+		return message.GetStickerMessage(), message.GetStickerMessage().GetMimetype(), ""
+	}
+
+	return nil, "", ""
+}
+
+func isMediaExpired(err error) bool {
+	return errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith403) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) ||
+		errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410)
+}
+
+// downloadRetried asks the sender to re-upload the media and downloads it from
+// the path sent back in the media retry notification
+func (s *Whatsmiau) downloadRetried(ctx context.Context, id string, client *whatsmeow.Client, info *types.MessageInfo, media whatsmeow.DownloadableMessage) ([]byte, error) {
+	key := id + "/" + info.ID
+	retries := make(chan *events.MediaRetry, 1)
+	s.mediaRetries.Store(key, retries)
+	defer s.mediaRetries.Delete(key)
+
+	if err := client.SendMediaRetryReceipt(ctx, info, media.GetMediaKey()); err != nil {
+		return nil, fmt.Errorf("failed to request media re-upload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, env.Env.MediaRetryTimeout)
+	defer cancel()
+
+	var retry *events.MediaRetry
+	select {
+	case retry = <-retries:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %w", ErrMediaExpired, ctx.Err())
+	}
+
+	notification, err := whatsmeow.DecryptMediaRetryNotification(retry, media.GetMediaKey())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMediaExpired, err)
+	}
+	if notification.GetResult() != waMmsRetry.MediaRetryNotification_SUCCESS {
+		return nil, fmt.Errorf("%w: retry result %s", ErrMediaExpired, notification.GetResult())
+	}
+
+	size := -1
+	if sized, ok := media.(interface{ GetFileLength() uint64 }); ok {
+		size = int(sized.GetFileLength())
+	}
+
+	content, err := client.DownloadMediaWithPath(ctx, notification.GetDirectPath(), media.GetFileEncSHA256(), media.GetFileSHA256(), media.GetMediaKey(), size, whatsmeow.GetMediaType(media), "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMediaExpired, err)
+	}
+
+	return content, nil
+}
+
+// handleMediaRetry hands the notification to the DownloadMedia call waiting for
+// it, retries nobody waits for anymore are dropped
+func (s *Whatsmiau) handleMediaRetry(id string, e *events.MediaRetry) {
+	retries, ok := s.mediaRetries.Load(id + "/" + e.MessageID)
+	if !ok {
+		zap.L().Debug("media retry without a waiting download", zap.String("id", id), zap.String("message", e.MessageID))
+		return
+	}
+
+	select {
+	case retries <- e:
+	default:
+	}
+}
@@ -0,0 +1,276 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("mimetype can't be sent as WhatsApp media")
+	ErrMediaTooLarge        = errors.New("media is bigger than MEDIA_FETCH_MAX_SIZE")
+)
+
+// MediaInput is the file to send, Data takes precedence over URL
+type MediaInput struct {
+	Data     []byte `json:"data"`
+	URL      string `json:"url"`
+	Mimetype string `json:"mimetype"` // detected from the content when empty
+	Caption  string `json:"caption"`  // not shown on audios
+	FileName string `json:"file_name"`
+}
+
+type SendMediaRequest struct {
+	InstanceID string        `json:"instance_id"`
+	RemoteJID  *types.JID    `json:"remote_jid"`
+	Media      MediaInput    `json:"media"`
+	Quote      *QuoteOptions `json:"quote"`
+}
+
+type SendMediaResponse struct {
+	ID          string              `json:"id"`
+	MediaType   whatsmeow.MediaType `json:"media_type"`
+	MessageType string              `json:"message_type"` // imageMessage, videoMessage, audioMessage or documentMessage
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// SendMedia sends an image, video, audio or document picked from the mimetype.
+// When file storage is configured a copy is archived under the instance, named
+// after the sent message id.
+func (s *Whatsmiau) SendMedia(ctx context.Context, data *SendMediaRequest) (*SendMediaResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	content, mimetype, mediaType, err := s.prepareMedia(ctx, data.InstanceID, &data.Media)
+	if err != nil {
+		return nil, err
+	}
+
+	contextInfo, err := s.buildContextInfo(ctx, client, data.InstanceID, *data.RemoteJID, &SendTextOptions{Quote: data.Quote})
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded, err := client.Upload(ctx, content, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	message := buildMediaMessage(mediaType, &uploaded, mimetype, &data.Media)
+	setMediaContextInfo(message, contextInfo)
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, message)
+	if err != nil {
+		return nil, err
+	}
+
+	s.archiveSentMedia(data.InstanceID, res.ID, mimetype, content)
+	return &SendMediaResponse{
+		ID:          res.ID,
+		MediaType:   mediaType,
+		MessageType: mediaMessageType(mediaType),
+		CreatedAt:   res.Timestamp,
+	}, nil
+}
+
+// prepareMedia reads the input and picks the media type from its mimetype,
+// detected from the content when empty
+func (s *Whatsmiau) prepareMedia(ctx context.Context, instanceID string, media *MediaInput) ([]byte, string, whatsmeow.MediaType, error) {
+	content, err := s.readMediaInput(ctx, instanceID, media)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	mimetype := media.Mimetype
+	if mimetype == "" {
+		if mimetype, err = extractMimetype(content, media.FileName); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	mediaType, err := mediaTypeByMimetype(mimetype)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return content, mimetype, mediaType, nil
+}
+
+func (s *Whatsmiau) readMediaInput(ctx context.Context, instanceID string, media *MediaInput) ([]byte, error) {
+	if len(media.Data) > 0 {
+		return media.Data, nil
+	}
+	if media.URL == "" {
+		return nil, fmt.Errorf("media requires data or url")
+	}
+
+	res, err := s.getCtx(ctx, instanceID, media.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("failed to download media: status %d", res.StatusCode)
+	}
+
+	if media.Mimetype == "" {
+		// servers often answer application/octet-stream, only trust specific types
+		if contentType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil && contentType != "application/octet-stream" {
+			media.Mimetype = contentType
+		}
+	}
+
+	return readMediaBody(res.Body)
+}
+
+// readMediaBody reads a fetched media up to MEDIA_FETCH_MAX_SIZE
+func readMediaBody(body io.Reader) ([]byte, error) {
+	maxSize := env.Env.MediaFetchMaxSize
+	if maxSize == 0 {
+		return io.ReadAll(body)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(body, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(content)) > maxSize {
+		return nil, ErrMediaTooLarge
+	}
+
+	return content, nil
+}
+
+// mediaMessageType names the message field carrying the media
+func mediaMessageType(mediaType whatsmeow.MediaType) string {
+	switch mediaType {
+	case whatsmeow.MediaImage:
+		return "imageMessage"
+	case whatsmeow.MediaVideo:
+		return "videoMessage"
+	case whatsmeow.MediaAudio:
+		return "audioMessage"
+	default:
+		return "documentMessage"
+	}
+}
+
+func mediaTypeByMimetype(mimetype string) (whatsmeow.MediaType, error) {
+	base, _, err := mime.ParseMediaType(mimetype)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mimetype)
+	}
+
+	switch {
+	case strings.HasPrefix(base, "image/"):
+		return whatsmeow.MediaImage, nil
+	case strings.HasPrefix(base, "video/"):
+		return whatsmeow.MediaVideo, nil
+	case strings.HasPrefix(base, "audio/"):
+		return whatsmeow.MediaAudio, nil
+	case strings.HasPrefix(base, "application/"), strings.HasPrefix(base, "text/"):
+		return whatsmeow.MediaDocument, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mimetype)
+}
+
+func buildMediaMessage(mediaType whatsmeow.MediaType, uploaded *whatsmeow.UploadResponse, mimetype string, media *MediaInput) *waE2E.Message {
+	switch mediaType {
+	case whatsmeow.MediaImage:
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			URL:           proto.String(uploaded.URL),
+			Mimetype:      proto.String(mimetype),
+			Caption:       proto.String(media.Caption),
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			DirectPath:    proto.String(uploaded.DirectPath),
+		}}
+	case whatsmeow.MediaVideo:
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			URL:           proto.String(uploaded.URL),
+			Mimetype:      proto.String(mimetype),
+			Caption:       proto.String(media.Caption),
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			DirectPath:    proto.String(uploaded.DirectPath),
+		}}
+	case whatsmeow.MediaAudio:
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(uploaded.URL),
+			Mimetype:      proto.String(mimetype),
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			DirectPath:    proto.String(uploaded.DirectPath),
+		}}
+	}
+
+	return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+		URL:           proto.String(uploaded.URL),
+		Mimetype:      proto.String(mimetype),
+		Caption:       proto.String(media.Caption),
+		FileName:      proto.String(media.FileName),
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+		MediaKey:      uploaded.MediaKey,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		DirectPath:    proto.String(uploaded.DirectPath),
+	}}
+}
+
+func setMediaContextInfo(message *waE2E.Message, info *waE2E.ContextInfo) {
+	switch {
+	case message.ImageMessage != nil:
+		message.ImageMessage.ContextInfo = info
+	case message.VideoMessage != nil:
+		message.VideoMessage.ContextInfo = info
+	case message.AudioMessage != nil:
+		message.AudioMessage.ContextInfo = info
+	case message.DocumentMessage != nil:
+		message.DocumentMessage.ContextInfo = info
+	case message.StickerMessage != nil:
+		message.StickerMessage.ContextInfo = info
+	}
+}
+
+// archiveSentMedia runs in background, a failed archive doesn't fail the send
+func (s *Whatsmiau) archiveSentMedia(instanceID string, messageID types.MessageID, mimetype string, content []byte) {
+	if s.fileStorage == nil {
+		return
+	}
+
+	key := mediaKeyNamed(instanceID, time.Now(), messageID)
+	if exts, _ := mime.ExtensionsByType(mimetype); len(exts) > 0 {
+		key += exts[0]
+	}
+
+	go func() {
+		ctx, c := context.WithTimeout(context.Background(), time.Minute)
+		defer c()
+
+		if _, _, err := s.fileStorage.Upload(ctx, key, mimetype, bytes.NewReader(content)); err != nil {
+			zap.L().Error("failed to archive sent media", zap.String("instance", instanceID), zap.String("message", messageID), zap.Error(err))
+		}
+	}()
+}
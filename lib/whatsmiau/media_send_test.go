@@ -0,0 +1,43 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+)
+
+func TestMediaMessageType(t *testing.T) {
+	tests := map[whatsmeow.MediaType]string{
+		whatsmeow.MediaImage:    "imageMessage",
+		whatsmeow.MediaVideo:    "videoMessage",
+		whatsmeow.MediaAudio:    "audioMessage",
+		whatsmeow.MediaDocument: "documentMessage",
+	}
+
+	for mediaType, want := range tests {
+		if got := mediaMessageType(mediaType); got != want {
+			t.Errorf("mediaMessageType(%q) = %q, want %q", mediaType, got, want)
+		}
+	}
+}
+
+func TestReadMediaBody(t *testing.T) {
+	previous := env.Env.MediaFetchMaxSize
+	t.Cleanup(func() { env.Env.MediaFetchMaxSize = previous })
+
+	env.Env.MediaFetchMaxSize = 4
+	if content, err := readMediaBody(bytes.NewReader([]byte("1234"))); err != nil || string(content) != "1234" {
+		t.Errorf("at the limit: got %q, %v", content, err)
+	}
+	if _, err := readMediaBody(bytes.NewReader([]byte("12345"))); !errors.Is(err, ErrMediaTooLarge) {
+		t.Errorf("past the limit: got %v, want ErrMediaTooLarge", err)
+	}
+
+	env.Env.MediaFetchMaxSize = 0
+	if content, err := readMediaBody(bytes.NewReader([]byte("12345"))); err != nil || len(content) != 5 {
+		t.Errorf("unbounded: got %q, %v", content, err)
+	}
+}
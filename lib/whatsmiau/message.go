@@ -0,0 +1,71 @@
+package whatsmiau
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type KeepMessageRequest struct {
+	InstanceID  string     `json:"instance_id"`
+	RemoteJID   *types.JID `json:"remote_jid"`
+	MessageID   string     `json:"message_id"`
+	FromMe      bool       `json:"from_me"`
+	Participant *types.JID `json:"participant"` // sender of the message, required on groups when not from me
+	Keep        bool       `json:"keep"`
+}
+
+type KeepMessageResponse struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeepMessage keeps (or un-keeps) a single message in a chat with disappearing messages enabled
+func (s *Whatsmiau) KeepMessage(ctx context.Context, data *KeepMessageRequest) (*KeepMessageResponse, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if len(data.MessageID) <= 0 {
+		return nil, fmt.Errorf("invalid message_id")
+	}
+
+	if client.Store == nil || client.Store.ID == nil {
+		return nil, fmt.Errorf("device is not connected")
+	}
+
+	sender := *data.RemoteJID
+	if data.Participant != nil {
+		sender = *data.Participant
+	}
+	if data.FromMe {
+		sender = *client.Store.ID
+	}
+
+	keepType := waE2E.KeepType_KEEP_FOR_ALL
+	if !data.Keep {
+		keepType = waE2E.KeepType_UNDO_KEEP_FOR_ALL
+	}
+
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, &waE2E.Message{
+		KeepInChatMessage: &waE2E.KeepInChatMessage{
+			Key:         client.BuildMessageKey(*data.RemoteJID, sender, data.MessageID),
+			KeepType:    keepType.Enum(),
+			TimestampMS: proto.Int64(time.Now().UnixMilli()),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeepMessageResponse{
+		ID:        res.ID,
+		CreatedAt: res.Timestamp,
+	}, nil
+}
@@ -0,0 +1,228 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrMessageStoreDisabled = errors.New("message store is disabled, set STORE_MESSAGES")
+
+// storeMessage saves the message when STORE_MESSAGES is on and reports whether
+// it's new. Store failures are logged and count as new so events are never lost.
+func (s *Whatsmiau) storeMessage(instanceID string, info types.MessageInfo, message *waE2E.Message) bool {
+	if !env.Env.StoreMessages || message == nil {
+		return true
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*5)
+	defer c()
+
+	messageType, _, _ := s.parseWAMessage(message)
+	chatJid, _ := s.GetJidLid(ctx, instanceID, info.Chat)
+	senderJid, senderLid := s.GetJidLid(ctx, instanceID, info.Sender)
+	stored := &models.StoredMessage{
+		InstanceID: instanceID,
+		ID:         info.ID,
+		Chat:       info.Chat.ToNonAD().String(),
+		ChatJID:    chatJid,
+		Sender:     info.Sender.ToNonAD().String(),
+		SenderJID:  senderJid,
+		SenderLID:  senderLid,
+		FromMe:     info.IsFromMe,
+		Timestamp:  info.Timestamp,
+		Type:       messageType,
+		Text:       extractText(message),
+	}
+	stored.Media = storedMediaOf(message)
+	if poll := pollCreation(message); poll != nil {
+		stored.Poll = &models.StoredPoll{Name: poll.GetName(), Options: pollOptionNames(poll)}
+	}
+
+	if maxSize := env.Env.MessageStoreMaxSize; maxSize > 0 {
+		if data, err := json.Marshal(stored); err != nil || len(data) > maxSize {
+			stored.Media, stored.Poll, stored.Truncated = nil, nil, true
+		}
+	}
+
+	created, err := s.messages.Save(ctx, stored)
+	if err != nil {
+		zap.L().Error("failed to store message", zap.String("instance", instanceID), zap.String("message", info.ID), zap.Error(err))
+		return true
+	}
+
+	return created
+}
+
+// inChat reports whether a sent or received message belongs to chat, given by
+// phone number or lid
+func (s *Whatsmiau) inChat(ctx context.Context, id string, message *models.StoredMessage, chat types.JID) bool {
+	jid, lid := s.GetJidLid(ctx, id, chat.ToNonAD())
+	return message.Chat == jid || message.Chat == lid
+}
+
+// storeMediaURL attaches the storage url of an inbound media to its stored
+// message, so the chat history can link it
+func (s *Whatsmiau) storeMediaURL(ctx context.Context, instanceID, messageID, url string) {
+	if !env.Env.StoreMessages {
+		return
+	}
+
+	if err := s.messages.SetMediaURL(ctx, instanceID, messageID, url); err != nil && !errors.Is(err, messages.ErrorNotFound) {
+		zap.L().Warn("failed to store media url", zap.String("instance", instanceID), zap.String("message", messageID), zap.Error(err))
+	}
+}
+
+// GetMessages pages through the stored messages of a chat newest first, pass
+// the id of the last message of a page as before to get the next one. Only
+// messages seen while STORE_MESSAGES is on are there, media keys are left out.
+func (s *Whatsmiau) GetMessages(ctx context.Context, id string, chat types.JID, limit int, before string) ([]models.StoredMessage, error) {
+	if !env.Env.StoreMessages {
+		return nil, ErrMessageStoreDisabled
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	chatJid, _ := s.GetJidLid(ctx, id, chat)
+	result, err := s.messages.ListChat(ctx, id, chatJid, limit, before)
+	if err != nil {
+		if errors.Is(err, messages.ErrorNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	for i := range result {
+		result[i].Media = nil
+	}
+
+	return result, nil
+}
+
+func extractText(m *waE2E.Message) string {
+	switch {
+	case m.GetConversation() != "":
+		return m.GetConversation()
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetText()
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetCaption()
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetCaption()
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetCaption()
+	}
+
+	return ""
+}
+
+// storedMediaOf describes the media of a message so it can be downloaded after
+// the message left the recent message cache
+func storedMediaOf(m *waE2E.Message) *models.MediaDescriptor {
+	var kind string
+	switch {
+	case m.GetImageMessage() != nil:
+		kind = "image"
+	case m.GetVideoMessage() != nil:
+		kind = "video"
+	case m.GetAudioMessage() != nil:
+		kind = "audio"
+	case m.GetDocumentMessage() != nil:
+		kind = "document"
+	case m.GetStickerMessage() != nil:
+		kind = "sticker"
+	default:
+		return nil
+	}
+
+	media, mimetype, fileName := downloadableMedia(m)
+	stored := &models.MediaDescriptor{
+		Kind:          kind,
+		DirectPath:    media.GetDirectPath(),
+		MediaKey:      media.GetMediaKey(),
+		FileEncSHA256: media.GetFileEncSHA256(),
+		FileSHA256:    media.GetFileSHA256(),
+		Mimetype:      mimetype,
+		FileName:      fileName,
+	}
+	if sized, ok := media.(interface{ GetFileLength() uint64 }); ok {
+		stored.FileLength = sized.GetFileLength()
+	}
+
+	return stored
+}
+
+// storedMediaMessage rebuilds a downloadable media message from the stored
+// descriptor, with the stored text as caption. Thumbnails and context are lost.
+func storedMediaMessage(stored *models.StoredMessage) *waE2E.Message {
+	media := stored.Media
+	var caption *string
+	if len(stored.Text) > 0 {
+		caption = proto.String(stored.Text)
+	}
+
+	switch media.Kind {
+	case "image":
+		return &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Mimetype:      proto.String(media.Mimetype),
+			Caption:       caption,
+		}}
+	case "video":
+		return &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Mimetype:      proto.String(media.Mimetype),
+			Caption:       caption,
+		}}
+	case "audio":
+		return &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Mimetype:      proto.String(media.Mimetype),
+		}}
+	case "document":
+		return &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Mimetype:      proto.String(media.Mimetype),
+			FileName:      proto.String(media.FileName),
+			Caption:       caption,
+		}}
+	case "sticker":
+		return &waE2E.Message{StickerMessage: &waE2E.StickerMessage{
+			DirectPath:    proto.String(media.DirectPath),
+			MediaKey:      media.MediaKey,
+			FileEncSHA256: media.FileEncSHA256,
+			FileSHA256:    media.FileSHA256,
+			FileLength:    proto.Uint64(media.FileLength),
+			Mimetype:      proto.String(media.Mimetype),
+		}}
+	}
+
+	return nil
+}
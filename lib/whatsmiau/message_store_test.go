@@ -0,0 +1,150 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type memoryMessages map[string]models.StoredMessage
+
+func (m memoryMessages) Save(_ context.Context, message *models.StoredMessage) (bool, error) {
+	key := message.InstanceID + ":" + message.ID
+	if _, ok := m[key]; ok {
+		return false, nil
+	}
+	m[key] = *message
+	return true, nil
+}
+
+func (m memoryMessages) Get(_ context.Context, instanceID, messageID string) (*models.StoredMessage, error) {
+	message, ok := m[instanceID+":"+messageID]
+	if !ok {
+		return nil, messages.ErrorNotFound
+	}
+	return &message, nil
+}
+
+func (m memoryMessages) ListChat(context.Context, string, string, int, string) ([]models.StoredMessage, error) {
+	return nil, nil
+}
+
+func (m memoryMessages) SetMediaURL(context.Context, string, string, string) error {
+	return nil
+}
+
+func (m memoryMessages) SaveVote(context.Context, string, string, string, [][]byte) error {
+	return nil
+}
+
+func (m memoryMessages) GetVotes(context.Context, string, string) (map[string][][]byte, error) {
+	return nil, nil
+}
+
+func TestStoreMessageKeepsNormalizedFields(t *testing.T) {
+	previous := env.Env
+	env.Env.StoreMessages = true
+	env.Env.RecentMessageCacheTTL = 0
+	t.Cleanup(func() { env.Env = previous })
+
+	stored := memoryMessages{}
+	s := newTestMiau()
+	s.messages = stored
+
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+	info := func(id string) types.MessageInfo {
+		return types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+			ID:            id,
+			Timestamp:     time.Unix(1700000000, 0),
+		}
+	}
+
+	image := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:       proto.String("photo"),
+		DirectPath:    proto.String("/v/t62/image"),
+		MediaKey:      []byte("key"),
+		FileEncSHA256: []byte("enc"),
+		FileSHA256:    []byte("sha"),
+		FileLength:    proto.Uint64(1234),
+		Mimetype:      proto.String("image/jpeg"),
+		JPEGThumbnail: []byte("thumbnail"),
+	}}
+	poll := &waE2E.Message{PollCreationMessage: &waE2E.PollCreationMessage{
+		Name:    proto.String("lunch?"),
+		Options: []*waE2E.PollCreationMessage_Option{{OptionName: proto.String("yes")}, {OptionName: proto.String("no")}},
+	}}
+	s.storeMessage("instance", info("TEXT"), &waE2E.Message{Conversation: proto.String("hello")})
+	s.storeMessage("instance", info("IMAGE"), image)
+	s.storeMessage("instance", info("POLL"), poll)
+
+	if got := stored["instance:IMAGE"]; got.Text != "photo" || got.Type != "imageMessage" || got.Chat != chat.String() || got.SenderJID != chat.String() {
+		t.Errorf("stored image = %+v, want its normalized fields", got)
+	}
+	if got := stored["instance:POLL"].Poll; got == nil || got.Name != "lunch?" || !slices.Equal(got.Options, []string{"yes", "no"}) {
+		t.Errorf("stored poll = %+v, want its name and options", got)
+	}
+
+	_, message, err := s.originalMessage(context.Background(), "instance", "TEXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.GetConversation() != "hello" {
+		t.Errorf("text content = %v, want it rebuilt from the stored text", message)
+	}
+
+	// the raw message isn't stored, the media is rebuilt from its descriptor
+	original, message, err := s.originalMessage(context.Background(), "instance", "IMAGE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebuilt := message.GetImageMessage()
+	if original.Text != "photo" || rebuilt.GetDirectPath() != "/v/t62/image" || string(rebuilt.GetMediaKey()) != "key" ||
+		string(rebuilt.GetFileEncSHA256()) != "enc" || string(rebuilt.GetFileSHA256()) != "sha" || rebuilt.GetFileLength() != 1234 ||
+		rebuilt.GetMimetype() != "image/jpeg" || rebuilt.GetCaption() != "photo" || len(rebuilt.GetJPEGThumbnail()) > 0 {
+		t.Errorf("image = %+v %v, want it rebuilt without the thumbnail", original, message)
+	}
+
+	s.clients.Store("instance", newTestClient(t))
+	if _, err := s.GetMessageThumbnail(context.Background(), "instance", "IMAGE"); !errors.Is(err, ErrThumbnailUnavailable) {
+		t.Errorf("thumbnail err = %v, want ErrThumbnailUnavailable", err)
+	}
+}
+
+func TestStoreMessageMaxSize(t *testing.T) {
+	previous := env.Env
+	env.Env.StoreMessages = true
+	env.Env.MessageStoreMaxSize = 300
+	t.Cleanup(func() { env.Env = previous })
+
+	stored := memoryMessages{}
+	s := newTestMiau()
+	s.messages = stored
+
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+	info := types.MessageInfo{MessageSource: types.MessageSource{Chat: chat, Sender: chat}, ID: "IMAGE"}
+	s.storeMessage("instance", info, &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:    proto.String("photo"),
+		DirectPath: proto.String("/v/t62/" + strings.Repeat("x", 300)),
+		MediaKey:   []byte("key"),
+	}})
+
+	got := stored["instance:IMAGE"]
+	if !got.Truncated || got.Media != nil || got.Text != "photo" || got.Type != "imageMessage" {
+		t.Errorf("stored = %+v, want the metadata and text without the media", got)
+	}
+
+	if _, message, err := s.originalMessage(context.Background(), "instance", "IMAGE"); err != nil || message != nil {
+		t.Errorf("original = %v, %v, want no content", message, err)
+	}
+}
@@ -0,0 +1,108 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/templates"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrTemplateNotFound    = errors.New("template not found")
+	ErrMissingTemplateVars = errors.New("missing template variables")
+)
+
+var templateVarRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// templateVars lists the placeholders of a template text, in order and unique
+func templateVars(text string) []string {
+	vars := []string{}
+	for _, match := range templateVarRegex.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(vars, match[1]) {
+			vars = append(vars, match[1])
+		}
+	}
+	return vars
+}
+
+func (s *Whatsmiau) SaveTemplate(ctx context.Context, instanceID, name, text string) (*models.MessageTemplate, error) {
+	template := &models.MessageTemplate{
+		InstanceID: instanceID,
+		Name:       name,
+		Text:       text,
+		Variables:  templateVars(text),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.templates.Save(ctx, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *Whatsmiau) ListTemplates(ctx context.Context, instanceID string) ([]models.MessageTemplate, error) {
+	return s.templates.List(ctx, instanceID)
+}
+
+func (s *Whatsmiau) DeleteTemplate(ctx context.Context, instanceID, name string) error {
+	if err := s.templates.Delete(ctx, instanceID, name); err != nil {
+		if errors.Is(err, templates.ErrorNotFound) {
+			return ErrTemplateNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+type SendTemplateRequest struct {
+	InstanceID   string            `json:"instance_id"`
+	RemoteJID    *types.JID        `json:"remote_jid"`
+	TemplateName string            `json:"template_name"`
+	Vars         map[string]string `json:"vars"`
+}
+
+// SendTemplate renders a stored template and sends it as text, every
+// placeholder must have a value
+func (s *Whatsmiau) SendTemplate(ctx context.Context, data *SendTemplateRequest) (*SendTextResponse, error) {
+	if _, ok := s.clients.Load(data.InstanceID); !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	template, err := s.templates.Get(ctx, data.InstanceID, data.TemplateName)
+	if err != nil {
+		if errors.Is(err, templates.ErrorNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range templateVars(template.Text) {
+		if _, ok := data.Vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingTemplateVars, strings.Join(missing, ", "))
+	}
+
+	text := templateVarRegex.ReplaceAllStringFunc(template.Text, func(placeholder string) string {
+		return data.Vars[templateVarRegex.FindStringSubmatch(placeholder)[1]]
+	})
+
+	return s.SendText(ctx, &SendText{
+		Text:       text,
+		InstanceID: data.InstanceID,
+		RemoteJID:  data.RemoteJID,
+	})
+}
@@ -0,0 +1,75 @@
+package whatsmiau
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/templates"
+)
+
+// memoryTemplates keeps templates by instance and name
+type memoryTemplates map[string]map[string]models.MessageTemplate
+
+func (m memoryTemplates) Save(_ context.Context, template *models.MessageTemplate) error {
+	if m[template.InstanceID] == nil {
+		m[template.InstanceID] = map[string]models.MessageTemplate{}
+	}
+	m[template.InstanceID][template.Name] = *template
+	return nil
+}
+
+func (m memoryTemplates) Get(_ context.Context, instanceID, name string) (*models.MessageTemplate, error) {
+	template, ok := m[instanceID][name]
+	if !ok {
+		return nil, templates.ErrorNotFound
+	}
+	return &template, nil
+}
+
+func (m memoryTemplates) List(_ context.Context, instanceID string) ([]models.MessageTemplate, error) {
+	result := []models.MessageTemplate{}
+	for _, template := range m[instanceID] {
+		result = append(result, template)
+	}
+	return result, nil
+}
+
+func (m memoryTemplates) Delete(_ context.Context, instanceID, name string) error {
+	if _, ok := m[instanceID][name]; !ok {
+		return templates.ErrorNotFound
+	}
+	delete(m[instanceID], name)
+	return nil
+}
+
+func (m memoryTemplates) DeleteAll(_ context.Context, instanceID string) error {
+	delete(m, instanceID)
+	return nil
+}
+
+func TestForgetInstanceDeletesTemplates(t *testing.T) {
+	ctx := context.Background()
+	s := &Whatsmiau{
+		templates:        memoryTemplates{},
+		payloadTemplates: xsync.NewMap[string, *payloadTemplate](),
+	}
+
+	for _, instance := range []string{"deleted", "kept"} {
+		if _, err := s.SaveTemplate(ctx, instance, "welcome", "Hi {{name}}"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.ForgetInstance(ctx, "deleted")
+
+	if deleted, _ := s.ListTemplates(ctx, "deleted"); len(deleted) != 0 {
+		t.Errorf("deleted instance templates = %v, want none", deleted)
+	}
+	kept, _ := s.ListTemplates(ctx, "kept")
+	if len(kept) != 1 || !slices.Equal(kept[0].Variables, []string{"name"}) {
+		t.Errorf("kept instance templates = %v, want the welcome template", kept)
+	}
+}
@@ -1,17 +1,37 @@
 package whatsmiau
 
 import (
+	"strings"
 	"time"
 
 	"github.com/emersion/go-vcard"
+	"go.mau.fi/whatsmeow/types"
 )
 
 type Wook string
 
 const (
-	WookMessagesUpsert Wook = "messages.upsert"
-	WookMessagesUpdate Wook = "messages.update"
-	WookContactsUpsert Wook = "contacts.upsert"
+	WookMessagesUpsert   Wook = "messages.upsert"
+	WookMessagesUpdate   Wook = "messages.update"
+	WookContactsUpsert   Wook = "contacts.upsert"
+	WookContactsUpdate   Wook = "contacts.update"
+	WookPresenceUpdate   Wook = "presence.update"
+	WookMessagesKeep     Wook = "messages.keep"
+	WookMessagesDelete   Wook = "messages.delete"
+	WookGroupsAutoJoined Wook = "groups.auto-joined"
+	WookGroupsLeft       Wook = "groups.left"
+	WookLogoutAllDevices Wook = "logout.all-devices"
+	WookConnectionUpdate Wook = "connection.update"
+	WookQrCodeScanned    Wook = "qrcode.scanned"
+	WookQrCodeError      Wook = "qrcode.error"
+	WookMessagesEdited   Wook = "messages.edited"
+	WookPrivacyUpdate    Wook = "privacy.update"
+	WookBlocklistUpdate  Wook = "blocklist.update"
+
+	WookMessagesUndecryptable Wook = "messages.undecryptable"
+	WookAccountBlocked        Wook = "account.blocked"
+	WookMessagesReaction      Wook = "messages.reaction"
+	WookMessagesPollVote      Wook = "messages.poll-vote"
 )
 
 type WookEvent[data any] struct {
@@ -25,9 +45,20 @@ type WookEvent[data any] struct {
 	Event       Wook      `json:"event,omitempty"`
 }
 
+func (e *WookEvent[data]) wook() Wook {
+	return e.Event
+}
+
+// eventKey is the name used in webhook.events and webhook.routes, ex:
+// messages.upsert is MESSAGES_UPSERT
+func (w Wook) eventKey() string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(string(w)))
+}
+
 type WookMessageData struct {
 	Key              *WookKey                `json:"key,omitempty"`
 	PushName         string                  `json:"pushName,omitempty"`
+	VerifiedBizName  string                  `json:"verifiedBizName,omitempty"` // set when the sender is a verified business
 	Status           string                  `json:"status,omitempty"`
 	Message          *WookMessageRaw         `json:"message,omitempty"`
 	ContextInfo      *WookMessageContextInfo `json:"contextInfo,omitempty"`
@@ -35,6 +66,9 @@ type WookMessageData struct {
 	MessageTimestamp int                     `json:"messageTimestamp,omitempty"`
 	InstanceId       string                  `json:"instanceId,omitempty"`
 	Source           string                  `json:"source,omitempty"`
+	Origin           Origin                  `json:"origin,omitempty"`
+	History          bool                    `json:"history,omitempty"` // replayed from an on-demand history sync
+	Raw              string                  `json:"raw,omitempty"`     // base64 waE2E.Message, only with webhook.raw
 }
 
 type WookMessageContextInfo struct {
@@ -98,10 +132,29 @@ type WookMessageRaw struct {
 	ReactionMessage      *ReactionMessageRaw      `json:"reactionMessage,omitempty"`
 	ContactMessage       *ContactMessageRaw       `json:"contactMessage,omitempty"`
 	ContactsArrayMessage *ContactsArrayMessageRaw `json:"contactsArrayMessage,omitempty"`
+	LocationMessage      *WookLocationMessageRaw  `json:"locationMessage,omitempty"`
 	//MessageContextInfo  WookMessageContextInfo `json:"messageContextInfo,omitempty"`
 
-	ListResponseMessage *WookListMessageRaw `json:"listResponseMessage,omitempty"`
-	MediaURL            string              `json:"mediaUrl,omitempty"` // Sent when connect with some storage
+	ListResponseMessage    *WookListMessageRaw            `json:"listResponseMessage,omitempty"`
+	ButtonsResponseMessage *WookButtonsResponseMessageRaw `json:"buttonsResponseMessage,omitempty"`
+	MediaURL               string                         `json:"mediaUrl,omitempty"` // Sent when connect with some storage
+}
+
+type WookButtonsResponseMessageRaw struct {
+	SelectedButtonId    string `json:"selectedButtonId"`
+	SelectedDisplayText string `json:"selectedDisplayText,omitempty"`
+}
+
+type WookLocationMessageRaw struct {
+	DegreesLatitude  float64 `json:"degreesLatitude"`
+	DegreesLongitude float64 `json:"degreesLongitude"`
+	Name             string  `json:"name,omitempty"`
+	Address          string  `json:"address,omitempty"`
+	Url              string  `json:"url,omitempty"`
+	IsLive           bool    `json:"isLive,omitempty"`
+	AccuracyInMeters int     `json:"accuracyInMeters,omitempty"`
+	Comment          string  `json:"comment,omitempty"`
+	JpegThumbnail    string  `json:"jpegThumbnail,omitempty"`
 }
 
 type ContactsArrayMessageRaw struct {
@@ -255,6 +308,7 @@ type WookMessageUpdateData struct {
 	ParticipantLid string                  `json:"participantLid,omitempty"`
 	Status         WookMessageUpdateStatus `json:"status,omitempty"`
 	InstanceId     string                  `json:"instanceId,omitempty"`
+	Origin         Origin                  `json:"origin,omitempty"`
 }
 
 type WookContact struct {
@@ -267,3 +321,134 @@ type WookContact struct {
 }
 
 type WookContactUpsertData []WookContact
+
+type WookContactUpdate struct {
+	WookContact
+	OldPushName string `json:"oldPushName,omitempty"`
+}
+
+type WookContactUpdateData []WookContactUpdate
+
+type WookPresenceUpdateData struct {
+	Id        string                  `json:"id,omitempty"`
+	Presences map[string]WookPresence `json:"presences,omitempty"`
+}
+
+type WookPresence struct {
+	LastKnownPresence string `json:"lastKnownPresence,omitempty"` // available, unavailable, composing, recording or paused
+	Media             string `json:"media,omitempty"`             // text or audio, only when composing
+	LastSeen          int64  `json:"lastSeen,omitempty"`          // unix seconds, only when unavailable and shared by the contact
+}
+
+type WookMessageKeepData struct {
+	Key        *WookKey `json:"key,omitempty"` // the kept message
+	Keep       bool     `json:"keep"`
+	KeptBy     string   `json:"keptBy,omitempty"`
+	InstanceId string   `json:"instanceId,omitempty"`
+}
+
+type WookMessageDeleteData struct {
+	Key        *WookKey `json:"key,omitempty"` // the revoked message
+	RevokedBy  string   `json:"revokedBy,omitempty"`
+	ForMe      bool     `json:"forMe,omitempty"` // deleted only on our devices
+	InstanceId string   `json:"instanceId,omitempty"`
+	Origin     Origin   `json:"origin,omitempty"`
+}
+
+type WookMessageUndecryptableData struct {
+	Key             *WookKey `json:"key,omitempty"`
+	Sender          string   `json:"sender,omitempty"`
+	IsUnavailable   bool     `json:"isUnavailable"`             // the sender device sent no ciphertext to us at all
+	UnavailableType string   `json:"unavailableType,omitempty"` // set for message types that are unavailable on purpose, ex: view_once
+	DecryptFailMode string   `json:"decryptFailMode,omitempty"` // "hide" when the sender asked to hide the placeholder
+	InstanceId      string   `json:"instanceId,omitempty"`
+}
+
+type WookAccountBlockedData struct {
+	Instance string     `json:"instance,omitempty"`
+	State    Status     `json:"state,omitempty"` // banned or temporary_block
+	Code     int        `json:"code,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+type WookGroupAutoJoinedData struct {
+	GroupJid   string `json:"groupJid,omitempty"`
+	GroupName  string `json:"groupName,omitempty"`
+	InvitedBy  string `json:"invitedBy,omitempty"`
+	InstanceId string `json:"instanceId,omitempty"`
+}
+
+type WookGroupLeftData struct {
+	GroupJid   string `json:"groupJid,omitempty"`
+	InstanceId string `json:"instanceId,omitempty"`
+}
+
+type WookLogoutAllDevicesData struct {
+	Account          string   `json:"account,omitempty"`
+	LoggedOut        []string `json:"loggedOut"`
+	RemainingDevices []string `json:"remainingDevices"`
+	InstanceId       string   `json:"instanceId,omitempty"`
+}
+
+type WookConnectionUpdateData struct {
+	Instance string `json:"instance,omitempty"`
+	State    Status `json:"state,omitempty"`
+	Reason   string `json:"reason,omitempty"`  // why the session closed, ex: stream_replaced or the logout reason
+	Attempt  int    `json:"attempt,omitempty"` // reconnect attempt, only with state connecting
+}
+
+type WookQrCodeScannedData struct {
+	Instance  string `json:"instance,omitempty"`
+	RemoteJid string `json:"remoteJid,omitempty"`
+	State     Status `json:"state,omitempty"`
+}
+
+type WookQrCodeErrorData struct {
+	Instance string `json:"instance,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type WookMessageEditedData struct {
+	Key        *WookKey `json:"key,omitempty"`
+	Text       string   `json:"text,omitempty"` // new text or caption
+	EditId     string   `json:"editId,omitempty"`
+	InstanceId string   `json:"instanceId,omitempty"`
+	Origin     Origin   `json:"origin,omitempty"`
+}
+
+type WookMessageReactionData struct {
+	Key        *WookKey `json:"key,omitempty"` // the reacted message
+	Reaction   string   `json:"reaction,omitempty"`
+	Removed    bool     `json:"removed,omitempty"`
+	InstanceId string   `json:"instanceId,omitempty"`
+	Origin     Origin   `json:"origin,omitempty"`
+}
+
+type WookPollVoteData struct {
+	Key             *WookKey `json:"key,omitempty"` // the poll
+	Voter           string   `json:"voter,omitempty"`
+	VoterLid        string   `json:"voterLid,omitempty"`
+	SelectedOptions []string `json:"selectedOptions"`          // empty when the vote was removed
+	UnknownOptions  []string `json:"unknownOptions,omitempty"` // hex hashes of options of polls not in the cache or message store
+	VoteId          string   `json:"voteId,omitempty"`
+	InstanceId      string   `json:"instanceId,omitempty"`
+	Origin          Origin   `json:"origin,omitempty"`
+}
+
+type WookBlocklistUpdateData struct {
+	Changes    []WookBlocklistChange `json:"changes,omitempty"`
+	InstanceId string                `json:"instanceId,omitempty"`
+}
+
+type WookBlocklistChange struct {
+	Jid     string `json:"jid,omitempty"`
+	Lid     string `json:"lid,omitempty"`
+	Blocked bool   `json:"blocked"`
+}
+
+type WookPrivacyUpdateData struct {
+	Settings   *PrivacySettings           `json:"settings,omitempty"`
+	Changed    []types.PrivacySettingType `json:"changed,omitempty"`
+	InstanceId string                     `json:"instanceId,omitempty"`
+}
@@ -0,0 +1,134 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrInvalidNewsletter   = errors.New("jid is not a newsletter")
+	ErrNewsletterNotFound  = errors.New("newsletter not found")
+	ErrNewsletterForbidden = errors.New("account can't access newsletters")
+)
+
+// GetNewsletterInfo returns the channel metadata, cached for
+// NEWSLETTER_INFO_CACHE_TTL. Following, unfollowing or muting the channel
+// drops the cached entry.
+func (s *Whatsmiau) GetNewsletterInfo(ctx context.Context, id string, newsletter types.JID) (*types.NewsletterMetadata, error) {
+	client, err := s.newsletterClient(id, newsletter)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newsletterInfoKey(id, newsletter)
+	if info, ok := s.newsletterInfos.Load(key); ok {
+		return info, nil
+	}
+
+	info, err := client.GetNewsletterInfo(ctx, newsletter)
+	if err != nil {
+		return nil, newsletterError(err)
+	}
+	if info == nil {
+		return nil, ErrNewsletterNotFound
+	}
+
+	if ttl := env.Env.NewsletterInfoCacheTTL; ttl > 0 {
+		s.newsletterInfos.Store(key, info)
+		time.AfterFunc(ttl, func() {
+			// a newer entry may have been stored since, it has its own timer
+			s.newsletterInfos.Compute(key, func(cached *types.NewsletterMetadata, loaded bool) (*types.NewsletterMetadata, xsync.ComputeOp) {
+				if loaded && cached == info {
+					return nil, xsync.DeleteOp
+				}
+				return cached, xsync.CancelOp
+			})
+		})
+	}
+
+	return info, nil
+}
+
+// FollowNewsletter follows the channel, its posts then arrive as messages
+func (s *Whatsmiau) FollowNewsletter(ctx context.Context, id string, newsletter types.JID) error {
+	client, err := s.newsletterClient(id, newsletter)
+	if err != nil {
+		return err
+	}
+
+	if err := client.FollowNewsletter(ctx, newsletter); err != nil {
+		return newsletterError(err)
+	}
+
+	s.invalidateNewsletterInfo(id, newsletter)
+	return nil
+}
+
+func (s *Whatsmiau) UnfollowNewsletter(ctx context.Context, id string, newsletter types.JID) error {
+	client, err := s.newsletterClient(id, newsletter)
+	if err != nil {
+		return err
+	}
+
+	if err := client.UnfollowNewsletter(ctx, newsletter); err != nil {
+		return newsletterError(err)
+	}
+
+	s.invalidateNewsletterInfo(id, newsletter)
+	return nil
+}
+
+func (s *Whatsmiau) newsletterClient(id string, newsletter types.JID) (*whatsmeow.Client, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if newsletter.Server != types.NewsletterServer {
+		return nil, ErrInvalidNewsletter
+	}
+
+	return client, nil
+}
+
+func (s *Whatsmiau) invalidateNewsletterInfo(id string, newsletter types.JID) {
+	s.newsletterInfos.Delete(newsletterInfoKey(id, newsletter))
+}
+
+func newsletterInfoKey(id string, newsletter types.JID) string {
+	return id + ":" + newsletter.String()
+}
+
+// newsletterError maps the info query and GraphQL errors of the channel
+// queries, accounts without channels get 401/403
+func newsletterError(err error) error {
+	code := 0
+	var iqErr *whatsmeow.IQError
+	var gqlErr types.GraphQLError
+	switch {
+	case errors.As(err, &iqErr):
+		code = iqErr.Code
+	case errors.As(err, &gqlErr):
+		code = gqlErr.Extensions.ErrorCode
+	}
+
+	switch code {
+	case 401, 403:
+		return fmt.Errorf("%w: %w", ErrNewsletterForbidden, err)
+	case 404:
+		return fmt.Errorf("%w: %w", ErrNewsletterNotFound, err)
+	}
+
+	return err
+}
@@ -0,0 +1,225 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// emitNormalized sends the models.Event envelope of evt to instances with
+// webhook.format event, gated by the envelope type key (ex: MESSAGE) in
+// webhook.events. Those instances don't receive the Evolution API events.
+func (s *Whatsmiau) emitNormalized(id string, instance *models.Instance, evt any, eventMap map[string]bool) {
+	if instance.Webhook.Format != models.WebhookFormatEvent {
+		return
+	}
+
+	// checked before normalizing, group updates may fetch the group info
+	if eventType, ok := normalizedType(evt); !ok || !eventMap[eventType.Key()] {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*10)
+	defer c()
+
+	event := s.normalizeEvent(ctx, id, instance, evt)
+	if event == nil {
+		return
+	}
+
+	s.emit(event, instance)
+}
+
+// normalizedType is the type normalizeEvent gives to the event, false for the
+// events it doesn't map
+func normalizedType(evt any) (models.EventType, bool) {
+	switch evt.(type) {
+	case *events.Message:
+		return models.EventMessage, true
+	case *events.Receipt:
+		return models.EventReceipt, true
+	case *events.Presence, *events.ChatPresence:
+		return models.EventPresence, true
+	case *events.GroupInfo:
+		return models.EventGroupUpdate, true
+	case *events.Connected, *events.Disconnected, *events.StreamReplaced, *events.LoggedOut:
+		return models.EventConnection, true
+	}
+
+	return "", false
+}
+
+// normalizeEvent maps the whatsmeow events consumers care about into the
+// stable envelope, other events and filtered ones (groupsIgnore, emitFromMe,
+// status updates) return nil
+func (s *Whatsmiau) normalizeEvent(ctx context.Context, id string, instance *models.Instance, evt any) *models.Event {
+	event := &models.Event{
+		InstanceID: instance.ID,
+		Timestamp:  time.Now(),
+	}
+
+	switch e := evt.(type) {
+	case *events.Message:
+		if canIgnoreGroup(e, instance) || canIgnoreMessage(e) || canIgnoreFromMe(e, instance) {
+			return nil
+		}
+
+		messageType, _, contextInfo := s.parseWAMessage(e.Message)
+		chatJID, chatLID := s.GetJidLid(ctx, id, e.Info.Chat)
+		event.Type = models.EventMessage
+		event.Timestamp = e.Info.Timestamp
+		event.FromJID, event.FromLID = s.GetJidLid(ctx, id, e.Info.Sender.ToNonAD())
+		event.Payload = &models.MessagePayload{
+			ID:        e.Info.ID,
+			ChatJID:   chatJID,
+			ChatLID:   chatLID,
+			FromMe:    e.Info.IsFromMe,
+			IsGroup:   e.Info.IsGroup,
+			IsChannel: e.Info.Chat.Server == types.NewsletterServer,
+			ServerID:  int(e.Info.ServerID),
+			Type:      messageType,
+			Text:      extractText(e.Message),
+			PushName:  e.Info.PushName,
+			QuotedID:  contextInfo.GetStanzaID(),
+			Forwarded: contextInfo.GetIsForwarded(),
+			History:   isHistoryMessage(e),
+		}
+	case *events.Receipt:
+		status, ok := receiptStatus(e.Type)
+		if !ok {
+			return nil
+		}
+
+		chatJID, chatLID := s.GetJidLid(ctx, id, e.Chat)
+		event.Type = models.EventReceipt
+		event.Timestamp = e.Timestamp
+		event.FromJID, event.FromLID = s.GetJidLid(ctx, id, e.Sender.ToNonAD())
+		event.Payload = &models.ReceiptPayload{
+			MessageIDs: e.MessageIDs,
+			ChatJID:    chatJID,
+			ChatLID:    chatLID,
+			Status:     status,
+			FromMe:     e.IsFromMe,
+		}
+	case *events.Presence:
+		fromJID, fromLID := s.GetJidLid(ctx, id, e.From.ToNonAD())
+		if !s.presenceSubscribed(id, fromJID) {
+			return nil
+		}
+
+		payload := &models.PresencePayload{Presence: "available"}
+		if e.Unavailable {
+			payload.Presence = "unavailable"
+		}
+		if !e.LastSeen.IsZero() {
+			payload.LastSeen = &e.LastSeen
+		}
+
+		event.Type = models.EventPresence
+		event.FromJID, event.FromLID = fromJID, fromLID
+		event.Payload = payload
+	case *events.ChatPresence:
+		fromJID, fromLID := s.GetJidLid(ctx, id, e.Sender.ToNonAD())
+		if !s.presenceSubscribed(id, fromJID) {
+			return nil
+		}
+
+		presence := string(e.State)
+		if e.State == types.ChatPresenceComposing && e.Media == types.ChatPresenceMediaAudio {
+			presence = "recording"
+		}
+
+		chatJID, _ := s.GetJidLid(ctx, id, e.Chat)
+		event.Type = models.EventPresence
+		event.FromJID, event.FromLID = fromJID, fromLID
+		event.Payload = &models.PresencePayload{ChatJID: chatJID, Presence: presence}
+	case *events.GroupInfo:
+		if canIgnoreGroup(e, instance) {
+			return nil
+		}
+
+		event.Type = models.EventGroupUpdate
+		event.Timestamp = e.Timestamp
+		if e.Sender != nil {
+			event.FromJID, event.FromLID = s.GetJidLid(ctx, id, e.Sender.ToNonAD())
+		}
+		event.Payload = s.groupUpdatePayload(ctx, id, e)
+	case *events.Connected:
+		event.Type = models.EventConnection
+		event.Payload = &models.ConnectionPayload{State: Connected}
+	case *events.Disconnected:
+		event.Type = models.EventConnection
+		event.Payload = &models.ConnectionPayload{State: Closed}
+	case *events.StreamReplaced:
+		event.Type = models.EventConnection
+		event.Payload = &models.ConnectionPayload{State: Closed, Reason: "stream_replaced"}
+	case *events.LoggedOut:
+		event.Type = models.EventConnection
+		event.Payload = &models.ConnectionPayload{State: LoggedOut, Reason: e.Reason.String()}
+	default:
+		return nil
+	}
+
+	return event
+}
+
+func receiptStatus(receiptType types.ReceiptType) (models.ReceiptStatus, bool) {
+	switch receiptType {
+	case types.ReceiptTypeDelivered:
+		return models.ReceiptDelivered, true
+	case types.ReceiptTypeRead, types.ReceiptTypeReadSelf:
+		return models.ReceiptRead, true
+	case types.ReceiptTypePlayed, types.ReceiptTypePlayedSelf:
+		return models.ReceiptPlayed, true
+	}
+
+	return "", false
+}
+
+func (s *Whatsmiau) groupUpdatePayload(ctx context.Context, id string, e *events.GroupInfo) *models.GroupUpdatePayload {
+	participants := func(jids []types.JID) []string {
+		result := make([]string, 0, len(jids))
+		for _, jid := range jids {
+			pn, _ := s.GetJidLid(ctx, id, jid.ToNonAD())
+			result = append(result, pn)
+		}
+		return result
+	}
+
+	payload := &models.GroupUpdatePayload{
+		GroupJID: e.JID.String(),
+		Joined:   participants(e.Join),
+		Left:     participants(e.Leave),
+		Promoted: participants(e.Promote),
+		Demoted:  participants(e.Demote),
+	}
+
+	// the cached info doesn't know yet about a link or unlink of this event
+	switch {
+	case e.Link != nil && e.Link.Type == types.GroupLinkChangeTypeParent:
+		payload.Community = e.Link.Group.JID.String()
+	case e.Unlink != nil && e.Unlink.Type == types.GroupLinkChangeTypeParent:
+		// left the community, now standalone
+	default:
+		if community := s.communityOf(ctx, id, e.JID); !community.IsEmpty() {
+			payload.Community = community.String()
+		}
+	}
+	if e.Name != nil {
+		payload.Name = &e.Name.Name
+	}
+	if e.Topic != nil {
+		payload.Description = &e.Topic.Topic
+	}
+	if e.Announce != nil {
+		payload.Announce = &e.Announce.IsAnnounce
+	}
+	if e.Locked != nil {
+		payload.Locked = &e.Locked.IsLocked
+	}
+
+	return payload
+}
@@ -0,0 +1,259 @@
+package whatsmiau
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNormalizeEvent(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	contact := types.NewJID("5511911111111", types.DefaultUserServer)
+	device := types.NewADJID("5511911111111", 0, 3)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	channel := types.NewJID("120363111111111111", types.NewsletterServer)
+	name, locked := "Team", true
+
+	instance := &models.Instance{ID: "instance"}
+	ignoringGroups := &models.Instance{ID: "instance", GroupsIgnore: true}
+
+	tests := []struct {
+		name     string
+		instance *models.Instance
+		evt      any
+		want     *models.Event // nil when the event is dropped, zero timestamps aren't compared
+	}{
+		{
+			name: "text message",
+			evt: &events.Message{
+				Info: types.MessageInfo{
+					MessageSource: types.MessageSource{Chat: contact, Sender: device},
+					ID:            "A1",
+					PushName:      "Ana",
+					Timestamp:     at,
+				},
+				Message: &waE2E.Message{Conversation: proto.String("hello")},
+			},
+			want: &models.Event{
+				Type:      models.EventMessage,
+				Timestamp: at,
+				FromJID:   contact.String(),
+				Payload: &models.MessagePayload{
+					ID:       "A1",
+					ChatJID:  contact.String(),
+					Type:     "conversation",
+					Text:     "hello",
+					PushName: "Ana",
+				},
+			},
+		},
+		{
+			name: "quoted and forwarded group message",
+			evt: &events.Message{
+				Info: types.MessageInfo{
+					MessageSource: types.MessageSource{Chat: group, Sender: contact, IsGroup: true},
+					ID:            "A2",
+					Timestamp:     at,
+				},
+				Message: &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+					Text:        proto.String("reply"),
+					ContextInfo: &waE2E.ContextInfo{StanzaID: proto.String("A1"), IsForwarded: proto.Bool(true)},
+				}},
+			},
+			want: &models.Event{
+				Type:      models.EventMessage,
+				Timestamp: at,
+				FromJID:   contact.String(),
+				Payload: &models.MessagePayload{
+					ID:        "A2",
+					ChatJID:   group.String(),
+					IsGroup:   true,
+					Type:      "conversation", // extended texts are reported as conversation, like on messages.upsert
+					Text:      "reply",
+					QuotedID:  "A1",
+					Forwarded: true,
+				},
+			},
+		},
+		{
+			name: "channel post",
+			evt: &events.Message{
+				Info: types.MessageInfo{
+					MessageSource: types.MessageSource{Chat: channel, Sender: channel},
+					ID:            "A3",
+					ServerID:      42,
+					Timestamp:     at,
+				},
+				Message: &waE2E.Message{Conversation: proto.String("news")},
+			},
+			want: &models.Event{
+				Type:      models.EventMessage,
+				Timestamp: at,
+				FromJID:   channel.String(),
+				Payload: &models.MessagePayload{
+					ID:        "A3",
+					ChatJID:   channel.String(),
+					IsChannel: true,
+					ServerID:  42,
+					Type:      "conversation",
+					Text:      "news",
+				},
+			},
+		},
+		{
+			name:     "group message with groupsIgnore",
+			instance: ignoringGroups,
+			evt: &events.Message{
+				Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: group, Sender: contact, IsGroup: true}},
+				Message: &waE2E.Message{Conversation: proto.String("hello")},
+			},
+		},
+		{
+			name: "status update",
+			evt: &events.Message{
+				Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: types.StatusBroadcastJID, Sender: contact}},
+				Message: &waE2E.Message{Conversation: proto.String("story")},
+			},
+		},
+		{
+			name: "read receipt",
+			evt: &events.Receipt{
+				MessageSource: types.MessageSource{Chat: contact, Sender: device},
+				MessageIDs:    []types.MessageID{"A1", "A2"},
+				Timestamp:     at,
+				Type:          types.ReceiptTypeRead,
+			},
+			want: &models.Event{
+				Type:      models.EventReceipt,
+				Timestamp: at,
+				FromJID:   contact.String(),
+				Payload: &models.ReceiptPayload{
+					MessageIDs: []string{"A1", "A2"},
+					ChatJID:    contact.String(),
+					Status:     models.ReceiptRead,
+				},
+			},
+		},
+		{
+			name: "played receipt on another device",
+			evt: &events.Receipt{
+				MessageSource: types.MessageSource{Chat: contact, Sender: testOwnJID, IsFromMe: true},
+				MessageIDs:    []types.MessageID{"A1"},
+				Timestamp:     at,
+				Type:          types.ReceiptTypePlayedSelf,
+			},
+			want: &models.Event{
+				Type:      models.EventReceipt,
+				Timestamp: at,
+				FromJID:   testOwnJID.String(),
+				Payload: &models.ReceiptPayload{
+					MessageIDs: []string{"A1"},
+					ChatJID:    contact.String(),
+					Status:     models.ReceiptPlayed,
+					FromMe:     true,
+				},
+			},
+		},
+		{
+			name: "retry receipt",
+			evt:  &events.Receipt{MessageSource: types.MessageSource{Chat: contact, Sender: contact}, Type: types.ReceiptTypeRetry},
+		},
+		{
+			name: "group update",
+			evt: &events.GroupInfo{
+				JID:       group,
+				Sender:    &device,
+				Timestamp: at,
+				Name:      &types.GroupName{Name: name},
+				Locked:    &types.GroupLocked{IsLocked: locked},
+				Join:      []types.JID{contact},
+				Promote:   []types.JID{device},
+			},
+			want: &models.Event{
+				Type:      models.EventGroupUpdate,
+				Timestamp: at,
+				FromJID:   contact.String(),
+				Payload: &models.GroupUpdatePayload{
+					GroupJID: group.String(),
+					Joined:   []string{contact.String()},
+					Left:     []string{},
+					Promoted: []string{contact.String()},
+					Demoted:  []string{},
+					Name:     &name,
+					Locked:   &locked,
+				},
+			},
+		},
+		{
+			name:     "group update with groupsIgnore",
+			instance: ignoringGroups,
+			evt:      &events.GroupInfo{JID: group, Name: &types.GroupName{Name: name}},
+		},
+		{
+			name: "connected",
+			evt:  &events.Connected{},
+			want: &models.Event{Type: models.EventConnection, Payload: &models.ConnectionPayload{State: Connected}},
+		},
+		{
+			name: "disconnected",
+			evt:  &events.Disconnected{},
+			want: &models.Event{Type: models.EventConnection, Payload: &models.ConnectionPayload{State: Closed}},
+		},
+		{
+			name: "stream replaced",
+			evt:  &events.StreamReplaced{},
+			want: &models.Event{Type: models.EventConnection, Payload: &models.ConnectionPayload{State: Closed, Reason: "stream_replaced"}},
+		},
+		{
+			name: "logged out",
+			evt:  &events.LoggedOut{Reason: events.ConnectFailureLoggedOut},
+			want: &models.Event{
+				Type:    models.EventConnection,
+				Payload: &models.ConnectionPayload{State: LoggedOut, Reason: events.ConnectFailureLoggedOut.String()},
+			},
+		},
+		{
+			name: "unmapped event",
+			evt:  &events.PushNameSetting{},
+		},
+	}
+
+	s := newTestMiau()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.instance == nil {
+				tt.instance = instance
+			}
+
+			got := s.normalizeEvent(context.Background(), "instance", tt.instance, tt.evt)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("normalizeEvent() = %+v, want the event dropped", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("normalizeEvent() = nil")
+			}
+			if eventType, ok := normalizedType(tt.evt); !ok || eventType != got.Type {
+				t.Errorf("normalizedType() = %q, %v, want %q", eventType, ok, got.Type)
+			}
+
+			tt.want.InstanceID = tt.instance.ID
+			if tt.want.Timestamp.IsZero() {
+				tt.want.Timestamp = got.Timestamp
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeEvent() = %+v\npayload %+v\nwant %+v\npayload %+v", got, got.Payload, tt.want, tt.want.Payload)
+			}
+		})
+	}
+}
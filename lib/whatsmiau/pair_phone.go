@@ -0,0 +1,86 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidPhoneNumber = errors.New("phone number must be in E.164 format, ex: +5511999999999")
+	ErrPairingInProgress  = errors.New("a pairing code login is in progress for this instance")
+	ErrQrCodeInProgress   = errors.New("a QR code login is in progress for this instance")
+	ErrAlreadyLoggedIn    = errors.New("instance is already logged in")
+)
+
+var e164Regex = regexp.MustCompile(`^\+?[1-9]\d{7,14}$`)
+
+// PairPhone links the instance with the 8-character code the user types on
+// the phone (Linked devices > Link with phone number) instead of scanning a QR
+// code. The login is observed by the same goroutine as Connect, so a
+// successful pair updates the instance RemoteJID the same way. Calling it
+// again while the code is valid returns the same code.
+func (s *Whatsmiau) PairPhone(ctx context.Context, id string, phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if !e164Regex.MatchString(phone) {
+		return "", ErrInvalidPhoneNumber
+	}
+	phone = strings.TrimPrefix(phone, "+")
+
+	if code, ok := s.pairCodes.Load(id); ok {
+		if len(code) > 0 {
+			return code, nil
+		}
+		return "", ErrPairingInProgress
+	}
+
+	if _, ok := s.observerRunning.Load(id); ok {
+		return "", ErrQrCodeInProgress
+	}
+
+	client, err := s.generateClient(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		return "", ErrAlreadyLoggedIn
+	}
+
+	// claims the login so Connect won't hand out a QR code meanwhile
+	if _, loaded := s.pairCodes.LoadOrStore(id, ""); loaded {
+		return "", ErrPairingInProgress
+	}
+
+	code, err := s.requestPairCode(ctx, id, client, phone)
+	if err != nil {
+		s.pairCodes.Delete(id)
+		return "", err
+	}
+
+	s.pairCodes.Store(id, code)
+	return code, nil
+}
+
+// requestPairCode waits for the first QR code, which means the login websocket
+// is ready, before asking for the pairing code
+func (s *Whatsmiau) requestPairCode(ctx context.Context, id string, client *whatsmeow.Client, phone string) (string, error) {
+	if _, err := s.observeAndQrCode(ctx, id, client); err != nil {
+		return "", fmt.Errorf("failed to start login: %w", err)
+	}
+
+	code, err := client.PairPhone(ctx, phone, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
+	if err != nil {
+		if errors.Is(err, whatsmeow.ErrPhoneNumberTooShort) || errors.Is(err, whatsmeow.ErrPhoneNumberIsNotInternational) {
+			return "", fmt.Errorf("%w: %w", ErrInvalidPhoneNumber, err)
+		}
+		return "", fmt.Errorf("failed to request pairing code: %w", err)
+	}
+
+	zap.L().Info("pairing code requested", zap.String("id", id))
+	return code, nil
+}
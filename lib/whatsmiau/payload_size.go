@@ -0,0 +1,107 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// fitPayload keeps events under EMITTER_MAX_PAYLOAD_SIZE so receivers with body
+// limits don't reject them:
+//   - events whose data is a list (contacts, history batches) are split in pages,
+//     each delivered as its own event with page and pages set;
+//   - other events lose the inline media (data.message.base64) and, if still too
+//     big, keep only data.key, which points at the message store/media download.
+//
+// Shrunk events are flagged with truncated and originalSize.
+func fitPayload(data []byte, maxSize int) ([][]byte, error) {
+	if maxSize <= 0 || len(data) <= maxSize {
+		return [][]byte{data}, nil
+	}
+
+	var event map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keeps ids and timestamps as they were
+	if err := decoder.Decode(&event); err != nil {
+		return nil, err
+	}
+
+	if items, ok := event["data"].([]any); ok && len(items) > 1 {
+		return paginatePayload(event, items, maxSize)
+	}
+
+	event["truncated"] = true
+	event["originalSize"] = len(data)
+	if eventData, ok := event["data"].(map[string]any); ok {
+		if message, ok := eventData["message"].(map[string]any); ok {
+			delete(message, "base64")
+		}
+		if fitted, err := json.Marshal(event); err != nil || len(fitted) <= maxSize {
+			return [][]byte{fitted}, err
+		}
+
+		event["data"] = map[string]any{"key": eventData["key"]}
+	} else {
+		delete(event, "data")
+	}
+
+	fitted, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if len(fitted) > maxSize {
+		return nil, fmt.Errorf("event is %d bytes even truncated, max is %d", len(fitted), maxSize)
+	}
+
+	return [][]byte{fitted}, nil
+}
+
+func paginatePayload(event map[string]any, items []any, maxSize int) ([][]byte, error) {
+	event["data"] = []any{}
+	event["page"] = 0
+	event["pages"] = 0
+	envelope, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// room for the page numbers digits
+	budget := maxSize - len(envelope) - 16
+
+	var (
+		pages [][]any
+		page  []any
+		size  int
+	)
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if len(encoded)+1 > budget {
+			return nil, fmt.Errorf("event item is %d bytes, max is %d", len(encoded), maxSize)
+		}
+		if size+len(encoded)+1 > budget && len(page) > 0 {
+			pages = append(pages, page)
+			page, size = nil, 0
+		}
+		page = append(page, item)
+		size += len(encoded) + 1
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+
+	result := make([][]byte, len(pages))
+	for i, page := range pages {
+		event["data"] = page
+		event["page"] = i + 1
+		event["pages"] = len(pages)
+		result[i], err = json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
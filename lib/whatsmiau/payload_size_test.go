@@ -0,0 +1,121 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+func TestFitPayloadUnderLimit(t *testing.T) {
+	data := []byte(`{"event":"messages.upsert","data":{"key":{"id":"A1"}}}`)
+	for _, maxSize := range []int{0, len(data)} {
+		payloads, err := fitPayload(data, maxSize)
+		if err != nil || len(payloads) != 1 || string(payloads[0]) != string(data) {
+			t.Errorf("fitPayload(max %d) = %s, %v, want the event untouched", maxSize, payloads, err)
+		}
+	}
+}
+
+func TestFitPayloadTruncates(t *testing.T) {
+	base64 := strings.Repeat("A", 500)
+	data := []byte(`{"event":"messages.upsert","data":{"key":{"id":"A1"},"message":{"conversation":"hi","base64":"` + base64 + `"}}}`)
+
+	// without the inline media it fits
+	payloads, err := fitPayload(data, 200)
+	if err != nil || len(payloads) != 1 {
+		t.Fatalf("fitPayload = %d payloads, %v", len(payloads), err)
+	}
+	var event struct {
+		Truncated    bool `json:"truncated"`
+		OriginalSize int  `json:"originalSize"`
+		Data         struct {
+			Message map[string]any `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payloads[0], &event); err != nil {
+		t.Fatal(err)
+	}
+	if !event.Truncated || event.OriginalSize != len(data) || event.Data.Message["base64"] != nil || event.Data.Message["conversation"] != "hi" {
+		t.Errorf("payload = %s, want the message without base64", payloads[0])
+	}
+
+	// still too big, only the key is kept
+	data = []byte(`{"event":"messages.upsert","data":{"key":{"id":"A1"},"message":{"conversation":"` + base64 + `"}}}`)
+	payloads, err = fitPayload(data, 200)
+	if err != nil || len(payloads) != 1 {
+		t.Fatalf("fitPayload = %d payloads, %v", len(payloads), err)
+	}
+	if got := string(payloads[0]); !strings.Contains(got, `"data":{"key":{"id":"A1"}}`) || len(got) > 200 {
+		t.Errorf("payload = %s, want only data.key", got)
+	}
+
+	if _, err := fitPayload(data, 10); err == nil {
+		t.Error("expected an error when not even the key fits")
+	}
+}
+
+func TestPaginatePayload(t *testing.T) {
+	items := make([]string, 10)
+	for i := range items {
+		items[i] = `{"id":"` + strings.Repeat("x", 40) + `"}`
+	}
+	data := []byte(`{"event":"contacts.upsert","data":[` + strings.Join(items, ",") + `]}`)
+
+	payloads, err := fitPayload(data, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) < 2 {
+		t.Fatalf("got %d pages, want the list split", len(payloads))
+	}
+
+	total := 0
+	for i, payload := range payloads {
+		if len(payload) > 200 {
+			t.Errorf("page %d is %d bytes, max is 200", i+1, len(payload))
+		}
+		var page struct {
+			Event string `json:"event"`
+			Data  []any  `json:"data"`
+			Page  int    `json:"page"`
+			Pages int    `json:"pages"`
+		}
+		if err := json.Unmarshal(payload, &page); err != nil {
+			t.Fatal(err)
+		}
+		if page.Event != "contacts.upsert" || page.Page != i+1 || page.Pages != len(payloads) {
+			t.Errorf("page %d = %s, want the event with page %d of %d", i+1, payload, i+1, len(payloads))
+		}
+		total += len(page.Data)
+	}
+	if total != len(items) {
+		t.Errorf("pages carry %d items, want %d", total, len(items))
+	}
+
+	big := []byte(`{"event":"contacts.upsert","data":[{"id":"` + strings.Repeat("x", 300) + `"},{"id":"1"}]}`)
+	if _, err := fitPayload(big, 200); err == nil {
+		t.Error("expected an error for an item bigger than the limit")
+	}
+}
+
+func TestEmitPayloadRenderedTooBig(t *testing.T) {
+	s, deadLetters := newRetryTestMiau(t, 10)
+	env.Env.EmitterMaxPayloadSize = 100
+	s.payloadTemplates = xsync.NewMap[string, *payloadTemplate]()
+
+	// the event fits, the template makes it bigger
+	event := emitter{
+		instance: "instance",
+		url:      "http://127.0.0.1:1",
+		template: `{"padding":"` + strings.Repeat("x", 200) + `","id":{{json .data.key.id}}}`,
+	}
+	s.emitPayload(event, []byte(`{"event":"messages.upsert","data":{"key":{"id":"A1"}}}`))
+
+	reasons := deadLetters.reasons()
+	if len(reasons) != 1 || !strings.Contains(reasons[0], "rendered event") {
+		t.Errorf("dead letters = %v, want the rendered event too big", reasons)
+	}
+}
@@ -0,0 +1,88 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+var payloadTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// ParsePayloadTemplate parses a webhook payload template. Templates are Go
+// text/template executed over the event as decoded JSON, so fields are reached by
+// their JSON names (ex: {{json .data.key.id}}), and must render valid JSON.
+func ParsePayloadTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("payload").Funcs(payloadTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// catches calls to unknown fields on non map values and bad function arguments
+	sample, err := json.Marshal(&WookEvent[WookMessageData]{
+		Instance: "sample",
+		Data:     &WookMessageData{Key: &WookKey{Id: "sample"}},
+		DateTime: time.Now(),
+		Event:    WookMessagesUpsert,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := executePayloadTemplate(tmpl, sample); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func executePayloadTemplate(tmpl *template.Template, data []byte) ([]byte, error) {
+	var event any
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// payloadTemplate is the parsed webhook template of an instance
+type payloadTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// renderPayload applies the instance template to an already marshalled event.
+// The parsed template is cached per instance, a changed text replaces it.
+func (s *Whatsmiau) renderPayload(instance, text string, data []byte) ([]byte, error) {
+	cached, ok := s.payloadTemplates.Load(instance)
+	if !ok || cached.text != text {
+		tmpl, err := ParsePayloadTemplate(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload template: %w", err)
+		}
+		cached = &payloadTemplate{text: text, tmpl: tmpl}
+		s.payloadTemplates.Store(instance, cached)
+	}
+
+	rendered, err := executePayloadTemplate(cached.tmpl, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute payload template: %w", err)
+	}
+
+	if !json.Valid(rendered) {
+		return nil, errors.New("payload template rendered invalid JSON")
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,40 @@
+package whatsmiau
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+func TestRenderPayloadCachePerInstance(t *testing.T) {
+	s := &Whatsmiau{
+		payloadTemplates: xsync.NewMap[string, *payloadTemplate](),
+		templates:        memoryTemplates{},
+	}
+	data := []byte(`{"event":"messages.upsert","data":{"key":{"id":"A1"}}}`)
+
+	for i, text := range []string{
+		`{"id":{{json .data.key.id}}}`,
+		`{"event":{{json .event}}}`,
+	} {
+		if _, err := s.renderPayload("instance", text, data); err != nil {
+			t.Fatalf("template %d: %v", i, err)
+		}
+	}
+
+	// an updated template replaces the previous one
+	if cached, _ := s.payloadTemplates.Load("instance"); s.payloadTemplates.Size() != 1 || cached.text != `{"event":{{json .event}}}` {
+		t.Errorf("cached %d templates, want only the latest of the instance", s.payloadTemplates.Size())
+	}
+
+	rendered, err := s.renderPayload("instance", `{"event":{{json .event}}}`, data)
+	if err != nil || string(rendered) != `{"event":"messages.upsert"}` {
+		t.Errorf("rendered %s, %v", rendered, err)
+	}
+
+	s.ForgetInstance(context.Background(), "instance")
+	if s.payloadTemplates.Size() != 0 {
+		t.Error("template still cached after the instance was deleted")
+	}
+}
@@ -0,0 +1,309 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+)
+
+// MaxPollOptions is how many options WhatsApp accepts on a poll
+const MaxPollOptions = 12
+
+var (
+	ErrNotPollMessage = errors.New("message is not a poll")
+	ErrInvalidPoll    = errors.New("invalid poll")
+)
+
+type PollResults struct {
+	PollID  types.MessageID `json:"poll_id"`
+	Chat    string          `json:"chat"`
+	Name    string          `json:"name"`
+	Options []PollOption    `json:"options"`
+}
+
+type PollOption struct {
+	Name   string   `json:"name"`
+	Voters []string `json:"voters"`
+}
+
+// handlePollVote decrypts a poll vote with the poll's message secret, kept by
+// whatsmeow for polls sent and received by the device. The voter's latest
+// selection is stored (STORE_MESSAGES) replacing the previous one like in the
+// app, and emitted as messages.poll-vote with the option names.
+func (s *Whatsmiau) handlePollVote(id string, instance *models.Instance, e *events.Message, eventMap map[string]bool) {
+	store, emit := env.Env.StoreMessages, eventMap["MESSAGES_POLL_VOTE"]
+	if !store && !emit {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return
+	}
+
+	ctx, c := context.WithTimeout(context.Background(), time.Second*5)
+	defer c()
+
+	vote, err := client.DecryptPollVote(ctx, e)
+	if err != nil {
+		zap.L().Warn("failed to decrypt poll vote", zap.String("instance", id), zap.String("message", e.Info.ID), zap.Error(err))
+		return
+	}
+
+	pollKey := e.Message.GetPollUpdateMessage().GetPollCreationMessageKey()
+	pollID := pollKey.GetID()
+	// keyed by number, the same voter may arrive by lid or by number
+	voterJid, voterLid := s.GetJidLid(ctx, id, e.Info.Sender.ToNonAD())
+	if store {
+		if err := s.messages.SaveVote(ctx, id, pollID, voterJid, vote.GetSelectedOptions()); err != nil {
+			zap.L().Error("failed to store poll vote", zap.String("instance", id), zap.String("poll", pollID), zap.Error(err))
+		}
+	}
+
+	if !emit || canIgnoreGroup(e, instance) {
+		return
+	}
+
+	selected, unknown := matchPollOptions(s.pollOptions(ctx, id, pollID), vote.GetSelectedOptions())
+	jid, lid := s.GetJidLid(ctx, id, e.Info.Chat)
+	s.emit(&WookEvent[WookPollVoteData]{
+		Instance: instance.ID,
+		Data: &WookPollVoteData{
+			Key: &WookKey{
+				RemoteJid:   jid,
+				RemoteLid:   lid,
+				FromMe:      pollKey.GetFromMe(),
+				Id:          pollID,
+				Participant: pollKey.GetParticipant(),
+			},
+			Voter:           voterJid,
+			VoterLid:        voterLid,
+			SelectedOptions: selected,
+			UnknownOptions:  unknown,
+			VoteId:          e.Info.ID,
+			InstanceId:      instance.ID,
+			Origin:          origin(e.Info.IsFromMe),
+		},
+		DateTime: e.Info.Timestamp,
+		Event:    WookMessagesPollVote,
+	}, instance)
+}
+
+// matchPollOptions maps the selected option hashes back to their names, hashes
+// of options we don't know are returned hex encoded. An empty selection is a
+// removed vote.
+func matchPollOptions(names []string, selected [][]byte) ([]string, []string) {
+	hashes := whatsmeow.HashPollOptions(names)
+	result := make([]string, 0, len(selected))
+	var unknown []string
+	for _, selectedHash := range selected {
+		index := slices.IndexFunc(hashes, func(hash []byte) bool {
+			return bytes.Equal(hash, selectedHash)
+		})
+		if index < 0 {
+			unknown = append(unknown, hex.EncodeToString(selectedHash))
+			continue
+		}
+		result = append(result, names[index])
+	}
+
+	return result, unknown
+}
+
+// cachePollOptions remembers the options of a poll for POLL_CACHE_TTL, votes
+// only carry hashes of the selected option names. A full cache (POLL_CACHE_SIZE)
+// stores nothing until entries expire.
+func (s *Whatsmiau) cachePollOptions(id string, pollID types.MessageID, names []string) {
+	ttl := env.Env.PollCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	key := id + ":" + pollID
+	if limit := env.Env.PollCacheSize; limit > 0 && s.polls.Size() >= limit {
+		if _, ok := s.polls.Load(key); !ok {
+			return
+		}
+	}
+	s.polls.Store(key, names)
+	time.AfterFunc(ttl, func() {
+		// a newer entry may have been stored since, it has its own timer
+		s.polls.Compute(key, func(cached []string, loaded bool) ([]string, xsync.ComputeOp) {
+			if loaded && slices.Equal(cached, names) {
+				return nil, xsync.DeleteOp
+			}
+			return cached, xsync.CancelOp
+		})
+	})
+}
+
+// pollOptions returns the option names of a poll from the cache, falling back
+// to the message store. Unknown polls have no options.
+func (s *Whatsmiau) pollOptions(ctx context.Context, id string, pollID types.MessageID) []string {
+	if names, ok := s.polls.Load(id + ":" + pollID); ok {
+		return names
+	}
+
+	if !env.Env.StoreMessages {
+		return nil
+	}
+
+	stored, err := s.messages.Get(ctx, id, pollID)
+	if err != nil || stored.Poll == nil {
+		return nil
+	}
+
+	s.cachePollOptions(id, pollID, stored.Poll.Options)
+	return stored.Poll.Options
+}
+
+func pollOptionNames(poll *waE2E.PollCreationMessage) []string {
+	names := make([]string, len(poll.GetOptions()))
+	for i, option := range poll.GetOptions() {
+		names[i] = option.GetOptionName()
+	}
+
+	return names
+}
+
+// SendPoll sends a poll where voters can pick up to selectableCount options.
+// Votes arrive as messages.poll-vote. Returns the message id.
+func (s *Whatsmiau) SendPoll(ctx context.Context, id string, to types.JID, question string, options []string, selectableCount int) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validatePoll(question, options, selectableCount); err != nil {
+		return "", err
+	}
+
+	release, err := s.acquireSendSlot(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	to = s.resolveRecipient(ctx, id, to)
+	message := client.BuildPollCreation(question, options, selectableCount)
+	poll := pollCreation(message)
+	poll.ContextInfo = s.defaultExpirationContext(id, to)
+	res, err := s.sendReserved(ctx, id, client, to, message)
+	if err != nil {
+		return "", err
+	}
+
+	s.cachePollOptions(id, res.ID, pollOptionNames(poll))
+	return res.ID, nil
+}
+
+func validatePoll(question string, options []string, selectableCount int) error {
+	if question == "" {
+		return fmt.Errorf("%w: question is required", ErrInvalidPoll)
+	}
+
+	if len(options) < 2 || len(options) > MaxPollOptions {
+		return fmt.Errorf("%w: between 2 and %d options are required, got %d", ErrInvalidPoll, MaxPollOptions, len(options))
+	}
+
+	seen := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option == "" {
+			return fmt.Errorf("%w: options can't be empty", ErrInvalidPoll)
+		}
+		if seen[option] {
+			// votes reference options by the hash of the name
+			return fmt.Errorf("%w: duplicated option %q", ErrInvalidPoll, option)
+		}
+		seen[option] = true
+	}
+
+	if selectableCount < 1 || selectableCount > len(options) {
+		return fmt.Errorf("%w: selectable count must be between 1 and %d, got %d", ErrInvalidPoll, len(options), selectableCount)
+	}
+
+	return nil
+}
+
+// GetPollResults aggregates the votes received for a poll so consumers that
+// missed the live updates can catch up. Both the poll and its votes come from
+// the message store (STORE_MESSAGES), votes received before it was on are unknown.
+func (s *Whatsmiau) GetPollResults(ctx context.Context, id string, pollChat types.JID, pollMessageID types.MessageID) (*PollResults, error) {
+	if _, ok := s.clients.Load(id); !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !env.Env.StoreMessages {
+		return nil, fmt.Errorf("%w: STORE_MESSAGES is disabled", ErrMessageNotFound)
+	}
+
+	stored, err := s.messages.Get(ctx, id, pollMessageID)
+	if err != nil {
+		if errors.Is(err, messages.ErrorNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	if stored.Chat != pollChat.ToNonAD().String() {
+		return nil, ErrMessageNotFound
+	}
+
+	if stored.Poll == nil {
+		return nil, ErrNotPollMessage
+	}
+
+	votes, err := s.messages.GetVotes(ctx, id, pollMessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := stored.Poll.Options
+	result := &PollResults{
+		PollID:  pollMessageID,
+		Chat:    stored.Chat,
+		Name:    stored.Poll.Name,
+		Options: make([]PollOption, len(names)),
+	}
+	for i, hash := range whatsmeow.HashPollOptions(names) {
+		option := PollOption{Name: names[i], Voters: []string{}}
+		for voter, selected := range votes {
+			for _, selectedHash := range selected {
+				if bytes.Equal(selectedHash, hash) {
+					option.Voters = append(option.Voters, voter)
+					break
+				}
+			}
+		}
+		result.Options[i] = option
+	}
+
+	return result, nil
+}
+
+func pollCreation(m *waE2E.Message) *waE2E.PollCreationMessage {
+	switch {
+	case m.GetPollCreationMessage() != nil:
+		return m.GetPollCreationMessage()
+	case m.GetPollCreationMessageV2() != nil:
+		return m.GetPollCreationMessageV2()
+	case m.GetPollCreationMessageV3() != nil:
+		return m.GetPollCreationMessageV3()
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package whatsmiau
+
+import (
+	"context"
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// memoryMsgSecrets is the part of the device store holding message secrets
+type memoryMsgSecrets map[string][]byte
+
+func (m memoryMsgSecrets) PutMessageSecrets(ctx context.Context, inserts []store.MessageSecretInsert) error {
+	for _, insert := range inserts {
+		_ = m.PutMessageSecret(ctx, insert.Chat, insert.Sender, insert.ID, insert.Secret)
+	}
+	return nil
+}
+
+func (m memoryMsgSecrets) PutMessageSecret(_ context.Context, chat, sender types.JID, id types.MessageID, secret []byte) error {
+	m[chat.String()+"|"+sender.String()+"|"+id] = secret
+	return nil
+}
+
+func (m memoryMsgSecrets) GetMessageSecret(_ context.Context, chat, sender types.JID, id types.MessageID) ([]byte, types.JID, error) {
+	return m[chat.String()+"|"+sender.String()+"|"+id], sender, nil
+}
+
+func newPollTestClient(own types.JID, secrets memoryMsgSecrets) *whatsmeow.Client {
+	return whatsmeow.NewClient(&store.Device{ID: &own, MsgSecrets: secrets}, nil)
+}
+
+func TestDecryptPollVote(t *testing.T) {
+	ctx := context.Background()
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	voter := types.NewJID("5511911111111", types.DefaultUserServer)
+	options := []string{"yes", "no", "maybe"}
+
+	// the poll was sent by us to the group, both devices know its secret
+	poll := newTestClient(t).BuildPollCreation("lunch?", options, 2)
+	pollID := types.MessageID("POLL1")
+	secrets := memoryMsgSecrets{}
+	_ = secrets.PutMessageSecret(ctx, group, testOwnJID, pollID, poll.GetMessageContextInfo().GetMessageSecret())
+
+	pollInfo := &types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: group, Sender: testOwnJID, IsGroup: true},
+		ID:            pollID,
+	}
+	voteMessage, err := newPollTestClient(voter, secrets).BuildPollVote(ctx, pollInfo, []string{"maybe", "yes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vote, err := newPollTestClient(testOwnJID, secrets).DecryptPollVote(ctx, &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: group, Sender: voter, IsGroup: true},
+			ID:            "VOTE1",
+		},
+		Message: voteMessage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknownHash := whatsmeow.HashPollOptions([]string{"removed option"})[0]
+	selected, unknown := matchPollOptions(options, append(vote.GetSelectedOptions(), unknownHash))
+	if !slices.Equal(selected, []string{"maybe", "yes"}) {
+		t.Errorf("selected = %v, want [maybe yes]", selected)
+	}
+	if !slices.Equal(unknown, []string{hex.EncodeToString(unknownHash)}) {
+		t.Errorf("unknown = %v, want the hash of the removed option", unknown)
+	}
+}
+
+func TestMatchPollOptionsRemovedVote(t *testing.T) {
+	selected, unknown := matchPollOptions([]string{"yes", "no"}, nil)
+	if len(selected) != 0 || len(unknown) != 0 {
+		t.Errorf("matchPollOptions(nil) = %v, %v, want an empty selection", selected, unknown)
+	}
+}
+
+// voteMessages records the stored votes by voter
+type voteMessages struct {
+	memoryMessages
+	votes map[string][][]byte
+}
+
+func (m *voteMessages) SaveVote(_ context.Context, _, _, voter string, selected [][]byte) error {
+	m.votes[voter] = selected
+	return nil
+}
+
+func TestPollVoteKeyedByNumber(t *testing.T) {
+	previous := env.Env
+	env.Env.StoreMessages = true
+	env.Env.LidCacheTTL = time.Hour
+	t.Cleanup(func() { env.Env = previous })
+
+	ctx := context.Background()
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	poll := newTestClient(t).BuildPollCreation("lunch?", []string{"yes", "no"}, 1)
+	pollID := types.MessageID("POLL1")
+	secrets := memoryMsgSecrets{}
+	_ = secrets.PutMessageSecret(ctx, group, testOwnJID, pollID, poll.GetMessageContextInfo().GetMessageSecret())
+
+	pollInfo := &types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: group, Sender: testOwnJID, IsGroup: true},
+		ID:            pollID,
+	}
+	stored := &voteMessages{votes: map[string][][]byte{}}
+	s := newTestMiau()
+	s.messages = stored
+	own := testOwnJID
+	s.clients.Store("instance", whatsmeow.NewClient(&store.Device{ID: &own, MsgSecrets: secrets, LIDs: &countingLIDs{}}, nil))
+
+	// the same contact votes by lid, then changes the vote by number
+	for i, voter := range []types.JID{testLID, testPN} {
+		voteMessage, err := newPollTestClient(voter, secrets).BuildPollVote(ctx, pollInfo, []string{[]string{"yes", "no"}[i]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.handlePollVote("instance", &models.Instance{ID: "instance"}, &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{Chat: group, Sender: voter, IsGroup: true},
+				ID:            types.MessageID("VOTE" + voter.User),
+			},
+			Message: voteMessage,
+		}, map[string]bool{})
+	}
+
+	votes, ok := stored.votes[testPN.String()]
+	if len(stored.votes) != 1 || !ok {
+		t.Fatalf("votes = %v, want a single voter keyed by number", stored.votes)
+	}
+	if selected, _ := matchPollOptions([]string{"yes", "no"}, votes); !slices.Equal(selected, []string{"no"}) {
+		t.Errorf("selected = %v, want the latest vote", selected)
+	}
+}
+
+func TestCachePollOptionsSize(t *testing.T) {
+	previous := env.Env
+	env.Env.PollCacheTTL = time.Hour
+	env.Env.PollCacheSize = 2
+	t.Cleanup(func() { env.Env = previous })
+
+	s := newTestMiau()
+	s.polls = xsync.NewMap[string, []string]()
+	s.cachePollOptions("instance", "POLL1", []string{"yes", "no"})
+	s.cachePollOptions("instance", "POLL2", []string{"yes", "no"})
+	s.cachePollOptions("instance", "POLL3", []string{"yes", "no"})
+
+	if _, ok := s.polls.Load("instance:POLL3"); ok || s.polls.Size() != 2 {
+		t.Errorf("cached %d polls, want a full cache to skip new ones", s.polls.Size())
+	}
+
+	// known polls are still refreshed
+	s.cachePollOptions("instance", "POLL1", []string{"lunch", "dinner"})
+	if names, _ := s.polls.Load("instance:POLL1"); !slices.Equal(names, []string{"lunch", "dinner"}) {
+		t.Errorf("POLL1 = %v, want the new options", names)
+	}
+}
@@ -0,0 +1,176 @@
+package whatsmiau
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// startAlwaysOnline marks the account as available right after connecting and
+// keeps refreshing it while connected. Staying online stops the phone from
+// receiving push notifications and may look automated, so it is opt-in per
+// instance (alwaysOnline).
+func (s *Whatsmiau) startAlwaysOnline(id string, instance *models.Instance) {
+	if instance.AlwaysOnline == nil || !*instance.AlwaysOnline {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if previous, loaded := s.presenceLoops.LoadAndStore(id, cancel); loaded {
+		previous()
+	}
+
+	go func() {
+		interval := env.Env.AlwaysOnlineInterval
+		for {
+			if client.IsConnected() && client.IsLoggedIn() {
+				if err := client.SendPresence(ctx, types.PresenceAvailable); err != nil {
+					zap.L().Warn("failed to send available presence", zap.String("id", id), zap.Error(err))
+				} else {
+					s.setOnline(ctx, id, client)
+				}
+			}
+
+			if interval <= 0 {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+// stopAlwaysOnline runs whenever the account goes offline, by request or with
+// the session, so it also forgets the available presence
+func (s *Whatsmiau) stopAlwaysOnline(id string) {
+	s.onlineInstances.Delete(id)
+	if cancel, ok := s.presenceLoops.LoadAndDelete(id); ok {
+		cancel()
+	}
+}
+
+var ErrPresenceOffline = errors.New("account must send an available presence first")
+
+type SendPresenceRequest struct {
+	InstanceID string `json:"instance_id"`
+	Available  bool   `json:"available"`
+}
+
+// SendPresence marks the account online or offline for every contact. Going
+// offline also stops the alwaysOnline loop, otherwise the next refresh would
+// bring the account back online.
+func (s *Whatsmiau) SendPresence(ctx context.Context, data *SendPresenceRequest) error {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	presence := types.PresenceAvailable
+	if !data.Available {
+		presence = types.PresenceUnavailable
+		s.stopAlwaysOnline(data.InstanceID)
+	}
+
+	if err := client.SendPresence(ctx, presence); err != nil {
+		return err
+	}
+
+	if data.Available {
+		s.setOnline(ctx, data.InstanceID, client)
+	}
+	return nil
+}
+
+// SubscribePresence asks WhatsApp for the presence of the contact, emitted as
+// presence.update (available, unavailable and last seen, typing and recording
+// in chats). Only subscribed contacts are emitted. WhatsApp only sends it while
+// the account itself is online, so an available presence must be sent first
+// (SendPresence or alwaysOnline). Subscriptions don't survive a reconnect, they
+// are renewed by the next available presence: alwaysOnline sends it on connect,
+// other instances get no presence until SendPresence is called again.
+func (s *Whatsmiau) SubscribePresence(ctx context.Context, id string, target types.JID) error {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return ErrNotLoggedIn
+	}
+
+	if _, ok := s.onlineInstances.Load(id); !ok {
+		return ErrPresenceOffline
+	}
+
+	pn, _ := s.GetJidLid(ctx, id, target.ToNonAD())
+	jid, err := types.ParseJID(pn)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SubscribePresence(ctx, jid); err != nil {
+		return err
+	}
+
+	s.presenceSubs.Store(presenceSubscriptionKey(id, pn), jid)
+	return nil
+}
+
+// setOnline records the available presence of the session, the first one
+// after going offline renews the presence subscriptions
+func (s *Whatsmiau) setOnline(ctx context.Context, id string, client *whatsmeow.Client) {
+	if _, loaded := s.onlineInstances.LoadOrStore(id, struct{}{}); loaded {
+		return
+	}
+
+	prefix := id + ":"
+	s.presenceSubs.Range(func(key string, jid types.JID) bool {
+		if strings.HasPrefix(key, prefix) {
+			if err := client.SubscribePresence(ctx, jid); err != nil {
+				zap.L().Warn("failed to renew presence subscription", zap.String("id", id), zap.Stringer("jid", jid), zap.Error(err))
+			}
+		}
+		return true
+	})
+}
+
+// presenceSubscribed expects the phone number JID, as returned by GetJidLid
+func (s *Whatsmiau) presenceSubscribed(id string, pn string) bool {
+	_, ok := s.presenceSubs.Load(presenceSubscriptionKey(id, pn))
+	return ok
+}
+
+func (s *Whatsmiau) forgetPresenceSubs(id string) {
+	prefix := id + ":"
+	s.presenceSubs.Range(func(key string, _ types.JID) bool {
+		if strings.HasPrefix(key, prefix) {
+			s.presenceSubs.Delete(key)
+		}
+		return true
+	})
+}
+
+func presenceSubscriptionKey(id string, pn string) string {
+	return id + ":" + pn
+}
@@ -0,0 +1,124 @@
+package whatsmiau
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestNormalizePresence(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	contact := types.NewJID("5511911111111", types.DefaultUserServer)
+	device := types.NewADJID("5511911111111", 0, 3)
+	other := types.NewJID("5511922222222", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	tests := []struct {
+		name string
+		evt  any
+		want *models.Event // nil when the event is dropped
+	}{
+		{
+			name: "presence of a subscribed contact",
+			evt:  &events.Presence{From: contact, Unavailable: true, LastSeen: at},
+			want: &models.Event{
+				Type:    models.EventPresence,
+				FromJID: contact.String(),
+				Payload: &models.PresencePayload{Presence: "unavailable", LastSeen: &at},
+			},
+		},
+		{
+			name: "presence of a contact not subscribed",
+			evt:  &events.Presence{From: other},
+		},
+		{
+			name: "recording of a subscribed contact",
+			evt: &events.ChatPresence{
+				MessageSource: types.MessageSource{Chat: group, Sender: device, IsGroup: true},
+				State:         types.ChatPresenceComposing,
+				Media:         types.ChatPresenceMediaAudio,
+			},
+			want: &models.Event{
+				Type:    models.EventPresence,
+				FromJID: contact.String(),
+				Payload: &models.PresencePayload{ChatJID: group.String(), Presence: "recording"},
+			},
+		},
+		{
+			name: "typing of a contact not subscribed",
+			evt: &events.ChatPresence{
+				MessageSource: types.MessageSource{Chat: other, Sender: other},
+				State:         types.ChatPresenceComposing,
+			},
+		},
+	}
+
+	s := newTestMiau()
+	s.presenceSubs.Store(presenceSubscriptionKey("instance", contact.String()), contact)
+	instance := &models.Instance{ID: "instance"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.normalizeEvent(context.Background(), "instance", instance, tt.evt)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("normalizeEvent() = %+v, want the event dropped", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("normalizeEvent() = nil")
+			}
+
+			tt.want.InstanceID = instance.ID
+			tt.want.Timestamp = got.Timestamp
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeEvent() = %+v\npayload %+v\nwant %+v\npayload %+v", got, got.Payload, tt.want, tt.want.Payload)
+			}
+		})
+	}
+}
+
+func TestConvertPresenceOnlySubscribed(t *testing.T) {
+	contact := types.NewJID("5511911111111", types.DefaultUserServer)
+	other := types.NewJID("5511922222222", types.DefaultUserServer)
+	s := newTestMiau()
+	s.presenceSubs.Store(presenceSubscriptionKey("instance", contact.String()), contact)
+
+	if got := s.convertPresence("instance", &events.Presence{From: contact}); got == nil || got.Presences[contact.String()].LastKnownPresence != "available" {
+		t.Errorf("convertPresence(subscribed) = %+v", got)
+	}
+	if got := s.convertPresence("instance", &events.Presence{From: other}); got != nil {
+		t.Errorf("convertPresence(not subscribed) = %+v, want nil", got)
+	}
+
+	typing := func(sender types.JID) *events.ChatPresence {
+		return &events.ChatPresence{MessageSource: types.MessageSource{Chat: sender, Sender: sender}, State: types.ChatPresenceComposing}
+	}
+	if got := s.convertChatPresence("instance", typing(contact)); got == nil || got.Presences[contact.String()].LastKnownPresence != "composing" {
+		t.Errorf("convertChatPresence(subscribed) = %+v", got)
+	}
+	if got := s.convertChatPresence("instance", typing(other)); got != nil {
+		t.Errorf("convertChatPresence(not subscribed) = %+v, want nil", got)
+	}
+}
+
+func TestForgetPresenceSubs(t *testing.T) {
+	contact := types.NewJID("5511911111111", types.DefaultUserServer)
+	s := newTestMiau()
+	s.presenceSubs.Store(presenceSubscriptionKey("instance", contact.String()), contact)
+	s.presenceSubs.Store(presenceSubscriptionKey("other", contact.String()), contact)
+
+	s.forgetPresenceSubs("instance")
+	if s.presenceSubscribed("instance", contact.String()) {
+		t.Error("subscription kept after logout")
+	}
+	if !s.presenceSubscribed("other", contact.String()) {
+		t.Error("subscription of another instance forgotten")
+	}
+}
@@ -0,0 +1,130 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var ErrInvalidPrivacySetting = errors.New("invalid privacy setting")
+
+var privacyAllowedValues = map[types.PrivacySettingType][]types.PrivacySetting{
+	types.PrivacySettingTypeGroupAdd:     {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeLastSeen:     {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeStatus:       {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeProfile:      {types.PrivacySettingAll, types.PrivacySettingContacts, types.PrivacySettingContactBlacklist, types.PrivacySettingNone},
+	types.PrivacySettingTypeReadReceipts: {types.PrivacySettingAll, types.PrivacySettingNone},
+	types.PrivacySettingTypeOnline:       {types.PrivacySettingAll, types.PrivacySettingMatchLastSeen},
+	types.PrivacySettingTypeCallAdd:      {types.PrivacySettingAll, types.PrivacySettingKnown},
+}
+
+type PrivacySettings struct {
+	GroupAdd     types.PrivacySetting `json:"groupadd"`
+	LastSeen     types.PrivacySetting `json:"last"`
+	Status       types.PrivacySetting `json:"status"`
+	Profile      types.PrivacySetting `json:"profile"`
+	ReadReceipts types.PrivacySetting `json:"readreceipts"`
+	Online       types.PrivacySetting `json:"online"`
+	CallAdd      types.PrivacySetting `json:"calladd"`
+}
+
+func convertPrivacySettings(settings types.PrivacySettings) *PrivacySettings {
+	return &PrivacySettings{
+		GroupAdd:     settings.GroupAdd,
+		LastSeen:     settings.LastSeen,
+		Status:       settings.Status,
+		Profile:      settings.Profile,
+		ReadReceipts: settings.ReadReceipts,
+		Online:       settings.Online,
+		CallAdd:      settings.CallAdd,
+	}
+}
+
+func (s *Whatsmiau) GetPrivacySettings(ctx context.Context, id string) (*PrivacySettings, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	settings, err := client.TryFetchPrivacySettings(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertPrivacySettings(*settings), nil
+}
+
+type SetPrivacySettingRequest struct {
+	InstanceID string                   `json:"instance_id"`
+	Name       types.PrivacySettingType `json:"name"`
+	Value      types.PrivacySetting     `json:"value"`
+}
+
+// SetPrivacySetting changes one privacy setting, validating the value allowed for
+// it, and returns the settings after the change. Unknown settings or values
+// fail with ErrInvalidPrivacySetting.
+func (s *Whatsmiau) SetPrivacySetting(ctx context.Context, data *SetPrivacySettingRequest) (*PrivacySettings, error) {
+	allowed, ok := privacyAllowedValues[data.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown setting %q", ErrInvalidPrivacySetting, data.Name)
+	}
+	if !slices.Contains(allowed, data.Value) {
+		return nil, fmt.Errorf("%w: value %q not allowed for %q, allowed: %v", ErrInvalidPrivacySetting, data.Value, data.Name, allowed)
+	}
+
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	settings, err := client.SetPrivacySetting(ctx, data.Name, data.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertPrivacySettings(settings), nil
+}
+
+// handlePrivacySettingsEvent is triggered by changes made from any device,
+// including the ones made through SetPrivacySetting
+func (s *Whatsmiau) handlePrivacySettingsEvent(id string, instance *models.Instance, e *events.PrivacySettings, eventMap map[string]bool) {
+	if !eventMap["PRIVACY_UPDATE"] {
+		return
+	}
+
+	var changed []types.PrivacySettingType
+	for name, ok := range map[types.PrivacySettingType]bool{
+		types.PrivacySettingTypeGroupAdd:     e.GroupAddChanged,
+		types.PrivacySettingTypeLastSeen:     e.LastSeenChanged,
+		types.PrivacySettingTypeStatus:       e.StatusChanged,
+		types.PrivacySettingTypeProfile:      e.ProfileChanged,
+		types.PrivacySettingTypeReadReceipts: e.ReadReceiptsChanged,
+		types.PrivacySettingTypeOnline:       e.OnlineChanged,
+		types.PrivacySettingTypeCallAdd:      e.CallAddChanged,
+	} {
+		if ok {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+
+	wookData := &WookEvent[WookPrivacyUpdateData]{
+		Instance: instance.ID,
+		Data: &WookPrivacyUpdateData{
+			Settings:   convertPrivacySettings(e.NewSettings),
+			Changed:    changed,
+			InstanceId: id,
+		},
+		DateTime: time.Now(),
+		Event:    WookPrivacyUpdate,
+	}
+
+	s.emit(wookData, instance)
+}
@@ -0,0 +1,33 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSetPrivacySettingValidation(t *testing.T) {
+	s := newTestMiau()
+	tests := []struct {
+		name    string
+		setting types.PrivacySettingType
+		value   types.PrivacySetting
+		wantErr error
+	}{
+		{name: "unknown setting", setting: "unknown", value: types.PrivacySettingAll, wantErr: ErrInvalidPrivacySetting},
+		{name: "value not allowed", setting: types.PrivacySettingTypeReadReceipts, value: types.PrivacySettingContacts, wantErr: ErrInvalidPrivacySetting},
+		{name: "valid", setting: types.PrivacySettingTypeReadReceipts, value: types.PrivacySettingNone, wantErr: whatsmeow.ErrClientIsNil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.SetPrivacySetting(context.Background(), &SetPrivacySettingRequest{InstanceID: "missing", Name: tt.setting, Value: tt.value})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
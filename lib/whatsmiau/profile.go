@@ -0,0 +1,163 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	profilePhotoSize = 640        // side of the square WhatsApp shows full size pictures
+	maxImagePixels   = 40_000_000 // bigger images are refused before decoding them
+)
+
+// SetOwnProfileName changes the push name of the account, the one contacts see
+// when the number isn't saved
+func (s *Whatsmiau) SetOwnProfileName(ctx context.Context, id string, name string) error {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SendAppState(ctx, appstate.BuildSettingPushName(name)); err != nil {
+		return err
+	}
+
+	// the patch isn't echoed back to this device, keep the store in sync for
+	// presences and outgoing messages
+	client.Store.PushName = name
+	return client.Store.Save(ctx)
+}
+
+// SetOwnStatusText changes the about text of the account
+func (s *Whatsmiau) SetOwnStatusText(ctx context.Context, id string, text string) error {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return err
+	}
+
+	return client.SetStatusMessage(ctx, text)
+}
+
+// SetOwnProfilePicture replaces the account picture, see profilePhoto for how
+// the image is converted. Returns the new picture id.
+func (s *Whatsmiau) SetOwnProfilePicture(ctx context.Context, id string, photo *MediaInput) (string, error) {
+	client, err := s.loggedInClient(id)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := s.readMediaInput(ctx, id, photo)
+	if err != nil {
+		return "", err
+	}
+
+	avatar, err := profilePhoto(content)
+	if err != nil {
+		return "", err
+	}
+
+	// whatsmeow targets the own account when the jid is empty
+	pictureID, err := client.SetGroupPhoto(ctx, types.EmptyJID, avatar)
+	if err != nil {
+		return "", err
+	}
+
+	if client.Store.ID != nil {
+		s.invalidateProfilePicture(id, client.Store.ID.ToNonAD())
+	}
+	return pictureID, nil
+}
+
+func (s *Whatsmiau) loggedInClient(id string) (*whatsmeow.Client, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	return client, nil
+}
+
+// profilePhoto converts an image to what WhatsApp expects for profile and group
+// pictures: a JPEG, center cropped to a square and scaled down to 640x640.
+// Content that isn't a supported image fails with ErrInvalidImageFormat.
+func profilePhoto(content []byte) ([]byte, error) {
+	img, err := decodeImage(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", whatsmeow.ErrInvalidImageFormat, err)
+	}
+
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	if side == 0 {
+		return nil, fmt.Errorf("%w: empty image", whatsmeow.ErrInvalidImageFormat)
+	}
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	var buf bytes.Buffer
+	size := min(side, profilePhotoSize)
+	if err := jpeg.Encode(&buf, scaleImage(img, crop, size, size), &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeImage decodes an image after checking its header, a few KB compressed
+// can declare dimensions that take gigabytes once decoded
+func decodeImage(content []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("image of %dx%d bigger than %d pixels", config.Width, config.Height, maxImagePixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	return img, err
+}
+
+// scaleImage resizes the src area to width x height averaging the source
+// pixels covered by each output pixel, good enough for downscaling photos.
+// Transparency is flattened over white since JPEG has no alpha.
+func scaleImage(img image.Image, src image.Rectangle, width, height int) image.Image {
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0, y1 := src.Min.Y+y*src.Dy()/height, src.Min.Y+(y+1)*src.Dy()/height
+		for x := range width {
+			x0, x1 := src.Min.X+x*src.Dx()/width, src.Min.X+(x+1)*src.Dx()/width
+
+			var r, g, b, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					// colors are alpha premultiplied, adding the missing alpha puts transparent areas over white
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, n = r+uint64(pr+0xffff-pa), g+uint64(pg+0xffff-pa), b+uint64(pb+0xffff-pa), n+1
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff})
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,109 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+var (
+	ErrNoProfilePicture     = errors.New("target has no profile picture")
+	ErrProfilePictureHidden = errors.New("target privacy settings hide the profile picture")
+)
+
+type ProfilePictureInfo struct {
+	URL        string `json:"url,omitempty"`
+	ID         string `json:"id"`
+	Type       string `json:"type,omitempty"`       // image (full size) or preview
+	NotChanged bool   `json:"notChanged,omitempty"` // the picture is still existingID, URL and Type are empty
+}
+
+// GetProfilePicture returns the picture of a contact or group. Passing the ID
+// of a previous result as existingID skips the download URL when the picture
+// didn't change since, answered from cache when the ID was seen recently.
+func (s *Whatsmiau) GetProfilePicture(ctx context.Context, id string, target types.JID, preview bool, existingID string) (*ProfilePictureInfo, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	if jid, _ := s.GetJidLid(ctx, id, target); jid != "" {
+		if parsed, err := types.ParseJID(jid); err == nil {
+			target = parsed
+		}
+	}
+
+	key := profilePictureKey(id, target)
+	if cached, ok := s.profilePictures.Load(key); ok && existingID != "" && cached == existingID {
+		return &ProfilePictureInfo{ID: existingID, NotChanged: true}, nil
+	}
+
+	info, err := client.GetProfilePictureInfo(ctx, target, &whatsmeow.GetProfilePictureParams{
+		Preview:    preview,
+		ExistingID: existingID,
+	})
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet):
+		s.profilePictures.Delete(key)
+		return nil, fmt.Errorf("%w: %w", ErrNoProfilePicture, err)
+	case errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+		return nil, fmt.Errorf("%w: %w", ErrProfilePictureHidden, err)
+	case err != nil:
+		return nil, err
+	}
+
+	if info == nil {
+		// whatsmeow answers nil without error when existingID is still current
+		s.cacheProfilePicture(key, existingID)
+		return &ProfilePictureInfo{ID: existingID, NotChanged: true}, nil
+	}
+
+	s.cacheProfilePicture(key, info.ID)
+	return &ProfilePictureInfo{
+		URL:  info.URL,
+		ID:   info.ID,
+		Type: info.Type,
+	}, nil
+}
+
+func (s *Whatsmiau) cacheProfilePicture(key, pictureID string) {
+	ttl := env.Env.ProfilePictureCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	if previous, loaded := s.profilePictures.LoadAndStore(key, pictureID); loaded && previous == pictureID {
+		return
+	}
+	time.AfterFunc(ttl, func() {
+		s.profilePictures.Compute(key, func(cached string, loaded bool) (string, xsync.ComputeOp) {
+			if loaded && cached == pictureID {
+				return "", xsync.DeleteOp
+			}
+			return cached, xsync.CancelOp
+		})
+	})
+}
+
+// invalidateProfilePicture drops the cached ID under both the given JID and the
+// phone number it resolves to, picture events may come with the LID
+func (s *Whatsmiau) invalidateProfilePicture(id string, target types.JID) {
+	s.profilePictures.Delete(profilePictureKey(id, target))
+	if jid, _ := s.GetJidLid(context.Background(), id, target); jid != "" {
+		s.profilePictures.Delete(id + ":" + jid)
+	}
+}
+
+func profilePictureKey(id string, target types.JID) string {
+	return id + ":" + target.ToNonAD().String()
+}
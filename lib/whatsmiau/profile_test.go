@@ -0,0 +1,53 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// pngHeader returns a PNG declaring width x height without any pixel data
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], width)
+	binary.BigEndian.PutUint32(ihdr[4:], height)
+	ihdr[8], ihdr[9] = 8, 2 // 8 bit RGB
+
+	chunk := append([]byte("IHDR"), ihdr...)
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	if _, err := decodeImage(pngHeader(100_000, 100_000)); err == nil {
+		t.Error("decodeImage(100000x100000) succeeded, want it refused before decoding")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := decodeImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 20 || bounds.Dy() != 10 {
+		t.Errorf("bounds = %v, want 20x10", bounds)
+	}
+}
+
+func TestProfilePhotoRefusesHugeImages(t *testing.T) {
+	if _, err := profilePhoto(pngHeader(100_000, 100_000)); !errors.Is(err, whatsmeow.ErrInvalidImageFormat) {
+		t.Errorf("err = %v, want ErrInvalidImageFormat", err)
+	}
+}
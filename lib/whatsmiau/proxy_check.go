@@ -0,0 +1,97 @@
+package whatsmiau
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// proxyCheckTargets are the hosts a client needs: the websocket and the media CDN
+var proxyCheckTargets = []string{
+	"https://web.whatsapp.com/",
+	"https://mmg.whatsapp.net/",
+}
+
+type ProxyTestResult struct {
+	Success bool                `json:"success"`
+	Targets []ProxyTargetResult `json:"targets"`
+}
+
+type ProxyTargetResult struct {
+	Target  string        `json:"target"`
+	Success bool          `json:"success"`
+	Status  int           `json:"status,omitempty"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// TestProxyConnectivity checks the proxy can reach WhatsApp by doing an HTTPS
+// request through it to each endpoint a client uses. Any HTTP response counts
+// as success, the goal is the tunnel and TLS handshake, not the status code.
+func (s *Whatsmiau) TestProxyConnectivity(ctx context.Context, proxyURL string) (*ProxyTestResult, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+
+	if !IsSupportedProxyScheme(u.Scheme) {
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+
+	client := newProxyHTTPClient(u, time.Second*15)
+	defer client.CloseIdleConnections()
+
+	result := &ProxyTestResult{Success: true}
+	for _, target := range proxyCheckTargets {
+		targetResult := ProxyTargetResult{Target: target}
+
+		start := time.Now()
+		status, err := probeTarget(ctx, client, target)
+		targetResult.Latency = time.Since(start)
+		if err != nil {
+			targetResult.Error = err.Error()
+			result.Success = false
+		} else {
+			targetResult.Success = true
+			targetResult.Status = status
+		}
+
+		result.Targets = append(result.Targets, targetResult)
+	}
+
+	return result, nil
+}
+
+// newProxyHTTPClient is a client that goes through the proxy without following
+// redirects or reusing connections, each request measures its own connection
+func newProxyHTTPClient(proxyUrl *url.URL, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyURL(proxyUrl),
+			DisableKeepAlives: true,
+		},
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func probeTarget(ctx context.Context, client *http.Client, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, target, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,49 @@
+package whatsmiau
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:8.8.8.8", true},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestRejectNonPublic(t *testing.T) {
+	if err := rejectNonPublic("tcp", "127.0.0.1:80", nil); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("loopback: got %v, want ErrPrivateAddress", err)
+	}
+	if err := rejectNonPublic("tcp", "[::1]:443", nil); !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("ipv6 loopback: got %v, want ErrPrivateAddress", err)
+	}
+	if err := rejectNonPublic("tcp", "1.1.1.1:443", nil); err != nil {
+		t.Errorf("public: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+type qrImage struct {
+	code string
+	png  []byte
+}
+
+// ConnectImage is Connect returning the QR code as a PNG. The image is encoded
+// once per QR code, repeated calls return the cached bytes until WhatsApp
+// rotates the code. Logged in instances return no image and no error.
+func (s *Whatsmiau) ConnectImage(ctx context.Context, id string) ([]byte, string, error) {
+	code, err := s.Connect(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if code == "" {
+		return []byte{}, "", nil
+	}
+
+	if cached, ok := s.qrImages.Load(id); ok && cached.code == code {
+		return cached.png, "image/png", nil
+	}
+
+	result, err := encodeQrPNG(code, env.Env.QrImageSize, env.Env.QrImageModuleSize, env.Env.QrImageQuietZone)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.qrImages.Store(id, qrImage{code: code, png: result})
+	return result, "image/png", nil
+}
+
+// encodeQrPNG draws the code with a quiet zone of quietZone modules. A positive
+// moduleSize sets the pixels per module and the image grows with the code,
+// otherwise the image is size x size.
+func encodeQrPNG(code string, size, moduleSize, quietZone int) ([]byte, error) {
+	qr, err := qrcode.New(code, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qrcode: %w", err)
+	}
+	qr.DisableBorder = true
+
+	symbol := qr.Bitmap()
+	quietZone = max(quietZone, 0)
+	modules := len(symbol) + quietZone*2
+	if moduleSize > 0 {
+		size = modules * moduleSize
+	}
+	size = max(size, modules)
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	modulesPerPixel := float64(modules) / float64(size)
+	for y := 0; y < size; y++ {
+		row := int(float64(y)*modulesPerPixel) - quietZone
+		if row < 0 || row >= len(symbol) {
+			continue
+		}
+		for x := 0; x < size; x++ {
+			col := int(float64(x)*modulesPerPixel) - quietZone
+			if col >= 0 && col < len(symbol) && symbol[row][col] {
+				img.Pix[img.PixOffset(x, y)] = 1
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
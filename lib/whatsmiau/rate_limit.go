@@ -0,0 +1,116 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+var ErrRateLimited = errors.New("send rate limit exceeded")
+
+// tokenBucket holds up to perMinute tokens and refills perMinute tokens a
+// minute, so an idle instance can burst a full minute of messages at once
+type tokenBucket struct {
+	mu        sync.Mutex
+	tokens    float64
+	perMinute int
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens:    float64(perMinute),
+		perMinute: perMinute,
+		last:      now,
+	}
+}
+
+// reserve takes a token, returning how long the caller must wait before using
+// it. Nothing is taken when the wait would exceed maxWait.
+func (b *tokenBucket) reserve(now time.Time, perMinute int, maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	capacity := float64(perMinute)
+	if b.perMinute != perMinute { // the instance limit changed
+		b.perMinute = perMinute
+		b.tokens = min(b.tokens, capacity)
+	}
+
+	if now.After(b.last) {
+		b.tokens = min(capacity, b.tokens+now.Sub(b.last).Minutes()*capacity)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	wait := time.Duration((1 - b.tokens) / capacity * float64(time.Minute))
+	if wait > maxWait {
+		return wait, false
+	}
+
+	b.tokens--
+	return wait, true
+}
+
+// release gives back a reserved token that wasn't used
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(float64(b.perMinute), b.tokens+1)
+}
+
+// acquireSend waits for a send token of the instance, up to SEND_RATE_LIMIT_WAIT.
+// Instances override SEND_RATE_LIMIT with sendRateLimit, 0 disables the limit.
+func (s *Whatsmiau) acquireSend(ctx context.Context, instanceID string) error {
+	perMinute := env.Env.SendRateLimit
+	if instance := s.getInstanceCached(instanceID); instance != nil && instance.SendRateLimit != nil {
+		perMinute = *instance.SendRateLimit
+	}
+	if perMinute <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	bucket, _ := s.sendLimiters.LoadOrCompute(instanceID, func() (*tokenBucket, bool) {
+		return newTokenBucket(perMinute, now), false
+	})
+
+	maxWait := env.Env.SendRateLimitWait
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < maxWait {
+		maxWait = time.Until(deadline)
+	}
+
+	wait, ok := bucket.reserve(now, perMinute, maxWait)
+	if !ok {
+		return ErrRateLimited
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		bucket.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// releaseSend gives back a token taken by acquireSend that won't be used
+func (s *Whatsmiau) releaseSend(instanceID string) {
+	if bucket, ok := s.sendLimiters.Load(instanceID); ok {
+		bucket.release()
+	}
+}
@@ -0,0 +1,121 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+func TestTokenBucketBurstAndRefill(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	bucket := newTokenBucket(60, start)
+
+	// an idle instance bursts a full minute at once
+	for i := range 60 {
+		if wait, ok := bucket.reserve(start, 60, 0); !ok || wait != 0 {
+			t.Fatalf("reserve %d = %v, %v, want immediate", i, wait, ok)
+		}
+	}
+
+	if wait, ok := bucket.reserve(start, 60, 0); ok {
+		t.Fatalf("reserve past the burst = %v, want rejected without waiting", wait)
+	}
+
+	// the next token is a second away at 60 a minute
+	wait, ok := bucket.reserve(start, 60, time.Minute)
+	if !ok || wait != time.Second {
+		t.Fatalf("reserve with wait = %v, %v, want 1s", wait, ok)
+	}
+
+	// that token was already promised, the refill after it is free again
+	if _, ok := bucket.reserve(start.Add(time.Second), 60, 0); ok {
+		t.Fatal("reserved a token promised to the waiting caller")
+	}
+	if wait, ok := bucket.reserve(start.Add(3*time.Second), 60, 0); !ok || wait != 0 {
+		t.Fatalf("reserve after refill = %v, %v, want immediate", wait, ok)
+	}
+
+	// refill is capped at the limit
+	later := start.Add(time.Hour)
+	for i := range 60 {
+		if _, ok := bucket.reserve(later, 60, 0); !ok {
+			t.Fatalf("reserve %d after an hour idle rejected", i)
+		}
+	}
+	if _, ok := bucket.reserve(later, 60, 0); ok {
+		t.Fatal("refill went past the limit")
+	}
+}
+
+func TestTokenBucketRelease(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	bucket := newTokenBucket(1, start)
+
+	if _, ok := bucket.reserve(start, 1, 0); !ok {
+		t.Fatal("first reserve rejected")
+	}
+	bucket.release()
+	if _, ok := bucket.reserve(start, 1, 0); !ok {
+		t.Fatal("released token can't be reserved again")
+	}
+}
+
+func TestAcquireSendSlotRateLimitedTakesNoSlot(t *testing.T) {
+	previous := env.Env
+	env.Env.SendRateLimit = 1
+	env.Env.SendRateLimitWait = 0
+	t.Cleanup(func() { env.Env = previous })
+
+	s := &Whatsmiau{
+		handlerSemaphore: make(chan struct{}, 1),
+		instanceCache:    xsync.NewMap[string, models.Instance](),
+		sendLimiters:     xsync.NewMap[string, *tokenBucket](),
+	}
+	s.instanceCache.Store("instance", models.Instance{ID: "instance"})
+
+	release, err := s.acquireSendSlot(context.Background(), "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	if _, err := s.acquireSendSlot(context.Background(), "instance"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second send err = %v, want ErrRateLimited", err)
+	}
+	if len(s.handlerSemaphore) != 0 {
+		t.Error("a rate limited send kept a handler slot")
+	}
+}
+
+func TestAcquireSendSlotCancelledReleasesToken(t *testing.T) {
+	previous := env.Env
+	env.Env.SendRateLimit = 1
+	env.Env.SendRateLimitWait = 0
+	t.Cleanup(func() { env.Env = previous })
+
+	s := &Whatsmiau{
+		handlerSemaphore: make(chan struct{}, 1),
+		instanceCache:    xsync.NewMap[string, models.Instance](),
+		sendLimiters:     xsync.NewMap[string, *tokenBucket](),
+	}
+	s.instanceCache.Store("instance", models.Instance{ID: "instance"})
+	s.handlerSemaphore <- struct{}{} // every slot busy
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquireSendSlot(ctx, "instance"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the context deadline", err)
+	}
+
+	<-s.handlerSemaphore
+	release, err := s.acquireSendSlot(context.Background(), "instance")
+	if err != nil {
+		t.Fatalf("send after a cancelled one = %v, want the token given back", err)
+	}
+	release()
+}
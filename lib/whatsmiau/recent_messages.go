@@ -0,0 +1,80 @@
+package whatsmiau
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrMessageContentExpired is returned for messages whose content can't be
+// rebuilt from the message store
+var ErrMessageContentExpired = fmt.Errorf("%w: content is only kept for RECENT_MESSAGE_CACHE_TTL", ErrMessageNotFound)
+
+type recentMessage struct {
+	info    types.MessageInfo
+	message *waE2E.Message
+}
+
+// rememberMessage keeps a sent or received message in memory for
+// RECENT_MESSAGE_CACHE_TTL, so it can be forwarded without the message store
+func (s *Whatsmiau) rememberMessage(id string, info types.MessageInfo, message *waE2E.Message) {
+	ttl := env.Env.RecentMessageCacheTTL
+	if ttl <= 0 || message == nil {
+		return
+	}
+
+	key := id + ":" + info.ID
+	recent := &recentMessage{info: info, message: message}
+	s.recentMessages.Store(key, recent)
+	time.AfterFunc(ttl, func() {
+		// a newer entry may have been stored since, it has its own timer
+		s.recentMessages.Compute(key, func(cached *recentMessage, loaded bool) (*recentMessage, xsync.ComputeOp) {
+			if loaded && cached == recent {
+				return nil, xsync.DeleteOp
+			}
+			return cached, xsync.CancelOp
+		})
+	})
+}
+
+// originalMessage returns a message the instance sent or received, from the
+// recent message cache or else the message store. The store keeps no raw
+// messages, older texts and media are rebuilt from its normalized fields and
+// the content is nil for anything else.
+func (s *Whatsmiau) originalMessage(ctx context.Context, id, messageID string) (*models.StoredMessage, *waE2E.Message, error) {
+	if recent, ok := s.recentMessages.Load(id + ":" + messageID); ok {
+		messageType, _, _ := s.parseWAMessage(recent.message)
+		return &models.StoredMessage{
+			InstanceID: id,
+			ID:         recent.info.ID,
+			Chat:       recent.info.Chat.ToNonAD().String(),
+			Sender:     recent.info.Sender.ToNonAD().String(),
+			FromMe:     recent.info.IsFromMe,
+			Timestamp:  recent.info.Timestamp,
+			Type:       messageType,
+			Text:       extractText(recent.message),
+		}, recent.message, nil
+	}
+
+	stored, err := s.getStoredMessage(ctx, id, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if stored.Media != nil {
+		return stored, storedMediaMessage(stored), nil
+	}
+
+	if stored.Type == "conversation" && stored.Text != "" {
+		return stored, &waE2E.Message{Conversation: proto.String(stored.Text)}, nil
+	}
+
+	return stored, nil, nil
+}
@@ -0,0 +1,64 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOriginalMessageFromRecentCache(t *testing.T) {
+	previous := env.Env
+	env.Env.StoreMessages = false
+	env.Env.RecentMessageCacheTTL = time.Hour
+	t.Cleanup(func() { env.Env = previous })
+
+	s := newTestMiau()
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+	info := types.MessageInfo{
+		MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+		ID:            "A1",
+		Timestamp:     time.Unix(1700000000, 0),
+	}
+	s.rememberMessage("instance", info, &waE2E.Message{Conversation: proto.String("hello")})
+
+	stored, message, err := s.originalMessage(context.Background(), "instance", "A1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.GetConversation() != "hello" {
+		t.Errorf("message = %v, want the remembered one", message)
+	}
+	if stored.Chat != chat.String() || stored.ID != "A1" || stored.FromMe || !stored.Timestamp.Equal(info.Timestamp) || stored.Type != "conversation" {
+		t.Errorf("stored = %+v, want the remembered info", stored)
+	}
+
+	if _, _, err := s.originalMessage(context.Background(), "other", "A1"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("other instance err = %v, want ErrMessageNotFound", err)
+	}
+}
+
+func TestRememberMessageExpires(t *testing.T) {
+	previous := env.Env
+	env.Env.RecentMessageCacheTTL = time.Millisecond
+	t.Cleanup(func() { env.Env = previous })
+
+	s := newTestMiau()
+	s.rememberMessage("instance", types.MessageInfo{ID: "A1"}, &waE2E.Message{Conversation: proto.String("hello")})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := s.recentMessages.Load("instance:A1"); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("message was not dropped after RECENT_MESSAGE_CACHE_TTL")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,191 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	waLog "go.mau.fi/whatsmeow/util/log"
+	"go.uber.org/zap"
+)
+
+// newClient creates a client with whatsmeow auto reconnect off, reconnects are
+// supervised by startReconnect so they can be spread with jitter. Every client
+// is configured here, so options must not be set after NewClient elsewhere.
+func newClient(device *store.Device, log waLog.Logger) *whatsmeow.Client {
+	client := whatsmeow.NewClient(device, log)
+	client.EnableAutoReconnect = false
+	client.AutomaticMessageRerequestFromPhone = env.Env.UndecryptableRequestFromPhone
+	return client
+}
+
+// reconnectDelay is the full jitter backoff: a random delay between zero and the
+// exponential backoff for the attempt, capped at RECONNECT_MAX_DELAY. Random
+// delays keep instances dropped at the same time from reconnecting together.
+func reconnectDelay(attempt int) time.Duration {
+	return backoffDelay(env.Env.ReconnectBaseDelay, env.Env.ReconnectMaxDelay, attempt)
+}
+
+// backoffDelay is a random delay up to base * 2^(attempt-1), capped at maxDelay
+// when positive
+func backoffDelay(base, maxDelay time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	backoff := base
+	for i := 1; i < attempt && backoff < time.Hour*24; i++ {
+		backoff *= 2
+	}
+	if maxDelay > 0 && backoff > maxDelay {
+		backoff = maxDelay
+	}
+
+	return rand.N(backoff + 1)
+}
+
+// reconnectLoop is the cancel of a running reconnect loop, kept by pointer so a
+// finished loop can tell its entry from the one of a loop started after it
+type reconnectLoop struct {
+	cancel context.CancelFunc
+}
+
+// startReconnect runs a single reconnect loop per instance until the client
+// connects, is removed (logout), RECONNECT_MAX_ATTEMPTS is reached or
+// stopReconnect is called
+func (s *Whatsmiau) startReconnect(id string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	loop := &reconnectLoop{cancel: cancel}
+	if _, loaded := s.reconnectLoops.LoadOrStore(id, loop); loaded {
+		cancel()
+		return
+	}
+
+	go func() {
+		defer func() {
+			s.forgetReconnect(id, loop)
+			cancel()
+		}()
+
+		for attempt := 1; ; attempt++ {
+			if maxAttempts := env.Env.ReconnectMaxAttempts; maxAttempts > 0 && attempt > maxAttempts {
+				zap.L().Warn("reconnect gave up", zap.String("id", id), zap.Int("attempts", maxAttempts))
+				s.emitReconnectState(id, Closed, "reconnect_failed", 0)
+				return
+			}
+
+			delay := reconnectDelay(attempt)
+			zap.L().Debug("reconnecting", zap.String("id", id), zap.Int("attempt", attempt), zap.Duration("delay", delay))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			client, ok := s.clients.Load(id)
+			if !ok || client.Store.ID == nil {
+				return
+			}
+
+			if _, blocked := s.accountBlock(id); blocked {
+				zap.L().Warn("account is blocked, reconnect stopped", zap.String("id", id))
+				return
+			}
+
+			s.emitReconnectState(id, Connecting, "", attempt)
+			err := client.Connect()
+			if err == nil || errors.Is(err, whatsmeow.ErrAlreadyConnected) {
+				zap.L().Info("reconnected", zap.String("id", id), zap.Int("attempt", attempt))
+				return
+			}
+
+			zap.L().Warn("failed to reconnect", zap.String("id", id), zap.Int("attempt", attempt), zap.Error(err))
+			if every := env.Env.ProxyFailoverAttempts; every > 0 && attempt%every == 0 {
+				s.rotateProxy(id, client)
+			}
+		}
+	}()
+}
+
+// emitReconnectState reports the reconnect progress. Nothing is emitted while
+// the disconnect is still inside the debounce window, consumers don't know
+// about it yet.
+func (s *Whatsmiau) emitReconnectState(id string, state Status, reason string, attempt int) {
+	if _, pending := s.disconnectTimers.Load(id); pending {
+		return
+	}
+
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return
+	}
+
+	eventMap := eventMapOf(instance)
+	if instance.Webhook.Format == models.WebhookFormatEvent {
+		if eventMap[models.EventConnection.Key()] {
+			s.emit(&models.Event{
+				Type:       models.EventConnection,
+				InstanceID: instance.ID,
+				Timestamp:  time.Now(),
+				Payload:    &models.ConnectionPayload{State: string(state), Reason: reason, Attempt: attempt},
+			}, instance)
+		}
+		return
+	}
+
+	if !eventMap["CONNECTION_UPDATE"] {
+		return
+	}
+
+	s.emit(&WookEvent[WookConnectionUpdateData]{
+		Instance: instance.ID,
+		Data: &WookConnectionUpdateData{
+			Instance: instance.ID,
+			State:    state,
+			Reason:   reason,
+			Attempt:  attempt,
+		},
+		DateTime: time.Now(),
+		Event:    WookConnectionUpdate,
+	}, instance)
+}
+
+// forgetReconnect removes the loop unless a stopReconnect followed by a new
+// startReconnect already replaced it
+func (s *Whatsmiau) forgetReconnect(id string, loop *reconnectLoop) {
+	s.reconnectLoops.Compute(id, func(current *reconnectLoop, loaded bool) (*reconnectLoop, xsync.ComputeOp) {
+		if loaded && current == loop {
+			return nil, xsync.DeleteOp
+		}
+		return current, xsync.CancelOp
+	})
+}
+
+func (s *Whatsmiau) stopReconnect(id string) {
+	if loop, ok := s.reconnectLoops.LoadAndDelete(id); ok {
+		loop.cancel()
+	}
+}
+
+// handleKeepAliveTimeout replaces the forced reconnect whatsmeow does on long
+// keepalive failures when its own auto reconnect is on
+func (s *Whatsmiau) handleKeepAliveTimeout(id string, lastSuccess time.Time) {
+	if time.Since(lastSuccess) <= whatsmeow.KeepAliveMaxFailTime {
+		return
+	}
+
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return
+	}
+
+	zap.L().Warn("forcing reconnect after keepalive failures", zap.String("id", id), zap.Time("lastSuccess", lastSuccess))
+	client.Disconnect()
+	s.startReconnect(id)
+}
@@ -0,0 +1,24 @@
+package whatsmiau
+
+import (
+	"testing"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+func TestForgetReconnectKeepsNewerLoop(t *testing.T) {
+	s := &Whatsmiau{reconnectLoops: xsync.NewMap[string, *reconnectLoop]()}
+	stale, newer := &reconnectLoop{cancel: func() {}}, &reconnectLoop{cancel: func() {}}
+
+	// the stale loop was stopped and a new one started before it returned
+	s.reconnectLoops.Store("instance", newer)
+	s.forgetReconnect("instance", stale)
+	if current, ok := s.reconnectLoops.Load("instance"); !ok || current != newer {
+		t.Fatal("a finished loop removed the one started after it")
+	}
+
+	s.forgetReconnect("instance", newer)
+	if _, ok := s.reconnectLoops.Load("instance"); ok {
+		t.Fatal("loop wasn't forgotten")
+	}
+}
@@ -0,0 +1,187 @@
+package whatsmiau
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/schedules"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("scheduled message not found")
+	ErrScheduleInPast   = errors.New("scheduled time is in the past")
+)
+
+const (
+	scheduleMaxAttempts   = 5 // sends tried before a scheduled message is dropped
+	scheduleRetryDelay    = time.Minute
+	scheduleRetryMaxDelay = time.Minute * 30
+)
+
+// ScheduledMessage is sent through SendText at dispatch time
+type ScheduledMessage struct {
+	Text    string           `json:"text"`
+	Options *SendTextOptions `json:"options"`
+}
+
+// ScheduleMessage persists the message and sends it at the given time, pending
+// messages are re-armed on boot. The connection and the send rate limit are
+// only checked at dispatch, a message that fails then is retried with backoff
+// and dropped after scheduleMaxAttempts sends.
+func (s *Whatsmiau) ScheduleMessage(ctx context.Context, id string, to types.JID, msg ScheduledMessage, at time.Time) (string, error) {
+	now := time.Now()
+	if !at.After(now) {
+		return "", ErrScheduleInPast
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	scheduled := models.ScheduledMessage{
+		ID:         uuid.NewString(),
+		InstanceID: id,
+		RemoteJID:  to.String(),
+		Message:    data,
+		At:         at,
+		CreatedAt:  now,
+	}
+	if err := s.schedules.Save(ctx, &scheduled); err != nil {
+		return "", err
+	}
+
+	s.armSchedule(scheduled, 0)
+	return scheduled.ID, nil
+}
+
+// CancelScheduled removes a pending message of the instance, ErrScheduleNotFound
+// means it was already sent or cancelled, or belongs to another instance
+func (s *Whatsmiau) CancelScheduled(ctx context.Context, id string, jobID string) error {
+	scheduled, err := s.schedules.Get(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, schedules.ErrorNotFound) {
+			return ErrScheduleNotFound
+		}
+		return err
+	}
+	if scheduled.InstanceID != id {
+		return ErrScheduleNotFound
+	}
+
+	if timer, ok := s.scheduleTimers.LoadAndDelete(jobID); ok {
+		timer.Stop()
+	}
+
+	pending, err := s.schedules.Delete(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		return ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+// armSchedules re-arms the pending messages on boot. Overdue ones wait
+// SCHEDULE_BOOT_DELAY so the clients have time to log in.
+func (s *Whatsmiau) armSchedules(ctx context.Context) {
+	pending, err := s.schedules.List(ctx)
+	if err != nil {
+		zap.L().Error("failed to load scheduled messages", zap.Error(err))
+		return
+	}
+
+	for _, scheduled := range pending {
+		s.armSchedule(scheduled, env.Env.ScheduleBootDelay)
+	}
+	zap.L().Info("scheduled messages armed", zap.Int("count", len(pending)))
+}
+
+func (s *Whatsmiau) armSchedule(scheduled models.ScheduledMessage, minDelay time.Duration) {
+	delay := max(time.Until(scheduled.At), minDelay)
+	s.scheduleTimers.Store(scheduled.ID, time.AfterFunc(delay, func() {
+		s.dispatchScheduled(scheduled)
+	}))
+}
+
+func (s *Whatsmiau) stopSchedules() {
+	s.scheduleTimers.Range(func(id string, timer *time.Timer) bool {
+		timer.Stop()
+		s.scheduleTimers.Delete(id)
+		return true
+	})
+}
+
+// dispatchScheduled claims the message before sending, so one cancelled or
+// already sent by another replica isn't sent again
+func (s *Whatsmiau) dispatchScheduled(scheduled models.ScheduledMessage) {
+	s.scheduleTimers.Delete(scheduled.ID)
+	if s.closing.Load() {
+		return // still pending, the next boot sends it
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	claimed, err := s.schedules.Delete(ctx, scheduled.ID)
+	if err != nil {
+		zap.L().Error("failed to claim scheduled message", zap.String("id", scheduled.ID), zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	var msg ScheduledMessage
+	if err := json.Unmarshal(scheduled.Message, &msg); err != nil {
+		zap.L().Error("failed to decode scheduled message", zap.String("id", scheduled.ID), zap.Error(err))
+		return
+	}
+
+	to, err := types.ParseJID(scheduled.RemoteJID)
+	if err != nil {
+		zap.L().Error("invalid scheduled message recipient", zap.String("id", scheduled.ID), zap.Error(err))
+		return
+	}
+
+	res, err := s.SendText(ctx, &SendText{
+		Text:       msg.Text,
+		InstanceID: scheduled.InstanceID,
+		RemoteJID:  &to,
+		Options:    msg.Options,
+	})
+	if err != nil {
+		zap.L().Error("failed to send scheduled message", zap.String("id", scheduled.ID), zap.String("instance", scheduled.InstanceID), zap.Int("attempt", scheduled.Attempts+1), zap.Error(err))
+		s.retryScheduled(ctx, scheduled)
+		return
+	}
+
+	zap.L().Debug("scheduled message sent", zap.String("id", scheduled.ID), zap.String("instance", scheduled.InstanceID), zap.String("messageId", res.ID))
+}
+
+// retryScheduled puts a message that failed to send back in the schedule, it
+// was claimed before sending so a cancel or another replica can take it again
+func (s *Whatsmiau) retryScheduled(ctx context.Context, scheduled models.ScheduledMessage) {
+	scheduled.Attempts++
+	if scheduled.Attempts >= scheduleMaxAttempts {
+		zap.L().Error("dropping scheduled message, no attempts left", zap.String("id", scheduled.ID), zap.String("instance", scheduled.InstanceID))
+		return
+	}
+
+	scheduled.At = time.Now().Add(backoffDelay(scheduleRetryDelay, scheduleRetryMaxDelay, scheduled.Attempts))
+	if err := s.schedules.Save(ctx, &scheduled); err != nil {
+		zap.L().Error("failed to reschedule message", zap.String("id", scheduled.ID), zap.Error(err))
+		return
+	}
+
+	s.armSchedule(scheduled, 0)
+}
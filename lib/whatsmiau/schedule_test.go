@@ -0,0 +1,101 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/schedules"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type memorySchedules map[string]models.ScheduledMessage
+
+func (m memorySchedules) Save(_ context.Context, message *models.ScheduledMessage) error {
+	m[message.ID] = *message
+	return nil
+}
+
+func (m memorySchedules) List(context.Context) ([]models.ScheduledMessage, error) {
+	result := make([]models.ScheduledMessage, 0, len(m))
+	for _, message := range m {
+		result = append(result, message)
+	}
+	return result, nil
+}
+
+func (m memorySchedules) Get(_ context.Context, id string) (*models.ScheduledMessage, error) {
+	message, ok := m[id]
+	if !ok {
+		return nil, schedules.ErrorNotFound
+	}
+	return &message, nil
+}
+
+func (m memorySchedules) Delete(_ context.Context, id string) (bool, error) {
+	_, ok := m[id]
+	delete(m, id)
+	return ok, nil
+}
+
+func TestCancelScheduled(t *testing.T) {
+	pending := memorySchedules{"job": {ID: "job", InstanceID: "owner", At: time.Now().Add(time.Hour)}}
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	s := &Whatsmiau{
+		schedules:      pending,
+		scheduleTimers: xsync.NewMap[string, *time.Timer](),
+	}
+	s.scheduleTimers.Store("job", timer)
+
+	if err := s.CancelScheduled(context.Background(), "other", "job"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Fatalf("cancel from another instance err = %v, want ErrScheduleNotFound", err)
+	}
+	if _, ok := pending["job"]; !ok {
+		t.Fatal("another instance cancelled the job")
+	}
+	if _, ok := s.scheduleTimers.Load("job"); !ok {
+		t.Fatal("another instance stopped the job timer")
+	}
+
+	if err := s.CancelScheduled(context.Background(), "owner", "job"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pending["job"]; ok {
+		t.Error("job still pending after cancel")
+	}
+
+	if err := s.CancelScheduled(context.Background(), "owner", "job"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("second cancel err = %v, want ErrScheduleNotFound", err)
+	}
+}
+
+func TestDispatchScheduledRetriesFailedSends(t *testing.T) {
+	to := types.NewJID("5511911111111", types.DefaultUserServer)
+	pending := memorySchedules{"job": {ID: "job", InstanceID: "offline", RemoteJID: to.String(), Message: []byte(`{"text":"hi"}`)}}
+	s := newTestMiau()
+	s.schedules = pending
+	s.scheduleTimers = xsync.NewMap[string, *time.Timer]()
+	t.Cleanup(s.stopSchedules)
+
+	s.dispatchScheduled(pending["job"])
+
+	retried, ok := pending["job"]
+	if !ok || retried.Attempts != 1 || !retried.At.After(time.Now()) {
+		t.Fatalf("job = %+v, %v, want it pending again for a later attempt", retried, ok)
+	}
+	if _, ok := s.scheduleTimers.Load("job"); !ok {
+		t.Error("retry not armed")
+	}
+
+	retried.Attempts = scheduleMaxAttempts - 1
+	s.stopSchedules()
+	s.dispatchScheduled(retried)
+	if _, ok := pending["job"]; ok {
+		t.Error("job still pending after its last attempt")
+	}
+}
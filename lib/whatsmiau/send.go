@@ -2,23 +2,126 @@ package whatsmiau
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"time"
 
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/lib/metrics"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
 )
 
+var (
+	ErrNotLoggedIn  = errors.New("instance is not logged in")
+	ErrInvalidAudio = errors.New("audio can't be decoded")
+)
+
+// sendMessage sends through the client once the instance rate limit allows,
+// sampling the server clock from the ack timestamp and storing the sent message
+func (s *Whatsmiau) sendMessage(ctx context.Context, instanceID string, client *whatsmeow.Client, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if err := s.acquireSend(ctx, instanceID); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	return s.sendReserved(ctx, instanceID, client, to, message, extra...)
+}
+
+// sendReserved is sendMessage for callers holding a send token from
+// acquireSendSlot
+func (s *Whatsmiau) sendReserved(ctx context.Context, instanceID string, client *whatsmeow.Client, to types.JID, message *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	start := time.Now()
+	res, err := client.SendMessage(ctx, to, message, extra...)
+	messageType, _, _ := s.parseWAMessage(message)
+	observeSend(instanceID, messageType, start, err)
+	if err != nil {
+		return res, err
+	}
+
+	s.sampleClock(instanceID, start, time.Now(), res.Timestamp)
+	s.markSent(instanceID, res.ID)
+	if client.Store.ID != nil {
+		info := types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:     to,
+				Sender:   *client.Store.ID,
+				IsFromMe: true,
+			},
+			ID:        res.ID,
+			Timestamp: res.Timestamp,
+		}
+		s.storeMessage(instanceID, info, message)
+		s.rememberMessage(instanceID, info, message)
+	}
+	return res, nil
+}
+
+func observeSend(instanceID, messageType string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	metrics.SendLatency.WithLabelValues(metrics.Instance(instanceID), messageType, result).Observe(time.Since(start).Seconds())
+}
+
+// markSent remembers messages sent through the API for SENT_MESSAGES_TTL, so
+// events about them can be tagged with origin self
+func (s *Whatsmiau) markSent(instanceID string, messageID types.MessageID) {
+	ttl := env.Env.SentMessagesTTL
+	if ttl <= 0 {
+		return
+	}
+
+	key := instanceID + ":" + messageID
+	s.recentlySent.Store(key, struct{}{})
+	time.AfterFunc(ttl, func() {
+		s.recentlySent.Delete(key)
+	})
+}
+
+func (s *Whatsmiau) isSelfSent(instanceID string, messageID types.MessageID) bool {
+	_, ok := s.recentlySent.Load(instanceID + ":" + messageID)
+	return ok
+}
+
 type SendText struct {
-	Text           string     `json:"text"`
-	InstanceID     string     `json:"instance_id"`
-	RemoteJID      *types.JID `json:"remote_jid"`
-	QuoteMessageID string     `json:"quote_message_id"`
-	QuoteMessage   string     `json:"quote_message"`
-	Participant    *types.JID `json:"participant"`
+	Text       string           `json:"text"`
+	InstanceID string           `json:"instance_id"`
+	RemoteJID  *types.JID       `json:"remote_jid"`
+	Options    *SendTextOptions `json:"options"`
+}
+
+// SendTextOptions groups everything that ends up in the message ContextInfo,
+// so quote, mentions and expiration can be combined in a single message
+type SendTextOptions struct {
+	Quote            *QuoteOptions `json:"quote"`
+	Mentions         []types.JID   `json:"mentions"`          // the text must contain @<number> for each one to render
+	MentionsEveryone bool          `json:"mentions_everyone"` // groups only, mentions every participant
+	Expiration       time.Duration `json:"expiration"`        // disappearing timer, one of 24h, 7d or 90d; 0 = instance default, negative = none
+
+	GenerateLinkPreview *bool `json:"generate_link_preview"` // nil uses the instance generateLinkPreview or GENERATE_LINK_PREVIEW
+}
+
+// QuoteOptions references the replied message. Only MessageID (the stanza id) is
+// required: without Text the reply is sent with an empty quoted body, which
+// official clients resolve from their local history when they have the message,
+// but show as an empty or "message not available" bubble when they don't
+// (new devices, web sessions without history, other integrations).
+type QuoteOptions struct {
+	MessageID   string     `json:"message_id"`
+	Text        string     `json:"text"`
+	FromMe      bool       `json:"from_me"`
+	Participant *types.JID `json:"participant"` // sender of the quoted message, required on groups when not from me
+	Chat        *types.JID `json:"chat"`        // chat of the quoted message, only when it differs from the destination
+}
+
+var ephemeralDurations = map[time.Duration]bool{
+	24 * time.Hour:      true,
+	7 * 24 * time.Hour:  true,
+	90 * 24 * time.Hour: true,
 }
 
 type SendTextResponse struct {
@@ -26,38 +129,50 @@ type SendTextResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// SendText sends a text, optionally quoting a message and mentioning users.
+// RemoteJID may be a phone number or a LID, LIDs with a known phone number are
+// sent to the phone number.
 func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResponse, error) {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	//rJid := data.RemoteJID.ToNonAD().String()
-	var extendedMessage *waE2E.ExtendedTextMessage
-	if len(data.QuoteMessage) > 0 && len(data.QuoteMessageID) > 0 {
-		extendedMessage = &waE2E.ExtendedTextMessage{
-			//ContextInfo: &waE2E.ContextInfo{ // TODO: implement quoted message
-			//	StanzaID:    &data.QuoteMessageID,
-			//	Participant: &rJid,
-			//	QuotedMessage: &waE2E.Message{
-			//		Conversation: &data.QuoteMessage,
-			//		ProtocolMessage: &waE2E.ProtocolMessage{
-			//			Key: &waCommon.MessageKey{
-			//				RemoteJID:   &rJid,
-			//				FromMe:      &[]bool{true}[0],
-			//				ID:          &data.QuoteMessageID,
-			//				Participant: nil,
-			//			},
-			//		},
-			//	},
-			//},
+	if !client.IsLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+
+	// fetched before taking a slot, a slow page shouldn't hold up event handling
+	var preview *waE2E.ExtendedTextMessage
+	if s.shouldGenerateLinkPreview(data.InstanceID, data.Options) {
+		preview = s.textLinkPreview(ctx, data.InstanceID, client, data.Text)
+	}
+
+	release, err := s.acquireSendSlot(ctx, data.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	to := s.resolveRecipient(ctx, data.InstanceID, *data.RemoteJID)
+	contextInfo, err := s.buildContextInfo(ctx, client, data.InstanceID, to, data.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &waE2E.Message{
+		Conversation: &data.Text,
+	}
+	if contextInfo != nil || preview != nil {
+		if preview == nil {
+			preview = &waE2E.ExtendedTextMessage{}
 		}
+		preview.Text = &data.Text
+		preview.ContextInfo = contextInfo
+		message = &waE2E.Message{ExtendedTextMessage: preview}
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
-		Conversation:        &data.Text,
-		ExtendedTextMessage: extendedMessage,
-	})
+	res, err := s.sendReserved(ctx, data.InstanceID, client, to, message)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +183,175 @@ func (s *Whatsmiau) SendText(ctx context.Context, data *SendText) (*SendTextResp
 	}, nil
 }
 
+// acquireSendSlot shares the handler slots with sends so bursts of sends don't
+// starve event handling, the returned func releases the slot. The send token of
+// the instance is reserved first, waiting on the rate limit must not hold a
+// slot; send with sendReserved afterwards.
+func (s *Whatsmiau) acquireSendSlot(ctx context.Context, instanceID string) (func(), error) {
+	if err := s.acquireSend(ctx, instanceID); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.handlerSemaphore <- struct{}{}:
+		return func() { <-s.handlerSemaphore }, nil
+	case <-ctx.Done():
+		s.releaseSend(instanceID)
+		return nil, ctx.Err()
+	}
+}
+
+// resolveRecipient maps a LID to its phone number through GetJidLid, other
+// JIDs are returned as they are
+func (s *Whatsmiau) resolveRecipient(ctx context.Context, id string, to types.JID) types.JID {
+	if to.Server != types.HiddenUserServer {
+		return to
+	}
+
+	jid, _ := s.GetJidLid(ctx, id, to)
+	resolved, err := types.ParseJID(jid)
+	if err != nil || resolved.Server != types.DefaultUserServer {
+		return to
+	}
+
+	return resolved
+}
+
+// buildContextInfo validates the options against the chat and merges them in one
+// ContextInfo, returning nil when there is nothing to add
+func (s *Whatsmiau) buildContextInfo(ctx context.Context, client *whatsmeow.Client, instanceID string, chat types.JID, opts *SendTextOptions) (*waE2E.ContextInfo, error) {
+	if opts == nil {
+		opts = &SendTextOptions{}
+	}
+
+	var (
+		info  waE2E.ContextInfo
+		empty = true
+		group *types.GroupInfo
+	)
+
+	getGroup := func() (*types.GroupInfo, error) {
+		if group != nil {
+			return group, nil
+		}
+		var err error
+		group, err = client.GetGroupInfo(ctx, chat)
+		return group, err
+	}
+
+	if opts.Quote != nil {
+		if len(opts.Quote.MessageID) <= 0 {
+			return nil, fmt.Errorf("quote requires message_id")
+		}
+
+		quotedChat := chat
+		if opts.Quote.Chat != nil {
+			quotedChat = opts.Quote.Chat.ToNonAD()
+		}
+
+		participant := quotedChat
+		switch {
+		case opts.Quote.FromMe:
+			if client.Store == nil || client.Store.ID == nil {
+				return nil, fmt.Errorf("device is not connected")
+			}
+			participant = client.Store.ID.ToNonAD()
+		case opts.Quote.Participant != nil:
+			participant = opts.Quote.Participant.ToNonAD()
+		case quotedChat.Server == types.GroupServer:
+			return nil, fmt.Errorf("participant is required to quote group messages")
+		}
+
+		info.StanzaID = proto.String(opts.Quote.MessageID)
+		info.Participant = proto.String(participant.String())
+		if quotedChat != chat {
+			info.RemoteJID = proto.String(quotedChat.String())
+		}
+		info.QuotedMessage = &waE2E.Message{
+			Conversation: proto.String(opts.Quote.Text),
+		}
+		empty = false
+	}
+
+	mentions := make(map[string]bool)
+	for _, jid := range opts.Mentions {
+		mentions[jid.ToNonAD().String()] = true
+	}
+
+	if opts.MentionsEveryone {
+		if chat.Server != types.GroupServer {
+			return nil, fmt.Errorf("mentions_everyone is only available on groups")
+		}
+
+		groupInfo, err := getGroup()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group participants: %w", err)
+		}
+		for _, participant := range groupInfo.Participants {
+			mentions[participant.JID.ToNonAD().String()] = true
+		}
+	}
+
+	for jid := range mentions {
+		info.MentionedJID = append(info.MentionedJID, jid)
+		empty = false
+	}
+
+	if opts.Expiration == 0 {
+		if defaultInfo := s.defaultExpirationContext(instanceID, chat); defaultInfo != nil {
+			info.Expiration = defaultInfo.Expiration
+			empty = false
+		}
+	} else if opts.Expiration > 0 {
+		if !ephemeralDurations[opts.Expiration] {
+			return nil, fmt.Errorf("invalid expiration %s, must be 24h, 168h or 2160h", opts.Expiration)
+		}
+
+		// only the group timer is known locally, on private chats it's up to the caller
+		if chat.Server == types.GroupServer {
+			groupInfo, err := getGroup()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get group ephemeral setting: %w", err)
+			}
+			if !groupInfo.IsEphemeral || time.Duration(groupInfo.DisappearingTimer)*time.Second != opts.Expiration {
+				return nil, fmt.Errorf("group disappearing messages are not set to %s", opts.Expiration)
+			}
+		}
+
+		info.Expiration = proto.Uint32(uint32(opts.Expiration / time.Second))
+		empty = false
+	}
+
+	if empty {
+		return nil, nil
+	}
+
+	return &info, nil
+}
+
+// defaultExpirationContext returns the ContextInfo for the instance default
+// disappearing timer, or nil when there is none. Groups are skipped, their timer
+// is shared by every member and set by the admins.
+func (s *Whatsmiau) defaultExpirationContext(instanceID string, chat types.JID) *waE2E.ContextInfo {
+	if chat.Server == types.GroupServer {
+		return nil
+	}
+
+	instanceFound := s.getInstanceCached(instanceID)
+	if instanceFound == nil || instanceFound.EphemeralExpiration == nil || *instanceFound.EphemeralExpiration == 0 {
+		return nil
+	}
+
+	return &waE2E.ContextInfo{
+		Expiration: proto.Uint32(*instanceFound.EphemeralExpiration),
+	}
+}
+
 type SendAudioRequest struct {
 	AudioURL       string     `json:"text"`
+	Data           []byte     `json:"data"`     // raw audio, takes precedence over AudioURL
+	Mimetype       string     `json:"mimetype"` // only used when PTT is false, detected when empty
+	PTT            *bool      `json:"ptt"`      // nil = true, sends a voice note
 	InstanceID     string     `json:"instance_id"`
 	RemoteJID      *types.JID `json:"remote_jid"`
 	QuoteMessageID string     `json:"quote_message_id"`
@@ -77,23 +359,46 @@ type SendAudioRequest struct {
 	Participant    *types.JID `json:"participant"`
 }
 
+// quote returns the replied message, nil when the audio isn't a reply
+func (data *SendAudioRequest) quote() *QuoteOptions {
+	if data.QuoteMessageID == "" {
+		return nil
+	}
+
+	return &QuoteOptions{
+		MessageID:   data.QuoteMessageID,
+		Text:        data.QuoteMessage,
+		Participant: data.Participant,
+	}
+}
+
 type SendAudioResponse struct {
 	ID        string    `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// SendAudio sends a voice note by default: any format ffmpeg decodes is
+// converted to OGG/Opus, with the duration and the 64 bars waveform shown by
+// the clients. With PTT false the file is sent as it is, as a regular audio.
 func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*SendAudioResponse, error) {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resAudio, err := s.getCtx(ctx, data.AudioURL)
+	if data.PTT != nil && !*data.PTT {
+		return s.sendPlainAudio(ctx, data)
+	}
+
+	dataBytes, err := s.readMediaInput(ctx, data.InstanceID, &MediaInput{
+		Data: data.Data,
+		URL:  data.AudioURL,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	dataBytes, err := io.ReadAll(resAudio.Body)
+	contextInfo, err := s.buildContextInfo(ctx, client, data.InstanceID, *data.RemoteJID, &SendTextOptions{Quote: data.quote()})
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +426,8 @@ func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*Sen
 		Waveform:      waveForm,
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	audio.ContextInfo = contextInfo
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, &waE2E.Message{
 		AudioMessage: &audio,
 	})
 	if err != nil {
@@ -134,6 +440,41 @@ func (s *Whatsmiau) SendAudio(ctx context.Context, data *SendAudioRequest) (*Sen
 	}, nil
 }
 
+func (s *Whatsmiau) sendPlainAudio(ctx context.Context, data *SendAudioRequest) (*SendAudioResponse, error) {
+	media := MediaInput{
+		Data:     data.Data,
+		URL:      data.AudioURL,
+		Mimetype: data.Mimetype,
+	}
+	content, err := s.readMediaInput(ctx, data.InstanceID, &media)
+	if err != nil {
+		return nil, err
+	}
+
+	if media.Mimetype == "" {
+		media.Mimetype, _ = extractMimetype(content, "")
+	}
+	if mediaType, err := mediaTypeByMimetype(media.Mimetype); err != nil || mediaType != whatsmeow.MediaAudio {
+		return nil, fmt.Errorf("%w: %s is not an audio", ErrUnsupportedMediaType, media.Mimetype)
+	}
+
+	media.Data = content
+	res, err := s.SendMedia(ctx, &SendMediaRequest{
+		InstanceID: data.InstanceID,
+		RemoteJID:  data.RemoteJID,
+		Media:      media,
+		Quote:      data.quote(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendAudioResponse{
+		ID:        res.ID,
+		CreatedAt: res.CreatedAt,
+	}, nil
+}
+
 type SendDocumentRequest struct {
 	InstanceID string     `json:"instance_id"`
 	MediaURL   string     `json:"media_url"`
@@ -154,12 +495,14 @@ func (s *Whatsmiau) SendDocument(ctx context.Context, data *SendDocumentRequest)
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resMedia, err := s.getCtx(ctx, data.MediaURL)
+	resMedia, err := s.getCtx(ctx, data.InstanceID, data.MediaURL)
 	if err != nil {
 		return nil, err
 	}
 
-	dataBytes, err := io.ReadAll(resMedia.Body)
+	defer resMedia.Body.Close()
+
+	dataBytes, err := readMediaBody(resMedia.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +524,8 @@ func (s *Whatsmiau) SendDocument(ctx context.Context, data *SendDocumentRequest)
 		Caption:       proto.String(data.Caption),
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	doc.ContextInfo = s.defaultExpirationContext(data.InstanceID, *data.RemoteJID)
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, &waE2E.Message{
 		DocumentMessage: &doc,
 	})
 	if err != nil {
@@ -212,12 +556,14 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	resMedia, err := s.getCtx(ctx, data.MediaURL)
+	resMedia, err := s.getCtx(ctx, data.InstanceID, data.MediaURL)
 	if err != nil {
 		return nil, err
 	}
 
-	dataBytes, err := io.ReadAll(resMedia.Body)
+	defer resMedia.Body.Close()
+
+	dataBytes, err := readMediaBody(resMedia.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +588,8 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 		DirectPath:    proto.String(uploaded.DirectPath),
 	}
 
-	res, err := client.SendMessage(ctx, *data.RemoteJID, &waE2E.Message{
+	doc.ContextInfo = s.defaultExpirationContext(data.InstanceID, *data.RemoteJID)
+	res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, &waE2E.Message{
 		ImageMessage: &doc,
 	})
 	if err != nil {
@@ -256,11 +603,13 @@ func (s *Whatsmiau) SendImage(ctx context.Context, data *SendImageRequest) (*Sen
 }
 
 type SendReactionRequest struct {
-	InstanceID string     `json:"instance_id"`
-	Reaction   string     `json:"reaction"`
-	RemoteJID  *types.JID `json:"remote_jid"`
-	MessageID  string     `json:"message_id"`
-	FromMe     bool       `json:"from_me"`
+	InstanceID  string                `json:"instance_id"`
+	Reaction    string                `json:"reaction"`
+	RemoteJID   *types.JID            `json:"remote_jid"`
+	MessageID   string                `json:"message_id"`
+	FromMe      bool                  `json:"from_me"`
+	Participant *types.JID            `json:"participant"` // sender of the reacted message, required on groups and status
+	ServerID    types.MessageServerID `json:"server_id"`   // required on newsletters, which address messages by server id
 }
 
 type SendReactionResponse struct {
@@ -268,16 +617,15 @@ type SendReactionResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// SendReaction reacts to a message, building the envelope expected by the chat
+// server type: users, groups, status broadcast or newsletters. An empty
+// reaction removes the previous one.
 func (s *Whatsmiau) SendReaction(ctx context.Context, data *SendReactionRequest) (*SendReactionResponse, error) {
 	client, ok := s.clients.Load(data.InstanceID)
 	if !ok {
 		return nil, whatsmeow.ErrClientIsNil
 	}
 
-	if len(data.Reaction) <= 0 {
-		return nil, fmt.Errorf("empty reaction, len: %d", len(data.Reaction))
-	}
-
 	if len(data.MessageID) <= 0 {
 		return nil, fmt.Errorf("invalid message_id")
 	}
@@ -286,19 +634,96 @@ func (s *Whatsmiau) SendReaction(ctx context.Context, data *SendReactionRequest)
 		return nil, fmt.Errorf("device is not connected")
 	}
 
-	sender := data.RemoteJID
-	if data.FromMe {
-		sender = client.Store.ID
+	if data.RemoteJID.Server == types.NewsletterServer {
+		return s.sendNewsletterReaction(ctx, client, data)
+	}
+
+	chat, doc, err := s.buildReaction(ctx, client, data)
+	if err != nil {
+		return nil, err
 	}
 
-	doc := client.BuildReaction(*data.RemoteJID, *sender, data.MessageID, data.Reaction)
-	res, err := client.SendMessage(ctx, *data.RemoteJID, doc)
+	res, err := s.sendMessage(ctx, data.InstanceID, client, chat, doc)
 	if err != nil {
 		return nil, err
 	}
 
+	s.emitReaction(ctx, data.InstanceID, chat, doc.GetReactionMessage())
 	return &SendReactionResponse{
 		ID:        res.ID,
 		CreatedAt: res.Timestamp,
 	}, nil
 }
+
+// buildReaction builds the reaction envelope of users, groups and the status
+// broadcast, groups and status address the reacted message by its sender
+func (s *Whatsmiau) buildReaction(ctx context.Context, client *whatsmeow.Client, data *SendReactionRequest) (types.JID, *waE2E.Message, error) {
+	switch data.RemoteJID.Server {
+	case types.BroadcastServer:
+		if *data.RemoteJID != types.StatusBroadcastJID {
+			return types.EmptyJID, nil, fmt.Errorf("reactions on broadcast lists are not supported")
+		}
+		fallthrough
+	case types.GroupServer:
+		if !data.FromMe && data.Participant == nil {
+			return types.EmptyJID, nil, fmt.Errorf("participant is required to react on %s messages", data.RemoteJID.Server)
+		}
+	}
+
+	chat := s.resolveRecipient(ctx, data.InstanceID, *data.RemoteJID)
+	sender := chat
+	if data.Participant != nil {
+		sender = s.resolveRecipient(ctx, data.InstanceID, *data.Participant)
+	}
+	if data.FromMe {
+		sender = *client.Store.ID
+	}
+
+	return chat, client.BuildReaction(chat, sender, data.MessageID, data.Reaction), nil
+}
+
+func (s *Whatsmiau) sendNewsletterReaction(ctx context.Context, client *whatsmeow.Client, data *SendReactionRequest) (*SendReactionResponse, error) {
+	if data.ServerID <= 0 {
+		return nil, fmt.Errorf("server_id is required to react on newsletter messages")
+	}
+
+	reactionID := client.GenerateMessageID()
+	if err := client.NewsletterSendReaction(ctx, *data.RemoteJID, data.ServerID, data.Reaction, reactionID); err != nil {
+		return nil, err
+	}
+
+	return &SendReactionResponse{
+		ID:        reactionID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// emitReaction tells consumers about reactions sent through the API, which
+// whatsmeow doesn't echo back as message events
+func (s *Whatsmiau) emitReaction(ctx context.Context, id string, chat types.JID, reaction *waE2E.ReactionMessage) {
+	instance := s.getInstanceCached(id)
+	if instance == nil || !eventMapOf(instance)["MESSAGES_REACTION"] {
+		return
+	}
+
+	jid, lid := s.GetJidLid(ctx, id, chat)
+	key := reaction.GetKey()
+	s.emit(&WookEvent[WookMessageReactionData]{
+		Instance: instance.ID,
+		Data: &WookMessageReactionData{
+			Key: &WookKey{
+				RemoteJid:   jid,
+				RemoteLid:   lid,
+				FromMe:      key.GetFromMe(),
+				Id:          key.GetID(),
+				Participant: key.GetParticipant(),
+			},
+			Reaction:   reaction.GetText(),
+			Removed:    reaction.GetText() == "",
+			InstanceId: instance.ID,
+			Origin:     OriginSelf,
+		},
+		DateTime: time.Now(),
+		Event:    WookMessagesReaction,
+	}, instance)
+}
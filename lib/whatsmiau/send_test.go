@@ -0,0 +1,193 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestBuildReaction(t *testing.T) {
+	contact := types.NewJID("5511911111111", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+	broadcast := types.NewJID("1234567890", types.BroadcastServer)
+
+	tests := []struct {
+		name            string
+		request         SendReactionRequest
+		wantErr         bool
+		wantChat        types.JID
+		wantFromMe      bool
+		wantParticipant string
+	}{
+		{
+			name:     "user",
+			request:  SendReactionRequest{RemoteJID: &contact, MessageID: "A1", Reaction: "👍"},
+			wantChat: contact,
+		},
+		{
+			name:       "user from me",
+			request:    SendReactionRequest{RemoteJID: &contact, MessageID: "A1", Reaction: "👍", FromMe: true},
+			wantChat:   contact,
+			wantFromMe: true,
+		},
+		{
+			name:            "group",
+			request:         SendReactionRequest{RemoteJID: &group, MessageID: "A1", Reaction: "👍", Participant: &contact},
+			wantChat:        group,
+			wantParticipant: contact.String(),
+		},
+		{
+			name:    "group without participant",
+			request: SendReactionRequest{RemoteJID: &group, MessageID: "A1", Reaction: "👍"},
+			wantErr: true,
+		},
+		{
+			name:            "status",
+			request:         SendReactionRequest{RemoteJID: &types.StatusBroadcastJID, MessageID: "A1", Reaction: "👍", Participant: &contact},
+			wantChat:        types.StatusBroadcastJID,
+			wantParticipant: contact.String(),
+		},
+		{
+			name:    "status without participant",
+			request: SendReactionRequest{RemoteJID: &types.StatusBroadcastJID, MessageID: "A1", Reaction: "👍"},
+			wantErr: true,
+		},
+		{
+			name:    "broadcast list",
+			request: SendReactionRequest{RemoteJID: &broadcast, MessageID: "A1", Reaction: "👍", Participant: &contact},
+			wantErr: true,
+		},
+	}
+
+	s := newTestMiau()
+	client := newTestClient(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, msg, err := s.buildReaction(context.Background(), client, &tt.request)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if chat != tt.wantChat {
+				t.Errorf("chat = %s, want %s", chat, tt.wantChat)
+			}
+
+			reaction := msg.GetReactionMessage()
+			if reaction.GetText() != tt.request.Reaction {
+				t.Errorf("text = %q, want %q", reaction.GetText(), tt.request.Reaction)
+			}
+
+			key := reaction.GetKey()
+			if key.GetID() != tt.request.MessageID {
+				t.Errorf("key id = %q, want %q", key.GetID(), tt.request.MessageID)
+			}
+			if key.GetRemoteJID() != tt.wantChat.String() {
+				t.Errorf("key remote jid = %q, want %q", key.GetRemoteJID(), tt.wantChat)
+			}
+			if key.GetFromMe() != tt.wantFromMe {
+				t.Errorf("key from me = %v, want %v", key.GetFromMe(), tt.wantFromMe)
+			}
+			if key.GetParticipant() != tt.wantParticipant {
+				t.Errorf("key participant = %q, want %q", key.GetParticipant(), tt.wantParticipant)
+			}
+		})
+	}
+}
+
+func TestSendNewsletterReactionRequiresServerID(t *testing.T) {
+	newsletter := types.NewJID("120363000000000001", types.NewsletterServer)
+	_, err := newTestMiau().sendNewsletterReaction(context.Background(), newTestClient(t), &SendReactionRequest{
+		RemoteJID: &newsletter,
+		MessageID: "A1",
+		Reaction:  "👍",
+	})
+	if err == nil {
+		t.Fatal("expected an error without server_id")
+	}
+}
+
+func TestBuildReactionRemoveAndLid(t *testing.T) {
+	pn := types.NewJID("5511922222222", types.DefaultUserServer)
+	lid := types.NewJID("123456789012345", types.HiddenUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	s := newTestMiau()
+	client := newTestClient(t)
+	s.clients.Store("a", client)
+	s.storeLidMapping(lidMappingKey("a", lid), &lidMapping{jid: pn.String()}, time.Minute)
+
+	chat, msg, err := s.buildReaction(context.Background(), client, &SendReactionRequest{
+		InstanceID: "a",
+		RemoteJID:  &lid,
+		MessageID:  "A1",
+		Reaction:   "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chat != pn {
+		t.Errorf("chat = %s, want the lid resolved to %s", chat, pn)
+	}
+
+	reaction := msg.GetReactionMessage()
+	if reaction.Text == nil || reaction.GetText() != "" {
+		t.Errorf("text = %v, want set and empty to remove the reaction", reaction.Text)
+	}
+	if reaction.GetKey().GetRemoteJID() != pn.String() || reaction.GetKey().GetID() != "A1" {
+		t.Errorf("key = %v, want %s/A1", reaction.GetKey(), pn)
+	}
+
+	_, msg, err = s.buildReaction(context.Background(), client, &SendReactionRequest{
+		InstanceID:  "a",
+		RemoteJID:   &group,
+		MessageID:   "A2",
+		Reaction:    "❤️",
+		Participant: &lid,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if participant := msg.GetReactionMessage().GetKey().GetParticipant(); participant != pn.String() {
+		t.Errorf("participant = %q, want the lid resolved to %s", participant, pn)
+	}
+}
+
+func TestAudioQuote(t *testing.T) {
+	if quote := (&SendAudioRequest{QuoteMessage: "hi"}).quote(); quote != nil {
+		t.Errorf("quote without id = %+v, want nil", quote)
+	}
+
+	s := newTestMiau()
+	s.instanceCache = xsync.NewMap[string, models.Instance]()
+	s.instanceCache.Store("instance", models.Instance{ID: "instance"})
+
+	chat := types.NewJID("5511911111111", types.DefaultUserServer)
+	data := &SendAudioRequest{QuoteMessageID: "A1", QuoteMessage: "hi"}
+	info, err := s.buildContextInfo(context.Background(), newTestClient(t), "instance", chat, &SendTextOptions{Quote: data.quote()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GetStanzaID() != "A1" || info.GetQuotedMessage().GetConversation() != "hi" || info.GetParticipant() != chat.String() {
+		t.Errorf("context = %v, want the quoted message", info)
+	}
+}
+
+func TestConvertAudioWithoutFFmpeg(t *testing.T) {
+	t.Setenv("PATH", "")
+
+	// a missing decoder is a server failure, not invalid audio
+	if _, _, _, err := convertAudio([]byte("audio"), 64); err == nil || errors.Is(err, ErrInvalidAudio) {
+		t.Errorf("err = %v, want a failure other than ErrInvalidAudio", err)
+	}
+}
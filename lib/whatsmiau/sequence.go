@@ -0,0 +1,81 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+type SendSequenceRequest struct {
+	InstanceID string           `json:"instance_id"`
+	RemoteJID  *types.JID       `json:"remote_jid"`
+	Messages   []*waE2E.Message `json:"messages"`
+	Delay      time.Duration    `json:"delay"`  // wait before each message after the first
+	Typing     bool             `json:"typing"` // show composing while waiting
+}
+
+type SendSequenceResult struct {
+	ID        string    `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// SendSequence sends the messages in order waiting Delay between them. A failed
+// message doesn't stop the sequence, a cancelled ctx fails the remaining ones.
+// Messages go through sendMessage, so send limits apply on top of the delay.
+func (s *Whatsmiau) SendSequence(ctx context.Context, data *SendSequenceRequest) ([]SendSequenceResult, error) {
+	client, ok := s.clients.Load(data.InstanceID)
+	if !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	results := make([]SendSequenceResult, len(data.Messages))
+	for i, message := range data.Messages {
+		if i > 0 && data.Delay > 0 {
+			if err := s.waitSequence(ctx, client, *data.RemoteJID, data.Delay, data.Typing); err != nil {
+				for j := i; j < len(results); j++ {
+					results[j].Error = err.Error()
+				}
+				return results, nil
+			}
+		}
+
+		res, err := s.sendMessage(ctx, data.InstanceID, client, *data.RemoteJID, message)
+		if err != nil {
+			zap.L().Warn("failed to send sequence message", zap.String("instance", data.InstanceID), zap.Int("index", i), zap.Error(err))
+			results[i].Error = err.Error()
+			continue
+		}
+
+		results[i] = SendSequenceResult{
+			ID:        res.ID,
+			CreatedAt: res.Timestamp,
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Whatsmiau) waitSequence(ctx context.Context, client *whatsmeow.Client, to types.JID, delay time.Duration, typing bool) error {
+	if typing {
+		if err := client.SendChatPresence(ctx, to, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil {
+			zap.L().Warn("failed to send composing presence", zap.Error(err))
+		}
+		defer func() {
+			if err := client.SendChatPresence(context.Background(), to, types.ChatPresencePaused, types.ChatPresenceMediaText); err != nil {
+				zap.L().Warn("failed to send paused presence", zap.Error(err))
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
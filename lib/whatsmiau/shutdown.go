@@ -0,0 +1,86 @@
+package whatsmiau
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mau.fi/whatsmeow"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+var ErrShuttingDown = errors.New("whatsmiau is shutting down")
+
+// Shutdown stops handling new events, disconnects every client (waiting their
+// in-flight handlers) and delivers the events already queued in the emitter
+// until ctx is done. Events left in the queue at the deadline are lost, the
+// webhook retries still pending are moved to the dead letter queue. Only the
+// first call runs, later calls return its result.
+func (s *Whatsmiau) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		s.shutdownErr = s.shutdown(ctx)
+	})
+
+	return s.shutdownErr
+}
+
+func (s *Whatsmiau) shutdown(ctx context.Context) error {
+	s.closing.Store(true)
+	s.stopSchedules() // still pending, the next boot re-arms them
+
+	var wg sync.WaitGroup
+	s.clients.Range(func(id string, _ *whatsmeow.Client) bool {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.cancelDisconnectTimer(id)
+			if err := s.Disconnect(id); err != nil {
+				zap.L().Error("failed to disconnect on shutdown", zap.String("id", id), zap.Error(err))
+			}
+		}()
+		return true
+	})
+
+	disconnected := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(disconnected)
+	}()
+
+	select {
+	case <-disconnected:
+	case <-ctx.Done():
+		zap.L().Warn("timeout disconnecting clients on shutdown")
+	}
+
+	// emit holds the read lock while enqueueing, nothing is sent after close.
+	// An emit blocked on a full queue holds it until the emitter makes room,
+	// so the close waits in the background instead of past the deadline.
+	closed := make(chan struct{})
+	go func() {
+		s.emitMu.Lock()
+		close(s.emitter)
+		s.emitMu.Unlock()
+		close(closed)
+	}()
+
+	err := s.drainEmitter(ctx, closed)
+	s.flushRetries()
+	return err
+}
+
+func (s *Whatsmiau) drainEmitter(ctx context.Context, closed <-chan struct{}) error {
+	select {
+	case <-closed:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to close emitter, %d events left: %w", len(s.emitter), ctx.Err())
+	}
+
+	select {
+	case <-s.emitterDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain emitter, %d events left: %w", len(s.emitter), ctx.Err())
+	}
+}
@@ -0,0 +1,96 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+type InstanceStatsSummary struct {
+	Total          int `json:"total"`
+	Connected      int `json:"connected"`
+	Connecting     int `json:"connecting"`
+	QrCode         int `json:"qrCode"`
+	Pairing        int `json:"pairing"`
+	Closed         int `json:"closed"`
+	TemporaryBlock int `json:"temporaryBlock"`
+}
+
+// InstanceStats counts loaded clients by status in a single pass. Instances
+// without a loaded client aren't counted, ask the repository for those.
+func (s *Whatsmiau) InstanceStats() InstanceStatsSummary {
+	var summary InstanceStatsSummary
+	s.clients.Range(func(id string, client *whatsmeow.Client) bool {
+		summary.Total++
+		switch s.clientStatus(id, client) {
+		case Connected:
+			summary.Connected++
+		case Connecting:
+			summary.Connecting++
+		case QrCode:
+			summary.QrCode++
+		case Pairing:
+			summary.Pairing++
+		case TemporaryBlock:
+			summary.TemporaryBlock++
+		default:
+			summary.Closed++
+		}
+		return true
+	})
+
+	return summary
+}
+
+func (s *Whatsmiau) instanceStatsByStatus() map[string]int {
+	summary := s.InstanceStats()
+	return map[string]int{
+		Connected:      summary.Connected,
+		Connecting:     summary.Connecting,
+		QrCode:         summary.QrCode,
+		Pairing:        summary.Pairing,
+		Closed:         summary.Closed,
+		TemporaryBlock: summary.TemporaryBlock,
+	}
+}
+
+type InstanceStatus struct {
+	ID        string `json:"id"`
+	RemoteJID string `json:"remoteJid,omitempty"`
+	Status    Status `json:"status"`
+}
+
+// ListInstances returns every instance of the repository with its connection
+// status. The status comes from the loaded clients and the listed instances,
+// so the whole list costs a single repository call.
+func (s *Whatsmiau) ListInstances(ctx context.Context) ([]InstanceStatus, error) {
+	instanceList, err := s.repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]InstanceStatus, 0, len(instanceList))
+	for _, inst := range instanceList {
+		row := InstanceStatus{
+			ID:        inst.ID,
+			RemoteJID: inst.RemoteJID,
+			Status:    Closed,
+		}
+
+		if client, ok := s.clients.Load(inst.ID); ok {
+			row.Status = s.clientStatus(inst.ID, client)
+			if client.Store != nil && client.Store.ID != nil {
+				row.RemoteJID = client.Store.ID.String()
+			}
+		} else if inst.Block.Active(now) {
+			// banned accounts are logged out, the block is only kept on the instance
+			row.Status = Status(inst.Block.Status)
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
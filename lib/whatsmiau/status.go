@@ -0,0 +1,152 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrUnsupportedStatus = errors.New("unsupported status content")
+
+const (
+	defaultStatusBackground = 0xff000000
+	defaultStatusTextColor  = 0xffffffff
+)
+
+// StatusContent is a text status, with optional colors and font, or an image
+// or video status with Text as the caption
+type StatusContent struct {
+	Text            string      `json:"text"`
+	BackgroundColor string      `json:"background_color"` // #RRGGBB or #AARRGGBB, text only
+	TextColor       string      `json:"text_color"`       // #RRGGBB or #AARRGGBB, text only
+	Font            *int32      `json:"font"`             // waE2E.ExtendedTextMessage_FontType, text only
+	Media           *MediaInput `json:"media"`
+}
+
+// SendStatus posts to the account status (status@broadcast) and returns the
+// status message id, views arrive as read receipts of it. The audience is the
+// account status privacy (my contacts, my contacts except or only share with)
+// set on the phone: the WhatsApp library resolves the recipients from it on
+// every status send and takes no list of its own, so there is no per status
+// audience.
+func (s *Whatsmiau) SendStatus(ctx context.Context, id string, content StatusContent) (string, error) {
+	client, ok := s.clients.Load(id)
+	if !ok {
+		return "", whatsmeow.ErrClientIsNil
+	}
+
+	if !client.IsLoggedIn() {
+		return "", ErrNotLoggedIn
+	}
+
+	if content.Media == nil {
+		message, err := textStatusMessage(content)
+		if err != nil {
+			return "", err
+		}
+
+		res, err := s.sendMessage(ctx, id, client, types.StatusBroadcastJID, message)
+		if err != nil {
+			return "", err
+		}
+
+		return res.ID, nil
+	}
+
+	media, fileContent, mimetype, mediaType, err := s.statusMedia(ctx, id, content)
+	if err != nil {
+		return "", err
+	}
+
+	uploaded, err := client.Upload(ctx, fileContent, mediaType)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := s.sendMessage(ctx, id, client, types.StatusBroadcastJID, buildMediaMessage(mediaType, &uploaded, mimetype, media))
+	if err != nil {
+		return "", err
+	}
+
+	s.archiveSentMedia(id, res.ID, mimetype, fileContent)
+	return res.ID, nil
+}
+
+// statusMedia reads the media of an image or video status, captioned with the
+// status text
+func (s *Whatsmiau) statusMedia(ctx context.Context, id string, content StatusContent) (*MediaInput, []byte, string, whatsmeow.MediaType, error) {
+	if len(content.BackgroundColor) > 0 || len(content.TextColor) > 0 || content.Font != nil {
+		return nil, nil, "", "", fmt.Errorf("%w: colors and font only apply to text statuses", ErrUnsupportedStatus)
+	}
+
+	media := *content.Media
+	media.Caption = content.Text
+
+	fileContent, mimetype, mediaType, err := s.prepareMedia(ctx, id, &media)
+	if err != nil {
+		return nil, nil, "", "", err
+	}
+
+	if mediaType != whatsmeow.MediaImage && mediaType != whatsmeow.MediaVideo {
+		return nil, nil, "", "", fmt.Errorf("%w: %s, only images and videos are supported", ErrUnsupportedStatus, mimetype)
+	}
+
+	return &media, fileContent, mimetype, mediaType, nil
+}
+
+func textStatusMessage(content StatusContent) (*waE2E.Message, error) {
+	if len(strings.TrimSpace(content.Text)) <= 0 {
+		return nil, fmt.Errorf("%w: text or media is required", ErrUnsupportedStatus)
+	}
+
+	background, err := parseStatusColor(content.BackgroundColor, defaultStatusBackground)
+	if err != nil {
+		return nil, err
+	}
+
+	textColor, err := parseStatusColor(content.TextColor, defaultStatusTextColor)
+	if err != nil {
+		return nil, err
+	}
+
+	font := waE2E.ExtendedTextMessage_SYSTEM
+	if content.Font != nil {
+		if _, ok := waE2E.ExtendedTextMessage_FontType_name[*content.Font]; !ok {
+			return nil, fmt.Errorf("%w: unknown font %d", ErrUnsupportedStatus, *content.Font)
+		}
+		font = waE2E.ExtendedTextMessage_FontType(*content.Font)
+	}
+
+	return &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text:           proto.String(content.Text),
+		BackgroundArgb: proto.Uint32(background),
+		TextArgb:       proto.Uint32(textColor),
+		Font:           font.Enum(),
+	}}, nil
+}
+
+// parseStatusColor parses #RRGGBB, opaque, or #AARRGGBB into ARGB
+func parseStatusColor(color string, fallback uint32) (uint32, error) {
+	if len(color) <= 0 {
+		return fallback, nil
+	}
+
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+
+	argb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return 0, fmt.Errorf("%w: invalid color %q, expected #RRGGBB or #AARRGGBB", ErrUnsupportedStatus, color)
+	}
+
+	return uint32(argb), nil
+}
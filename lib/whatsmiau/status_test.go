@@ -0,0 +1,92 @@
+package whatsmiau
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+)
+
+func TestStatusMedia(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestMiau()
+	tests := []struct {
+		name     string
+		content  StatusContent
+		wantType whatsmeow.MediaType
+		wantErr  error
+	}{
+		{
+			name:     "image",
+			content:  StatusContent{Text: "caption", Media: &MediaInput{Data: img.Bytes()}},
+			wantType: whatsmeow.MediaImage,
+		},
+		{
+			name:     "video",
+			content:  StatusContent{Text: "caption", Media: &MediaInput{Data: []byte("video"), Mimetype: "video/mp4"}},
+			wantType: whatsmeow.MediaVideo,
+		},
+		{
+			name:    "audio",
+			content: StatusContent{Media: &MediaInput{Data: []byte("audio"), Mimetype: "audio/ogg"}},
+			wantErr: ErrUnsupportedStatus,
+		},
+		{
+			name:    "colors on media",
+			content: StatusContent{BackgroundColor: "#123456", Media: &MediaInput{Data: img.Bytes()}},
+			wantErr: ErrUnsupportedStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media, _, mimetype, mediaType, err := s.statusMedia(context.Background(), "instance", tt.content)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mediaType != tt.wantType {
+				t.Errorf("media type = %q, want %q", mediaType, tt.wantType)
+			}
+
+			message := buildMediaMessage(mediaType, &whatsmeow.UploadResponse{}, mimetype, media)
+			if caption := message.GetImageMessage().GetCaption() + message.GetVideoMessage().GetCaption(); caption != "caption" {
+				t.Errorf("message = %v, want the text as caption", message)
+			}
+		})
+	}
+}
+
+func TestTextStatusMessage(t *testing.T) {
+	message, err := textStatusMessage(StatusContent{Text: "hello", BackgroundColor: "#123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := message.GetExtendedTextMessage(); text.GetText() != "hello" || text.GetBackgroundArgb() != 0xff123456 || text.GetTextArgb() != defaultStatusTextColor {
+		t.Errorf("message = %v, want the text on an opaque #123456 background", text)
+	}
+
+	font := int32(99)
+	for _, content := range []StatusContent{
+		{Text: " "},
+		{Text: "hello", TextColor: "red"},
+		{Text: "hello", Font: &font},
+	} {
+		if _, err := textStatusMessage(content); !errors.Is(err, ErrUnsupportedStatus) {
+			t.Errorf("textStatusMessage(%+v) err = %v, want ErrUnsupportedStatus", content, err)
+		}
+	}
+}
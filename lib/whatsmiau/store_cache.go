@@ -0,0 +1,174 @@
+package whatsmiau
+
+import (
+	"sync"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"golang.org/x/net/context"
+)
+
+// cacheDeviceStore wraps the identity and session stores of a device with an
+// in-memory read-through cache. Both are read on every encrypted message sent or
+// received, while writes only happen on key changes, so most reads never reach
+// the database. The process is the only writer for its devices, which keeps the
+// cache consistent as long as every write goes through the wrapper.
+func cacheDeviceStore(device *store.Device) {
+	if !env.Env.DeviceStoreCacheEnabled || device == nil {
+		return
+	}
+
+	if _, ok := device.Identities.(*cachedIdentityStore); !ok {
+		device.Identities = &cachedIdentityStore{
+			IdentityStore: device.Identities,
+			keys:          newBoundedCache[[32]byte](env.Env.DeviceStoreCacheSize),
+		}
+	}
+	if _, ok := device.Sessions.(*cachedSessionStore); !ok {
+		device.Sessions = &cachedSessionStore{
+			SessionStore: device.Sessions,
+			sessions:     newBoundedCache[[]byte](env.Env.DeviceStoreCacheSize),
+		}
+	}
+}
+
+// boundedCache is a map that is reset when it reaches its size limit, which is
+// cheap and good enough for hot keys that get re-read right away
+type boundedCache[T any] struct {
+	mu      sync.RWMutex
+	maxSize int
+	items   map[string]T
+}
+
+func newBoundedCache[T any](maxSize int) *boundedCache[T] {
+	return &boundedCache[T]{maxSize: maxSize, items: make(map[string]T)}
+}
+
+func (c *boundedCache[T]) get(key string) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	v, ok := c.items[key]
+	return v, ok
+}
+
+func (c *boundedCache[T]) set(key string, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxSize > 0 && len(c.items) >= c.maxSize {
+		c.items = make(map[string]T)
+	}
+	c.items[key] = value
+}
+
+func (c *boundedCache[T]) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+}
+
+func (c *boundedCache[T]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]T)
+}
+
+type cachedIdentityStore struct {
+	store.IdentityStore
+	keys *boundedCache[[32]byte]
+}
+
+func (s *cachedIdentityStore) PutIdentity(ctx context.Context, address string, key [32]byte) error {
+	if err := s.IdentityStore.PutIdentity(ctx, address, key); err != nil {
+		s.keys.delete(address)
+		return err
+	}
+	s.keys.set(address, key)
+	return nil
+}
+
+func (s *cachedIdentityStore) DeleteAllIdentities(ctx context.Context, phone string) error {
+	s.keys.clear()
+	return s.IdentityStore.DeleteAllIdentities(ctx, phone)
+}
+
+func (s *cachedIdentityStore) DeleteIdentity(ctx context.Context, address string) error {
+	s.keys.delete(address)
+	return s.IdentityStore.DeleteIdentity(ctx, address)
+}
+
+func (s *cachedIdentityStore) IsTrustedIdentity(ctx context.Context, address string, key [32]byte) (bool, error) {
+	if known, ok := s.keys.get(address); ok {
+		return known == key, nil
+	}
+
+	trusted, err := s.IdentityStore.IsTrustedIdentity(ctx, address, key)
+	if err != nil || !trusted {
+		return trusted, err
+	}
+
+	// unknown identities are trusted too (they're saved later), probing with a
+	// different key tells whether this one is actually the stored identity
+	probe := key
+	probe[0] ^= 0xff
+	if probeTrusted, err := s.IdentityStore.IsTrustedIdentity(ctx, address, probe); err == nil && !probeTrusted {
+		s.keys.set(address, key)
+	}
+	return true, nil
+}
+
+type cachedSessionStore struct {
+	store.SessionStore
+	sessions *boundedCache[[]byte]
+}
+
+func (s *cachedSessionStore) GetSession(ctx context.Context, address string) ([]byte, error) {
+	if session, ok := s.sessions.get(address); ok {
+		return session, nil
+	}
+
+	session, err := s.SessionStore.GetSession(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	s.sessions.set(address, session)
+	return session, nil
+}
+
+func (s *cachedSessionStore) HasSession(ctx context.Context, address string) (bool, error) {
+	if session, ok := s.sessions.get(address); ok {
+		return session != nil, nil
+	}
+	return s.SessionStore.HasSession(ctx, address)
+}
+
+func (s *cachedSessionStore) PutSession(ctx context.Context, address string, session []byte) error {
+	s.sessions.delete(address)
+	if err := s.SessionStore.PutSession(ctx, address, session); err != nil {
+		return err
+	}
+	s.sessions.set(address, session)
+	return nil
+}
+
+func (s *cachedSessionStore) PutManySessions(ctx context.Context, sessions map[string][]byte) error {
+	for address := range sessions {
+		s.sessions.delete(address)
+	}
+	return s.SessionStore.PutManySessions(ctx, sessions)
+}
+
+func (s *cachedSessionStore) DeleteAllSessions(ctx context.Context, phone string) error {
+	s.sessions.clear()
+	return s.SessionStore.DeleteAllSessions(ctx, phone)
+}
+
+func (s *cachedSessionStore) DeleteSession(ctx context.Context, address string) error {
+	s.sessions.delete(address)
+	return s.SessionStore.DeleteSession(ctx, address)
+}
+
+func (s *cachedSessionStore) MigratePNToLID(ctx context.Context, pn, lid types.JID) error {
+	s.sessions.clear()
+	return s.SessionStore.MigratePNToLID(ctx, pn, lid)
+}
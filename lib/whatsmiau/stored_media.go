@@ -0,0 +1,123 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+var (
+	ErrStorageDisabled     = errors.New("file storage is disabled")
+	ErrStoredMediaNotFound = errors.New("stored media not found")
+)
+
+const (
+	mediaKeyDateLayout     = "2006/01/02"
+	defaultStoredMediaPage = 100
+	maxStoredMediaPage     = 1000
+)
+
+// mediaKey namespaces uploads as <instance>/<yyyy>/<mm>/<dd>/<uuid> so a tenant's
+// media can be listed by prefix and date range
+func mediaKey(instanceID string, at time.Time) string {
+	return mediaKeyNamed(instanceID, at, uuid.NewString())
+}
+
+func mediaKeyNamed(instanceID string, at time.Time, name string) string {
+	return mediaPrefix(instanceID) + at.UTC().Format(mediaKeyDateLayout) + "/" + name
+}
+
+func mediaPrefix(instanceID string) string {
+	return instanceID + "/"
+}
+
+type ListStoredMediaRequest struct {
+	InstanceID string
+	From       time.Time
+	To         time.Time // exclusive, zero is now
+	PageToken  string
+	Limit      int
+}
+
+type ListStoredMediaResponse struct {
+	Media         []models.StoredMedia `json:"media"`
+	NextPageToken string               `json:"nextPageToken,omitempty"`
+}
+
+// ListStoredMedia pages through the media uploaded for the instance in [From, To).
+// Keys are narrowed by day and filtered by creation time, so a page may hold
+// fewer than Limit entries while NextPageToken is still set.
+func (s *Whatsmiau) ListStoredMedia(ctx context.Context, req *ListStoredMediaRequest) (*ListStoredMediaResponse, error) {
+	if s.fileStorage == nil {
+		return nil, ErrStorageDisabled
+	}
+
+	to := req.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultStoredMediaPage
+	}
+	limit = min(limit, maxStoredMediaPage)
+
+	prefix := mediaPrefix(req.InstanceID)
+	query := &models.StorageQuery{
+		Prefix:    prefix,
+		EndOffset: prefix + to.UTC().AddDate(0, 0, 1).Format(mediaKeyDateLayout),
+		PageToken: req.PageToken,
+		Limit:     limit,
+	}
+	if !req.From.IsZero() {
+		query.StartOffset = prefix + req.From.UTC().Format(mediaKeyDateLayout)
+	}
+
+	objects, next, err := s.fileStorage.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	media := make([]models.StoredMedia, 0, len(objects))
+	for _, object := range objects {
+		if object.CreatedAt.Before(req.From) || !object.CreatedAt.Before(to) {
+			continue
+		}
+		media = append(media, object)
+	}
+
+	return &ListStoredMediaResponse{
+		Media:         media,
+		NextPageToken: next,
+	}, nil
+}
+
+// DownloadStoredMedia opens one of the instance's stored files, the caller must
+// close the reader
+func (s *Whatsmiau) DownloadStoredMedia(ctx context.Context, id, key string) (io.ReadCloser, *models.StoredMedia, error) {
+	if s.fileStorage == nil {
+		return nil, nil, ErrStorageDisabled
+	}
+
+	// keys of other tenants are reported as missing rather than forbidden
+	if !strings.HasPrefix(key, mediaPrefix(id)) || strings.Contains(key, "..") {
+		return nil, nil, ErrStoredMediaNotFound
+	}
+
+	reader, media, err := s.fileStorage.Download(ctx, key)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrObjectNotFound) {
+			return nil, nil, ErrStoredMediaNotFound
+		}
+		return nil, nil, err
+	}
+
+	return reader, media, nil
+}
@@ -0,0 +1,66 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+)
+
+var ErrThumbnailUnavailable = errors.New("message has no stored thumbnail")
+
+type MessageThumbnail struct {
+	MessageID string `json:"message_id"`
+	Mimetype  string `json:"mimetype"`
+	Thumbnail []byte `json:"thumbnail"` // base64 in JSON
+}
+
+// GetMessageThumbnail returns the thumbnail embedded in a recent message
+// (RECENT_MESSAGE_CACHE_TTL), no media download involved. The message store
+// keeps no raw messages, so older thumbnails are unavailable.
+func (s *Whatsmiau) GetMessageThumbnail(ctx context.Context, id, messageID string) (*MessageThumbnail, error) {
+	if _, ok := s.clients.Load(id); !ok {
+		return nil, whatsmeow.ErrClientIsNil
+	}
+
+	_, message, err := s.originalMessage(ctx, id, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, fmt.Errorf("%w: content is only kept for RECENT_MESSAGE_CACHE_TTL", ErrThumbnailUnavailable)
+	}
+
+	thumbnail, mimetype := extractThumbnail(message)
+	if len(thumbnail) == 0 {
+		return nil, ErrThumbnailUnavailable
+	}
+
+	return &MessageThumbnail{
+		MessageID: messageID,
+		Mimetype:  mimetype,
+		Thumbnail: thumbnail,
+	}, nil
+}
+
+func extractThumbnail(m *waE2E.Message) ([]byte, string) {
+	switch {
+	case m.GetImageMessage() != nil:
+		return m.GetImageMessage().GetJPEGThumbnail(), "image/jpeg"
+	case m.GetVideoMessage() != nil:
+		return m.GetVideoMessage().GetJPEGThumbnail(), "image/jpeg"
+	case m.GetDocumentMessage() != nil:
+		return m.GetDocumentMessage().GetJPEGThumbnail(), "image/jpeg"
+	case m.GetExtendedTextMessage() != nil:
+		return m.GetExtendedTextMessage().GetJPEGThumbnail(), "image/jpeg"
+	case m.GetLocationMessage() != nil:
+		return m.GetLocationMessage().GetJPEGThumbnail(), "image/jpeg"
+	case m.GetStickerMessage() != nil:
+		return m.GetStickerMessage().GetPngThumbnail(), "image/png"
+	}
+
+	return nil, ""
+}
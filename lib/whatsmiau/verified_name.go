@@ -0,0 +1,65 @@
+package whatsmiau
+
+import (
+	"context"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+// verifiedName returns the WhatsApp-verified business name of the sender, empty
+// for regular accounts. The name comes with the message when WhatsApp attaches
+// it, otherwise from a cached user info lookup when VERIFIED_NAME_LOOKUP is on.
+func (s *Whatsmiau) verifiedName(ctx context.Context, id string, client *whatsmeow.Client, info types.MessageInfo) string {
+	if info.IsFromMe {
+		return ""
+	}
+
+	sender := info.Sender.ToNonAD()
+	key := id + ":" + sender.String()
+	if info.VerifiedName != nil && info.VerifiedName.Details != nil {
+		name := info.VerifiedName.Details.GetVerifiedName()
+		s.cacheVerifiedName(key, name)
+		return name
+	}
+
+	if name, ok := s.verifiedNames.Load(key); ok {
+		return name
+	}
+
+	if !env.Env.VerifiedNameLookup {
+		return ""
+	}
+
+	users, err := client.GetUserInfo(ctx, []types.JID{sender})
+	if err != nil {
+		zap.L().Warn("failed to lookup verified name", zap.String("id", id), zap.String("sender", sender.String()), zap.Error(err))
+		return ""
+	}
+
+	var name string
+	if user, ok := users[sender]; ok && user.VerifiedName != nil && user.VerifiedName.Details != nil {
+		name = user.VerifiedName.Details.GetVerifiedName()
+	}
+	// regular accounts are cached too, so they aren't looked up on every message
+	s.cacheVerifiedName(key, name)
+
+	return name
+}
+
+func (s *Whatsmiau) cacheVerifiedName(key, name string) {
+	ttl := env.Env.VerifiedNameCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	if _, loaded := s.verifiedNames.LoadAndStore(key, name); loaded {
+		return
+	}
+	time.AfterFunc(ttl, func() {
+		s.verifiedNames.Delete(key)
+	})
+}
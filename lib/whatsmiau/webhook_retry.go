@@ -0,0 +1,206 @@
+package whatsmiau
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"golang.org/x/net/context"
+)
+
+var (
+	errBreakerOpen    = errors.New("webhook circuit breaker open")
+	errRetryQueueFull = errors.New("webhook retry queue full")
+)
+
+type pendingDelivery struct {
+	event    emitter
+	data     []byte
+	attempts int // deliveries already tried, 0 for events queued behind a retry
+	err      error
+}
+
+// retryQueue holds the deliveries of one instance waiting for retry, drained
+// by a single goroutine so they go out in the order they were emitted
+type retryQueue struct {
+	mu      sync.Mutex
+	pending []pendingDelivery // the first one is being retried while running
+	running bool
+	flushed bool // dead lettered on Shutdown, nothing is retried anymore
+}
+
+// retryDelivery queues a failed delivery to be retried in the background with
+// backoff so the emitter keeps going, then dead letters it once
+// WEBHOOK_MAX_ATTEMPTS is spent. Every retry takes a handler slot, a burst of
+// failing deliveries can't outgrow event handling. An open breaker dead letters
+// right away, retrying against it would only fail again.
+func (s *Whatsmiau) retryDelivery(event emitter, data []byte, err error) {
+	if errors.Is(err, errBreakerOpen) || env.Env.WebhookMaxAttempts <= 1 {
+		s.sendToDeadLetter(event, data, err.Error())
+		return
+	}
+
+	s.enqueueRetry(pendingDelivery{event: event, data: data, attempts: 1, err: err})
+}
+
+// queueBehindRetries puts the delivery at the end of the instance retry queue
+// when it has retries pending, sending it first would reorder the events.
+// False when nothing is pending and the delivery can go out now.
+func (s *Whatsmiau) queueBehindRetries(event emitter, data []byte) bool {
+	queue, ok := s.retryQueues.Load(event.instance)
+	if !ok {
+		return false
+	}
+
+	queue.mu.Lock()
+	busy := queue.running
+	queue.mu.Unlock()
+	if !busy {
+		return false
+	}
+
+	s.enqueueRetry(pendingDelivery{event: event, data: data})
+	return true
+}
+
+func (s *Whatsmiau) enqueueRetry(delivery pendingDelivery) {
+	queue, _ := s.retryQueues.LoadOrCompute(delivery.event.instance, func() (*retryQueue, bool) {
+		return &retryQueue{}, false
+	})
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if queue.flushed {
+		s.sendToDeadLetter(delivery.event, delivery.data, ErrShuttingDown.Error())
+		return
+	}
+	if len(queue.pending) >= env.Env.WebhookRetryQueueSize {
+		s.sendToDeadLetter(delivery.event, delivery.data, errRetryQueueFull.Error())
+		return
+	}
+
+	queue.pending = append(queue.pending, delivery)
+	if !queue.running {
+		queue.running = true
+		go s.drainRetries(queue)
+	}
+}
+
+func (s *Whatsmiau) drainRetries(queue *retryQueue) {
+	for {
+		queue.mu.Lock()
+		if queue.flushed || len(queue.pending) == 0 {
+			queue.running = false
+			queue.mu.Unlock()
+			return
+		}
+		delivery := queue.pending[0]
+		queue.mu.Unlock()
+
+		err := s.retryPending(delivery)
+
+		queue.mu.Lock()
+		if queue.flushed {
+			// Shutdown already dead lettered it with the rest of the queue
+			queue.running = false
+			queue.mu.Unlock()
+			return
+		}
+		queue.pending = queue.pending[1:]
+		queue.mu.Unlock()
+
+		if err != nil {
+			s.sendToDeadLetter(delivery.event, delivery.data, err.Error())
+		}
+	}
+}
+
+// flushRetries dead letters every delivery still waiting for retry, including
+// the ones in backoff. A retry attempt already running when it's called may
+// still succeed, leaving the event both delivered and dead lettered.
+func (s *Whatsmiau) flushRetries() {
+	s.retryQueues.Range(func(_ string, queue *retryQueue) bool {
+		queue.mu.Lock()
+		pending := queue.pending
+		queue.pending = nil
+		queue.flushed = true
+		queue.mu.Unlock()
+
+		for _, delivery := range pending {
+			reason := ErrShuttingDown.Error()
+			if delivery.err != nil {
+				reason = delivery.err.Error()
+			}
+			s.sendToDeadLetter(delivery.event, delivery.data, reason)
+		}
+		return true
+	})
+}
+
+// retryPending tries the delivery until it succeeds or runs out of attempts,
+// returning the last error
+func (s *Whatsmiau) retryPending(delivery pendingDelivery) error {
+	err := delivery.err
+	for attempt := delivery.attempts + 1; attempt <= env.Env.WebhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(backoffDelay(env.Env.WebhookRetryBaseDelay, env.Env.WebhookRetryMaxDelay, attempt-1))
+		}
+
+		s.handlerSemaphore <- struct{}{}
+		err = s.attemptDelivery(delivery.event, delivery.data)
+		<-s.handlerSemaphore
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errBreakerOpen) {
+			break
+		}
+	}
+
+	return err
+}
+
+// ReplayDeadLetter moves up to max dead letters of the instance, oldest first,
+// back to the emitter. Payloads are sent as stored, already rendered by the
+// webhook template, to the url they failed on with the current headers and
+// secret of the instance. Returns how many were enqueued.
+func (s *Whatsmiau) ReplayDeadLetter(ctx context.Context, id string, max int) (int, error) {
+	instance := s.getInstanceCached(id)
+	if instance == nil {
+		return 0, instances.ErrorNotFound
+	}
+
+	s.emitMu.RLock()
+	defer s.emitMu.RUnlock()
+	if s.closing.Load() {
+		return 0, ErrShuttingDown
+	}
+
+	letters, err := s.deadLetters.Pop(ctx, id, max)
+	for i, letter := range letters {
+		event := emitter{
+			instance:   id,
+			url:        letter.Url,
+			headers:    instance.Webhook.Headers,
+			secret:     webhookSecret(instance),
+			data:       json.RawMessage(letter.Payload),
+			enqueuedAt: time.Now(),
+		}
+
+		select {
+		case s.emitter <- event:
+		case <-ctx.Done():
+			// the rest was already popped, keep it dead lettered
+			for _, rest := range letters[i:] {
+				s.sendToDeadLetter(emitter{instance: id, url: rest.Url}, rest.Payload, rest.Reason)
+			}
+			return i, ctx.Err()
+		}
+	}
+
+	return len(letters), err
+}
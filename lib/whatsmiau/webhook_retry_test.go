@@ -0,0 +1,153 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+type memoryDeadLetters struct {
+	mu      sync.Mutex
+	letters []models.DeadLetter
+}
+
+func (m *memoryDeadLetters) Push(_ context.Context, _ string, letter *models.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, *letter)
+	return nil
+}
+
+func (m *memoryDeadLetters) Pop(context.Context, string, int) ([]models.DeadLetter, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *memoryDeadLetters) reasons() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var reasons []string
+	for _, letter := range m.letters {
+		reasons = append(reasons, letter.Reason)
+	}
+	return reasons
+}
+
+func newRetryTestMiau(t *testing.T, queueSize int) (*Whatsmiau, *memoryDeadLetters) {
+	t.Helper()
+	previous := env.Env
+	env.Env.WebhookMaxAttempts = 3
+	env.Env.WebhookRetryBaseDelay = time.Millisecond
+	env.Env.WebhookRetryMaxDelay = time.Millisecond
+	env.Env.WebhookRetryQueueSize = queueSize
+	env.Env.WebhookBreakerThreshold = 100
+	t.Cleanup(func() { env.Env = previous })
+
+	deadLetters := &memoryDeadLetters{}
+	return &Whatsmiau{
+		httpClient:       http.DefaultClient,
+		handlerSemaphore: make(chan struct{}, 1),
+		breakers:         xsync.NewMap[string, *circuitBreaker](),
+		retryQueues:      xsync.NewMap[string, *retryQueue](),
+		deadLetters:      deadLetters,
+	}, deadLetters
+}
+
+func TestRetryKeepsDeliveryOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, string(body))
+	}))
+	defer server.Close()
+
+	s, deadLetters := newRetryTestMiau(t, 10)
+	event := emitter{instance: "retry", url: server.URL}
+	for _, payload := range []string{"1", "2", "3"} {
+		s.emitPayload(event, []byte(payload))
+	}
+
+	queue, _ := s.retryQueues.Load("retry")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		queue.mu.Lock()
+		running := queue.running
+		queue.mu.Unlock()
+		if !running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("retry queue did not drain")
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 3 {
+		t.Fatalf("received %v, want 3 deliveries", received)
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if received[i] != want {
+			t.Fatalf("received %v, want in emit order", received)
+		}
+	}
+	if reasons := deadLetters.reasons(); len(reasons) != 0 {
+		t.Errorf("dead lettered %v", reasons)
+	}
+}
+
+func TestRetryQueueIsBounded(t *testing.T) {
+	s, deadLetters := newRetryTestMiau(t, 1)
+	// a worker already draining, so nothing leaves the queue during the test
+	s.retryQueues.Store("retry", &retryQueue{running: true})
+
+	event := emitter{instance: "retry", url: "http://127.0.0.1:1"}
+	s.retryDelivery(event, []byte("1"), errors.New("status 503"))
+	s.retryDelivery(event, []byte("2"), errors.New("status 503"))
+
+	queue, _ := s.retryQueues.Load("retry")
+	if len(queue.pending) != 1 || string(queue.pending[0].data) != "1" {
+		t.Errorf("pending = %v, want only the first delivery", queue.pending)
+	}
+	if reasons := deadLetters.reasons(); len(reasons) != 1 || reasons[0] != errRetryQueueFull.Error() {
+		t.Errorf("dead letter reasons = %v, want [%s]", reasons, errRetryQueueFull)
+	}
+}
+
+func TestFlushRetriesDeadLettersPending(t *testing.T) {
+	s, deadLetters := newRetryTestMiau(t, 10)
+	s.retryQueues.Store("retry", &retryQueue{running: true})
+
+	event := emitter{instance: "retry", url: "http://127.0.0.1:1"}
+	s.retryDelivery(event, []byte("1"), errors.New("status 503"))
+	s.retryDelivery(event, []byte("2"), errors.New("status 503"))
+	s.flushRetries()
+	s.retryDelivery(event, []byte("3"), errors.New("status 503"))
+
+	want := []string{"status 503", "status 503", ErrShuttingDown.Error()}
+	if reasons := deadLetters.reasons(); !slices.Equal(reasons, want) {
+		t.Errorf("dead letter reasons = %v, want %v", reasons, want)
+	}
+	if queue, _ := s.retryQueues.Load("retry"); len(queue.pending) != 0 {
+		t.Errorf("pending = %v, want the queue flushed", queue.pending)
+	}
+}
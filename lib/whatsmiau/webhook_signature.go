@@ -0,0 +1,61 @@
+package whatsmiau
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+const (
+	SignatureHeader = "X-Whatsmiau-Signature"
+	TimestampHeader = "X-Whatsmiau-Timestamp"
+)
+
+// webhookSecret is the instance secret, falling back to WEBHOOK_SECRET
+func webhookSecret(instance *models.Instance) string {
+	if secret := instance.Webhook.Secret; secret != nil && len(*secret) > 0 {
+		return *secret
+	}
+
+	return env.Env.WebhookSecret
+}
+
+// SignPayload is the hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the
+// secret, where timestamp is the X-Whatsmiau-Timestamp value (unix seconds).
+// Receivers recompute it over the raw body and reject old timestamps.
+func SignPayload(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signRequest(req *http.Request, secret string, body []byte) {
+	if len(secret) == 0 {
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+SignPayload(secret, timestamp, body))
+}
+
+// MaskWebhookSecret returns a copy of the instance with the webhook secret
+// masked, for the responses echoing instances back
+func MaskWebhookSecret(instance *models.Instance) *models.Instance {
+	if instance == nil || instance.Webhook.Secret == nil {
+		return instance
+	}
+
+	masked := *instance
+	secret := maskSecret(*instance.Webhook.Secret)
+	masked.Webhook.Secret = &secret
+	return &masked
+}
@@ -0,0 +1,89 @@
+package whatsmiau
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+func TestSignRequest(t *testing.T) {
+	body := []byte(`{"event":"messages.upsert"}`)
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signRequest(req, "s3cret", body)
+
+	timestamp := req.Header.Get(TimestampHeader)
+	if timestamp == "" {
+		t.Fatalf("missing %s header", TimestampHeader)
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get(SignatureHeader); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+}
+
+func TestSignRequestWithoutSecret(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signRequest(req, "", []byte("{}"))
+
+	if req.Header.Get(SignatureHeader) != "" || req.Header.Get(TimestampHeader) != "" {
+		t.Errorf("unsigned request got headers %v", req.Header)
+	}
+}
+
+func TestWebhookSecret(t *testing.T) {
+	previous := env.Env.WebhookSecret
+	env.Env.WebhookSecret = "global"
+	t.Cleanup(func() { env.Env.WebhookSecret = previous })
+
+	empty, own := "", "own"
+	tests := []struct {
+		name   string
+		secret *string
+		want   string
+	}{
+		{name: "unset", secret: nil, want: "global"},
+		{name: "empty", secret: &empty, want: "global"},
+		{name: "instance", secret: &own, want: "own"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &models.Instance{Webhook: models.InstanceWebhook{Secret: tt.secret}}
+			if got := webhookSecret(instance); got != tt.want {
+				t.Errorf("webhookSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskWebhookSecret(t *testing.T) {
+	secret := "supersecret"
+	instance := &models.Instance{ID: "instance", Webhook: models.InstanceWebhook{Secret: &secret}}
+
+	masked := MaskWebhookSecret(instance)
+	if got := *masked.Webhook.Secret; got != "su*******et" {
+		t.Errorf("masked secret = %q", got)
+	}
+	if *instance.Webhook.Secret != "supersecret" || masked.ID != "instance" {
+		t.Errorf("instance = %+v, want the original kept", instance)
+	}
+	if MaskWebhookSecret(nil) != nil {
+		t.Error("MaskWebhookSecret(nil) != nil")
+	}
+}
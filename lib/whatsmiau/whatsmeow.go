@@ -1,21 +1,33 @@
 package whatsmiau
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
 	"github.com/verbeux-ai/whatsmiau/env"
 	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/metrics"
 	"github.com/verbeux-ai/whatsmiau/lib/storage/gcs"
+	"github.com/verbeux-ai/whatsmiau/lib/storage/localfs"
+	"github.com/verbeux-ai/whatsmiau/lib/storage/s3"
 	"github.com/verbeux-ai/whatsmiau/models"
+	"github.com/verbeux-ai/whatsmiau/repositories/deadletters"
 	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"github.com/verbeux-ai/whatsmiau/repositories/messages"
+	"github.com/verbeux-ai/whatsmiau/repositories/schedules"
+	"github.com/verbeux-ai/whatsmiau/repositories/templates"
 	"github.com/verbeux-ai/whatsmiau/services"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
@@ -27,13 +39,49 @@ type Whatsmiau struct {
 	logger           waLog.Logger
 	repo             interfaces.InstanceRepository
 	qrCache          *xsync.Map[string, string]
+	pairCodes        *xsync.Map[string, string] // pairing code logins in progress, empty while the code is requested
+	qrImages         *xsync.Map[string, qrImage]
 	observerRunning  *xsync.Map[string, bool]
 	instanceCache    *xsync.Map[string, models.Instance]
 	lockConnection   *xsync.Map[string, *sync.Mutex]
 	emitter          chan emitter
 	httpClient       *http.Client
+	fetchClient      *http.Client
 	fileStorage      interfaces.Storage
 	handlerSemaphore chan struct{}
+	breakers         *xsync.Map[string, *circuitBreaker]
+	retryQueues      *xsync.Map[string, *retryQueue]
+	deadLetters      interfaces.DeadLetterRepository
+	disconnectTimers *xsync.Map[string, *time.Timer]
+	presenceLoops    *xsync.Map[string, context.CancelFunc]
+	reconnectLoops   *xsync.Map[string, *reconnectLoop]
+	proxies          *proxyPools
+	accountBlocks    *xsync.Map[string, models.AccountBlock]     // temporary blocks of loaded clients
+	mediaRetries     *xsync.Map[string, chan *events.MediaRetry] // <instance>/<message id> of DownloadMedia calls waiting for a re-upload
+	groupInfos       *xsync.Map[string, *types.GroupInfo]        // <instance>:<group>, see GROUP_INFO_CACHE_TTL
+	profilePictures  *xsync.Map[string, string]                  // <instance>:<jid> to the last seen picture id, see PROFILE_PICTURE_CACHE_TTL
+	polls            *xsync.Map[string, []string]                // <instance>:<poll id> to the option names, see POLL_CACHE_TTL
+	recentMessages   *xsync.Map[string, *recentMessage]          // <instance>:<message id>, see RECENT_MESSAGE_CACHE_TTL
+	historyRequests  *xsync.Map[string, *historyRequest]         // <instance>:<chat> of on-demand history syncs waiting the phone
+	lidMappings      *xsync.Map[string, *lidMapping]             // <instance>:<pn or lid> to the other address, see LID_CACHE_TTL
+	presenceSubs     *xsync.Map[string, types.JID]               // <instance>:<pn> of contacts whose presence is emitted
+	onlineInstances  *xsync.Map[string, struct{}]                // instances that sent an available presence since going offline
+	clockSkews       *xsync.Map[string, ClockSkew]
+	newsletterInfos  *xsync.Map[string, *types.NewsletterMetadata] // <instance>:<newsletter>, see NEWSLETTER_INFO_CACHE_TTL
+	messages         interfaces.MessageRepository
+	recentlySent     *xsync.Map[string, struct{}]
+	sendLimiters     *xsync.Map[string, *tokenBucket]
+	inFlight         *xsync.Map[string, *inFlightHandlers]
+	payloadTemplates *xsync.Map[string, *payloadTemplate] // by instance
+	verifiedNames    *xsync.Map[string, string]
+	templates        interfaces.TemplateRepository
+	schedules        interfaces.ScheduleRepository
+	scheduleTimers   *xsync.Map[string, *time.Timer]
+	emitMu           sync.RWMutex // guards sends to emitter against its close on Shutdown
+	emitterDone      chan struct{}
+	closing          atomic.Bool
+	shutdownOnce     sync.Once
+	shutdownErr      error
 }
 
 var instance *Whatsmiau
@@ -45,12 +93,21 @@ func Get() *Whatsmiau {
 	return instance
 }
 
+// LoadMiau loads the global Whatsmiau instance, panicking on failure
 func LoadMiau(ctx context.Context, container *sqlstore.Container) {
+	if _, err := LoadMiauE(ctx, container); err != nil {
+		zap.L().Panic("failed to load whatsmiau", zap.Error(err))
+	}
+}
+
+// LoadMiauE loads the global Whatsmiau instance, returning an error instead of
+// crashing so the caller can decide whether to retry
+func LoadMiauE(ctx context.Context, container *sqlstore.Container) (*Whatsmiau, error) {
 	mu.Lock()
 	defer mu.Unlock()
-	deviceStore, err := container.GetAllDevices(ctx)
+	deviceStore, err := getAllDevicesWithRetry(ctx, container)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	level := "INFO"
@@ -61,7 +118,7 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 	repo := instances.NewRedis(services.Redis())
 	instanceList, err := repo.List(ctx, "")
 	if err != nil {
-		zap.L().Fatal("failed to list instances", zap.Error(err))
+		return nil, fmt.Errorf("failed to list instances: %w", err)
 	}
 
 	instanceByRemoteJid := make(map[string]models.Instance)
@@ -73,11 +130,43 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 		instanceByRemoteJid[inst.RemoteJID] = inst
 	}
 
+	// before connecting any device, a failure here would leave their sessions running
+	enabledStorages := 0
+	for _, enabled := range []bool{env.Env.GCSEnabled, env.Env.S3Enabled, env.Env.LocalStorageEnabled} {
+		if enabled {
+			enabledStorages++
+		}
+	}
+	if enabledStorages > 1 {
+		return nil, errors.New("only one of GCS_ENABLED, S3_ENABLED and LOCAL_STORAGE_ENABLED can be set")
+	}
+
+	var storage interfaces.Storage
+	switch {
+	case env.Env.GCSEnabled:
+		storage, err = gcs.New(env.Env.GCSBucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCS storage: %w", err)
+		}
+	case env.Env.S3Enabled:
+		storage, err = s3.New(env.Env.S3Bucket)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 storage: %w", err)
+		}
+	case env.Env.LocalStorageEnabled:
+		storage, err = localfs.New(env.Env.LocalStoragePath, env.Env.LocalStorageURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create local storage: %w", err)
+		}
+	}
+
 	clients := xsync.NewMap[string, *whatsmeow.Client]()
+	proxies := newProxyPools()
 
 	clientLog := waLog.Stdout("Client", level, false)
 	for _, device := range deviceStore {
-		client := whatsmeow.NewClient(device, clientLog)
+		cacheDeviceStore(device)
+		client := newClient(device, clientLog)
 		if client.Store.ID == nil {
 			zap.L().Error("device without id on db", zap.Any("device", device))
 			continue
@@ -85,8 +174,12 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 
 		instanceFound, ok := instanceByRemoteJid[client.Store.ID.String()]
 		if ok {
-			configProxy(client, instanceFound.InstanceProxy)
 			clients.Store(instanceFound.ID, client)
+			if err := proxies.config(client, &instanceFound); err != nil {
+				// connecting without the proxy would expose the host address
+				zap.L().Error("failed to configure proxy, not connecting", zap.Error(err), zap.String("id", instanceFound.ID))
+				continue
+			}
 			if err := client.Connect(); err != nil {
 				zap.L().Error("failed to connect connected device", zap.Error(err), zap.String("jid", client.Store.ID.String()))
 			}
@@ -103,20 +196,14 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 		}
 	}
 
-	var storage interfaces.Storage
-	if env.Env.GCSEnabled {
-		storage, err = gcs.New(env.Env.GCSBucket)
-		if err != nil {
-			zap.L().Panic("failed to create GCS storage", zap.Error(err))
-		}
-	}
-
 	instance = &Whatsmiau{
 		clients:         clients,
 		container:       container,
 		logger:          clientLog,
 		repo:            repo,
 		qrCache:         xsync.NewMap[string, string](),
+		pairCodes:       xsync.NewMap[string, string](),
+		qrImages:        xsync.NewMap[string, qrImage](),
 		instanceCache:   xsync.NewMap[string, models.Instance](),
 		observerRunning: xsync.NewMap[string, bool](),
 		lockConnection:  xsync.NewMap[string, *sync.Mutex](),
@@ -124,11 +211,44 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 		httpClient: &http.Client{
 			Timeout: time.Second * 30, // TODO: load from env
 		},
+		fetchClient:      newPublicHTTPClient(time.Second * 30),
 		fileStorage:      storage,
 		handlerSemaphore: make(chan struct{}, env.Env.HandlerSemaphoreSize),
+		breakers:         xsync.NewMap[string, *circuitBreaker](),
+		retryQueues:      xsync.NewMap[string, *retryQueue](),
+		deadLetters:      deadletters.NewRedis(services.Redis(), env.Env.DeadLetterMaxSize),
+		disconnectTimers: xsync.NewMap[string, *time.Timer](),
+		presenceLoops:    xsync.NewMap[string, context.CancelFunc](),
+		reconnectLoops:   xsync.NewMap[string, *reconnectLoop](),
+		proxies:          proxies,
+		accountBlocks:    xsync.NewMap[string, models.AccountBlock](),
+		mediaRetries:     xsync.NewMap[string, chan *events.MediaRetry](),
+		groupInfos:       xsync.NewMap[string, *types.GroupInfo](),
+		newsletterInfos:  xsync.NewMap[string, *types.NewsletterMetadata](),
+		profilePictures:  xsync.NewMap[string, string](),
+		polls:            xsync.NewMap[string, []string](),
+		recentMessages:   xsync.NewMap[string, *recentMessage](),
+		historyRequests:  xsync.NewMap[string, *historyRequest](),
+		lidMappings:      xsync.NewMap[string, *lidMapping](),
+		presenceSubs:     xsync.NewMap[string, types.JID](),
+		onlineInstances:  xsync.NewMap[string, struct{}](),
+		clockSkews:       xsync.NewMap[string, ClockSkew](),
+		messages:         messages.NewRedis(services.Redis(), env.Env.MessageStoreTTL),
+		recentlySent:     xsync.NewMap[string, struct{}](),
+		sendLimiters:     xsync.NewMap[string, *tokenBucket](),
+		inFlight:         xsync.NewMap[string, *inFlightHandlers](),
+		payloadTemplates: xsync.NewMap[string, *payloadTemplate](),
+		verifiedNames:    xsync.NewMap[string, string](),
+		templates:        templates.NewRedis(services.Redis()),
+		schedules:        schedules.NewRedis(services.Redis()),
+		scheduleTimers:   xsync.NewMap[string, *time.Timer](),
+		emitterDone:      make(chan struct{}),
 	}
 
 	go instance.startEmitter()
+	go instance.startProxyHealthCheck()
+	instance.armSchedules(ctx)
+	metrics.SetInstancesSource(instance.instanceStatsByStatus)
 
 	clients.Range(func(id string, client *whatsmeow.Client) bool {
 		zap.L().Info("stating event handler", zap.String("jid", client.Store.ID.String()))
@@ -136,9 +256,66 @@ func LoadMiau(ctx context.Context, container *sqlstore.Container) {
 		return true
 	})
 
+	return instance, nil
+}
+
+// getAllDevicesWithRetry retries container.GetAllDevices with exponential backoff,
+// so a database that is briefly unavailable at boot doesn't take the service down
+func getAllDevicesWithRetry(ctx context.Context, container *sqlstore.Container) ([]*store.Device, error) {
+	delay := env.Env.BootRetryDelay
+	for attempt := 1; ; attempt++ {
+		devices, err := container.GetAllDevices(ctx)
+		if err == nil {
+			return devices, nil
+		}
+
+		if attempt >= env.Env.BootRetryAttempts {
+			return nil, fmt.Errorf("failed to get devices after %d attempts: %w", attempt, err)
+		}
+
+		zap.L().Warn("failed to get devices, retrying", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to get devices: %w (last error: %v)", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// getQRChannelWithRetry retries client.GetQRChannel with exponential backoff.
+// Errors caused by the device state (already connected or logged in) aren't retried.
+func getQRChannelWithRetry(ctx context.Context, client *whatsmeow.Client) (<-chan whatsmeow.QRChannelItem, error) {
+	delay := env.Env.QrChannelRetryDelay
+	for attempt := 1; ; attempt++ {
+		qrChan, err := client.GetQRChannel(ctx)
+		if err == nil {
+			return qrChan, nil
+		}
+
+		if errors.Is(err, whatsmeow.ErrQRAlreadyConnected) || errors.Is(err, whatsmeow.ErrQRStoreContainsID) {
+			return nil, err
+		}
+
+		if attempt >= env.Env.QrChannelRetryAttempts {
+			return nil, fmt.Errorf("failed to get QR channel after %d attempts: %w", attempt, err)
+		}
+
+		zap.L().Warn("failed to get QR channel, retrying", zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to get QR channel: %w (last error: %v)", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
 }
 
 func (s *Whatsmiau) Connect(ctx context.Context, id string) (string, error) {
+	if _, ok := s.pairCodes.Load(id); ok {
+		return "", ErrPairingInProgress
+	}
+
 	client, err := s.generateClient(ctx, id)
 	if err != nil {
 		return "", err
@@ -171,14 +348,17 @@ func (s *Whatsmiau) generateClient(ctx context.Context, id string) (*whatsmeow.C
 	client, ok := s.clients.Load(id)
 	if !ok {
 		device := s.container.NewDevice()
-		client = whatsmeow.NewClient(device, s.logger)
+		cacheDeviceStore(device)
+		client = newClient(device, s.logger)
 		s.clients.Store(id, client)
 	}
 
 	// trying recover existent connection
 	if s.hasSomeDevice(client) {
 		if instanceFound := s.getInstanceCached(id); instanceFound != nil {
-			configProxy(client, instanceFound.InstanceProxy)
+			if err := s.proxies.config(client, instanceFound); err != nil {
+				return nil, err
+			}
 		}
 
 		if client.IsLoggedIn() {
@@ -198,7 +378,8 @@ func (s *Whatsmiau) generateClient(ctx context.Context, id string) (*whatsmeow.C
 		}
 
 		device := s.container.NewDevice()
-		client = whatsmeow.NewClient(device, s.logger)
+		cacheDeviceStore(device)
+		client = newClient(device, s.logger)
 		s.clients.Store(id, client) // replaces old client
 	}
 
@@ -231,17 +412,26 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 		zap.L().Debug("stopping observer connection", zap.String("id", id))
 		s.observerRunning.Delete(id)
 		s.qrCache.Delete(id)
+		s.pairCodes.Delete(id)
+		s.qrImages.Delete(id)
 	}()
 
-	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute*2)
-	qrChan, err := client.GetQRChannel(ctx)
+	ctx, cancel := context.WithTimeout(context.TODO(), env.Env.QRObserveTimeout)
+	qrChan, err := getQRChannelWithRetry(ctx, client)
 	if err != nil {
-		zap.L().Error("failed to observe QR Code", zap.Error(err))
+		zap.L().Error("failed to observe QR Code", zap.String("id", id), zap.Error(err))
+		cancel()
+		s.emitQrCodeError(id, err)
 		return
 	}
 
 	if instanceFound := s.getInstance(id); instanceFound != nil {
-		configProxy(client, instanceFound.InstanceProxy)
+		if err := s.proxies.config(client, instanceFound); err != nil {
+			zap.L().Error("failed to configure proxy", zap.String("id", id), zap.Error(err))
+			cancel()
+			s.emitQrCodeError(id, err)
+			return
+		}
 	}
 	if err := client.Connect(); err != nil {
 		zap.L().Error("failed to connect connected device", zap.Error(err))
@@ -278,6 +468,7 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 				}
 
 				zap.L().Info("device connected successfully", zap.String("id", id))
+				s.emitQrCodeScanned(id, client.Store.ID.ToNonAD().String())
 				client.RemoveEventHandlers()
 				client.AddEventHandler(s.Handle(id))
 				if _, err := s.repo.Update(context.Background(), id, &models.Instance{
@@ -295,13 +486,22 @@ func (s *Whatsmiau) observeConnection(client *whatsmeow.Client, id string) {
 }
 
 func (s *Whatsmiau) observeAndQrCode(ctx context.Context, id string, client *whatsmeow.Client) (string, error) {
-	ctx, c := context.WithTimeout(ctx, 15*time.Second)
+	// a zero timeout would fail every connect before the first poll
+	timeout := env.Env.QRPollTimeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	ctx, c := context.WithTimeout(ctx, timeout)
 	defer c()
 
 	zap.L().Debug("starting observe and qr code", zap.String("id", id))
 	go s.observeConnection(client, id)
 
-	ticker := time.NewTicker(200 * time.Millisecond)
+	interval := env.Env.QRPollInterval
+	if interval <= 0 {
+		interval = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -340,23 +540,47 @@ func (s *Whatsmiau) deleteDeviceIfExists(ctx context.Context, client *whatsmeow.
 func (s *Whatsmiau) Status(id string) (Status, error) {
 	client, ok := s.clients.Load(id)
 	if !ok {
+		// banned accounts are logged out, the block is only kept on the instance
+		if instance := s.getInstanceCached(id); instance != nil && instance.Block.Active(time.Now()) {
+			return Status(instance.Block.Status), nil
+		}
 		return Closed, nil
 	}
 
-	if client.IsConnected() && client.IsLoggedIn() {
-		return Connected, nil
+	return s.clientStatus(id, client), nil
+}
+
+// clientStatus derives the status from the client and the login in progress.
+// Until the device is paired (Store.ID set) the login decides: an issued
+// pairing code is Pairing, a cached QR code is QrCode, whether or not the
+// login websocket is up at that moment.
+func (s *Whatsmiau) clientStatus(id string, client *whatsmeow.Client) Status {
+	return s.loginStatus(id, client.IsConnected(), client.IsLoggedIn(), client.Store.ID != nil)
+}
+
+func (s *Whatsmiau) loginStatus(id string, connected, loggedIn, paired bool) Status {
+	if loggedIn && connected {
+		return Connected
 	}
 
-	// If not connected, but we have a QR code, the state is QrCode
-	if _, ok := s.qrCache.Load(id); ok && client.IsConnected() {
-		return QrCode, nil
+	if block, ok := s.accountBlock(id); ok {
+		return Status(block.Status)
 	}
 
-	if client.IsLoggedIn() {
-		return Connecting, nil
+	if !loggedIn && !paired {
+		if code, ok := s.pairCodes.Load(id); ok && len(code) > 0 {
+			return Pairing
+		}
+		if _, ok := s.qrCache.Load(id); ok {
+			return QrCode
+		}
 	}
 
-	return Closed, nil
+	if loggedIn {
+		return Connecting
+	}
+
+	return Closed
 }
 
 func (s *Whatsmiau) Logout(ctx context.Context, id string) error {
@@ -366,8 +590,24 @@ func (s *Whatsmiau) Logout(ctx context.Context, id string) error {
 		return nil
 	}
 
+	// logging out disconnects the client, then pending handlers can drain
+	err := s.deleteDeviceIfExists(ctx, client)
+	s.waitHandlers(id)
 	s.clients.Delete(id)
-	return s.deleteDeviceIfExists(ctx, client)
+	s.stopAlwaysOnline(id)
+	s.forgetPresenceSubs(id)
+	s.stopReconnect(id)
+	return err
+}
+
+// ForgetInstance drops what is kept about the instance outside its client,
+// called once the instance is deleted from the repository
+func (s *Whatsmiau) ForgetInstance(ctx context.Context, id string) {
+	metrics.DeleteInstance(id)
+	s.payloadTemplates.Delete(id)
+	if err := s.templates.DeleteAll(ctx, id); err != nil {
+		zap.L().Error("failed to delete templates of deleted instance", zap.String("id", id), zap.Error(err))
+	}
 }
 
 func (s *Whatsmiau) Disconnect(id string) error {
@@ -377,7 +617,10 @@ func (s *Whatsmiau) Disconnect(id string) error {
 		return nil
 	}
 
+	s.stopReconnect(id)
 	client.Disconnect()
+	s.waitHandlers(id)
+	s.stopAlwaysOnline(id)
 	s.qrCache.Delete(id)
 	return nil
 }
@@ -398,9 +641,15 @@ func (s *Whatsmiau) extractJidLid(ctx context.Context, id string, jid types.JID)
 	}
 
 	if jid.Server == types.DefaultUserServer {
+		if lid, ok := s.cachedLidMapping(id, jid); ok {
+			return jid.ToNonAD().String(), lid
+		}
+
 		lid, err := client.Store.LIDs.GetLIDForPN(ctx, jid)
 		if err != nil {
 			zap.L().Warn("failed to get lid from store", zap.String("id", id), zap.Error(err))
+		} else {
+			s.cacheLidMapping(id, jid, lid)
 		}
 
 		return jid.ToNonAD().String(), lid.ToNonAD().String()
@@ -408,6 +657,10 @@ func (s *Whatsmiau) extractJidLid(ctx context.Context, id string, jid types.JID)
 
 	if jid.Server == types.HiddenUserServer {
 		lidString := jid.ToNonAD().String()
+		if pn, ok := s.cachedLidMapping(id, jid); ok {
+			return pn, lidString
+		}
+
 		pnJID, err := client.Store.LIDs.GetPNForLID(ctx, jid)
 		if err != nil {
 			zap.L().Warn("failed to get pn for lid", zap.Stringer("lid", jid), zap.Error(err))
@@ -415,6 +668,7 @@ func (s *Whatsmiau) extractJidLid(ctx context.Context, id string, jid types.JID)
 		}
 
 		if !pnJID.IsEmpty() {
+			s.cacheLidMapping(id, pnJID, jid)
 			return pnJID.ToNonAD().String(), lidString
 		}
 
@@ -0,0 +1,112 @@
+package whatsmiau
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+	"github.com/verbeux-ai/whatsmiau/models"
+)
+
+func TestObserveAndQrCodeTimeout(t *testing.T) {
+	previousTimeout, previousInterval := env.Env.QRPollTimeout, env.Env.QRPollInterval
+	env.Env.QRPollTimeout, env.Env.QRPollInterval = 50*time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { env.Env.QRPollTimeout, env.Env.QRPollInterval = previousTimeout, previousInterval })
+
+	s := &Whatsmiau{
+		qrCache:         xsync.NewMap[string, string](),
+		observerRunning: xsync.NewMap[string, bool](),
+	}
+	// the observer is already running, nothing connects to WhatsApp
+	s.observerRunning.Store("a", true)
+	s.observerRunning.Store("b", true)
+
+	started := time.Now()
+	code, err := s.observeAndQrCode(context.Background(), "a", nil)
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("blocked for %s with a %s poll timeout", elapsed, env.Env.QRPollTimeout)
+	}
+	if code != "" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %q, %v, want an empty code and the deadline", code, err)
+	}
+
+	s.qrCache.Store("b", "2@qr")
+	code, err = s.observeAndQrCode(context.Background(), "b", nil)
+	if code != "2@qr" || err != nil {
+		t.Fatalf("got %q, %v, want the cached code", code, err)
+	}
+
+	// a zero timeout falls back to the default instead of expiring at once
+	env.Env.QRPollTimeout = 0
+	code, err = s.observeAndQrCode(context.Background(), "b", nil)
+	if code != "2@qr" || err != nil {
+		t.Fatalf("got %q, %v with a zero timeout, want the cached code", code, err)
+	}
+}
+
+func TestClientStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		connected bool
+		loggedIn  bool
+		paired    bool
+		qrCode    string
+		pairing   bool   // pairing code login in progress
+		pairCode  string // empty while the code is requested
+		block     string
+		want      Status
+	}{
+		{name: "connected and logged in", connected: true, loggedIn: true, paired: true, want: Connected},
+		{name: "connected wins over a block", connected: true, loggedIn: true, paired: true, block: "banned", want: Connected},
+		{name: "logged in reconnecting", loggedIn: true, paired: true, want: Connecting},
+		{name: "blocked", paired: true, block: "banned", want: "banned"},
+		{name: "qr code with the socket up", connected: true, qrCode: "2@qr", want: QrCode},
+		{name: "qr code with the socket down", qrCode: "2@qr", want: QrCode},
+		{name: "pairing code issued", connected: true, pairing: true, pairCode: "ABCD1234", want: Pairing},
+		{name: "pairing code wins over a qr code", qrCode: "2@qr", pairing: true, pairCode: "ABCD1234", want: Pairing},
+		{name: "pairing code still requested", connected: true, qrCode: "2@qr", pairing: true, want: QrCode},
+		{name: "stale qr code of a paired device", qrCode: "2@qr", paired: true, want: Closed},
+		{name: "connected without login", connected: true, want: Closed},
+		{name: "nothing in progress", want: Closed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Whatsmiau{
+				qrCache:       xsync.NewMap[string, string](),
+				pairCodes:     xsync.NewMap[string, string](),
+				accountBlocks: xsync.NewMap[string, models.AccountBlock](),
+			}
+			if tt.qrCode != "" {
+				s.qrCache.Store("instance", tt.qrCode)
+			}
+			if tt.pairing {
+				s.pairCodes.Store("instance", tt.pairCode)
+			}
+			if tt.block != "" {
+				s.accountBlocks.Store("instance", models.AccountBlock{Status: tt.block, At: time.Now()})
+			}
+
+			if got := s.loginStatus("instance", tt.connected, tt.loggedIn, tt.paired); got != tt.want {
+				t.Errorf("loginStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientStatusOfDisconnectedClient(t *testing.T) {
+	s := &Whatsmiau{
+		qrCache:       xsync.NewMap[string, string](),
+		pairCodes:     xsync.NewMap[string, string](),
+		accountBlocks: xsync.NewMap[string, models.AccountBlock](),
+	}
+	s.qrCache.Store("instance", "2@qr")
+
+	// paired but without a socket, the cached qr code is stale
+	if got := s.clientStatus("instance", newTestClient(t)); got != Closed {
+		t.Errorf("clientStatus() = %q, want %q", got, Closed)
+	}
+}
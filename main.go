@@ -1,7 +1,12 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -40,7 +45,23 @@ func main() {
 	zap.L().Info("starting server...", zap.String("port", port))
 
 	s := &http2.Server{}
-	if err := app.StartH2CServer(port, s); err != nil {
-		zap.L().Fatal("failed to start server", zap.Error(err))
+	go func() {
+		if err := app.StartH2CServer(port, s); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zap.L().Fatal("failed to start server", zap.Error(err))
+		}
+	}()
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-signalCtx.Done()
+
+	zap.L().Info("shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), env.Env.ShutdownTimeout)
+	defer cancel()
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		zap.L().Error("failed to shutdown server", zap.Error(err))
+	}
+	if err := whatsmiau.Get().Shutdown(shutdownCtx); err != nil {
+		zap.L().Error("failed to shutdown whatsmiau", zap.Error(err))
 	}
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// AccountBlock is set when WhatsApp bans or temporarily blocks the account
+type AccountBlock struct {
+	Status string     `json:"status,omitempty"` // banned or temporary_block, empty clears the block on update
+	Code   int        `json:"code,omitempty"`
+	Reason string     `json:"reason,omitempty"`
+	Until  *time.Time `json:"until,omitempty"` // end of a temporary block
+	At     time.Time  `json:"at"`
+}
+
+func (b *AccountBlock) Active(now time.Time) bool {
+	if b == nil || b.Status == "" {
+		return false
+	}
+
+	return b.Until == nil || now.Before(*b.Until)
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeadLetter struct {
+	InstanceID string          `json:"instanceId,omitempty"`
+	Url        string          `json:"url,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	FailedAt   time.Time       `json:"failedAt,omitempty"`
+}
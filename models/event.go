@@ -0,0 +1,95 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookFormatEvent makes the instance receive Event envelopes instead of the
+// Evolution API shaped events
+const WebhookFormatEvent = "event"
+
+type EventType string
+
+const (
+	EventMessage     EventType = "message"
+	EventReceipt     EventType = "receipt"
+	EventPresence    EventType = "presence"
+	EventGroupUpdate EventType = "group-update"
+	EventConnection  EventType = "connection"
+)
+
+// Key is the name used in webhook.events and webhook.routes, ex: group-update
+// is GROUP_UPDATE
+func (t EventType) Key() string {
+	return strings.ToUpper(strings.ReplaceAll(string(t), "-", "_"))
+}
+
+// Event is the stable webhook envelope, its fields and payloads only change in
+// backwards compatible ways regardless of the WhatsApp library behind it.
+// Payload is one of the *Payload types below, picked by Type.
+type Event struct {
+	Type       EventType `json:"type"`
+	InstanceID string    `json:"instanceId"`
+	Timestamp  time.Time `json:"timestamp"`
+	FromJID    string    `json:"fromJID,omitempty"` // phone number JID of who caused the event
+	FromLID    string    `json:"fromLID,omitempty"`
+	Payload    any       `json:"payload"`
+}
+
+type MessagePayload struct {
+	ID        string `json:"id"`
+	ChatJID   string `json:"chatJID"`
+	ChatLID   string `json:"chatLID,omitempty"`
+	FromMe    bool   `json:"fromMe"`
+	IsGroup   bool   `json:"isGroup"`
+	Type      string `json:"type"`           // same names as messageType on messages.upsert, ex: conversation, imageMessage
+	Text      string `json:"text,omitempty"` // text or caption
+	PushName  string `json:"pushName,omitempty"`
+	QuotedID  string `json:"quotedId,omitempty"`
+	Forwarded bool   `json:"forwarded,omitempty"`
+	IsChannel bool   `json:"isChannel,omitempty"` // post of a followed newsletter, chatJID is the channel
+	ServerID  int    `json:"serverId,omitempty"`  // channel post id, needed to react to it
+	History   bool   `json:"history,omitempty"`   // replayed from an on-demand history sync
+}
+
+type ReceiptStatus string
+
+const (
+	ReceiptDelivered ReceiptStatus = "delivered"
+	ReceiptRead      ReceiptStatus = "read"
+	ReceiptPlayed    ReceiptStatus = "played"
+)
+
+type ReceiptPayload struct {
+	MessageIDs []string      `json:"messageIds"`
+	ChatJID    string        `json:"chatJID"`
+	ChatLID    string        `json:"chatLID,omitempty"`
+	Status     ReceiptStatus `json:"status"`
+	FromMe     bool          `json:"fromMe"` // read on another device of this account
+}
+
+type PresencePayload struct {
+	ChatJID  string     `json:"chatJID,omitempty"` // only for chat presences (composing, recording, paused)
+	Presence string     `json:"presence"`          // available, unavailable, composing, recording or paused
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+}
+
+type GroupUpdatePayload struct {
+	GroupJID    string   `json:"groupJID"`
+	Joined      []string `json:"joined,omitempty"`
+	Left        []string `json:"left,omitempty"`
+	Promoted    []string `json:"promoted,omitempty"`
+	Demoted     []string `json:"demoted,omitempty"`
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Announce    *bool    `json:"announce,omitempty"`     // only admins send messages
+	Locked      *bool    `json:"locked,omitempty"`       // only admins edit the group info
+	Community   string   `json:"communityJID,omitempty"` // parent community, empty for standalone groups
+}
+
+type ConnectionPayload struct {
+	State   string `json:"state"`             // open, connecting, closed or logged_out
+	Reason  string `json:"reason,omitempty"`  // logout reason, stream_replaced or reconnect_failed
+	Attempt int    `json:"attempt,omitempty"` // reconnect attempt, only with state connecting
+}
@@ -1,20 +1,38 @@
 package models
 
 type Instance struct {
-	ID                string          `json:"id,omitempty"`
-	RejectCall        bool            `json:"rejectCall,omitempty"`
-	MsgCall           string          `json:"msgCall,omitempty"`
-	GroupsIgnore      bool            `json:"groupsIgnore,omitempty"`
-	AlwaysOnline      bool            `json:"alwaysOnline,omitempty"`
-	ReadMessages      bool            `json:"readMessages,omitempty"`
-	ReadStatus        bool            `json:"readStatus,omitempty"`
-	SyncFullHistory   bool            `json:"syncFullHistory,omitempty"`
-	SyncRecentHistory bool            `json:"syncRecentHistory,omitempty"`
-	RemoteJID         string          `json:"remoteJID,omitempty"`
-	Webhook           InstanceWebhook `json:"webhook,omitempty"`
+	ID                  string          `json:"id,omitempty"`
+	RejectCall          bool            `json:"rejectCall,omitempty"`
+	MsgCall             string          `json:"msgCall,omitempty"`
+	GroupsIgnore        bool            `json:"groupsIgnore,omitempty"`
+	AlwaysOnline        *bool           `json:"alwaysOnline,omitempty"`
+	ReadMessages        bool            `json:"readMessages,omitempty"`
+	ReadStatus          bool            `json:"readStatus,omitempty"`
+	SyncFullHistory     bool            `json:"syncFullHistory,omitempty"`
+	SyncRecentHistory   bool            `json:"syncRecentHistory,omitempty"`
+	EphemeralExpiration *uint32         `json:"ephemeralExpiration,omitempty"` // default disappearing timer (seconds) for private chats
+	EmitFromMe          *bool           `json:"emitFromMe,omitempty"`          // overrides EMIT_FROM_ME
+	AutoMarkRead        *bool           `json:"autoMarkRead,omitempty"`        // overrides AUTO_MARK_READ
+	AutoDownloadMedia   *bool           `json:"autoDownloadMedia,omitempty"`   // overrides AUTO_DOWNLOAD_MEDIA
+	GenerateLinkPreview *bool           `json:"generateLinkPreview,omitempty"` // overrides GENERATE_LINK_PREVIEW
+	SendRateLimit       *int            `json:"sendRateLimit,omitempty"`       // messages per minute, overrides SEND_RATE_LIMIT, 0 = unbounded
+	Locale              string          `json:"locale,omitempty"`              // Accept-Language for link previews and media fetches, ex: pt-BR
+	GroupAutoJoin       *GroupAutoJoin  `json:"groupAutoJoin,omitempty"`
+	IgnoreInbound       []string        `json:"ignoreInbound,omitempty"` // event categories dropped before handling: status, newsletter, broadcast, group, presence, receipt
+	RemoteJID           string          `json:"remoteJID,omitempty"`
+	Block               *AccountBlock   `json:"block,omitempty"` // set by the service when the account is banned or temporarily blocked
+	Webhook             InstanceWebhook `json:"webhook,omitempty"`
+	ProxyPool           []string        `json:"proxyPool,omitempty"` // proxy urls used instead of InstanceProxy, rotates to the next healthy one on repeated connection failures
 	InstanceProxy
 }
 
+// GroupAutoJoin accepts group invites sent by the whitelisted inviters, an empty
+// whitelist accepts none
+type GroupAutoJoin struct {
+	Inviters  []string `json:"inviters,omitempty"`  // numbers or JIDs
+	MaxGroups int      `json:"maxGroups,omitempty"` // invites are ignored once the account is in this many groups, 0 uses GROUP_AUTO_JOIN_MAX_GROUPS
+}
+
 type InstanceProxy struct {
 	ProxyHost     string `json:"proxyHost,omitempty"`
 	ProxyPort     string `json:"proxyPort,omitempty"`
@@ -27,6 +45,11 @@ type InstanceWebhook struct {
 	Url      string            `json:"url,omitempty"`
 	ByEvents *bool             `json:"byEvents,omitempty"`
 	Base64   *bool             `json:"base64,omitempty"`
+	Raw      *bool             `json:"raw,omitempty"` // adds the base64 whatsmeow protobuf to message events
 	Headers  map[string]string `json:"headers,omitempty"`
+	Secret   *string           `json:"secret,omitempty"` // signs deliveries with X-Whatsmiau-Signature, overrides WEBHOOK_SECRET
 	Events   []string          `json:"events,omitempty"`
+	Template *string           `json:"template,omitempty"` // Go text/template reshaping the event payload, see whatsmiau.ParsePayloadTemplate
+	Format   string            `json:"format,omitempty"`   // "event" sends models.Event envelopes instead of the Evolution API events
+	Routes   map[string]string `json:"routes,omitempty"`   // event (ex: MESSAGES_UPSERT) to webhook url, other events go to Url
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type StoredMedia struct {
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// StorageQuery lists the objects under Prefix whose key sorts in
+// [StartOffset, EndOffset), empty offsets are unbounded
+type StorageQuery struct {
+	Prefix      string
+	StartOffset string
+	EndOffset   string
+	PageToken   string
+	Limit       int
+}
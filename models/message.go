@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// StoredMessage is what the message store keeps for dedup, reply-quoting and
+// the chat history. Only normalized fields are kept, never the raw message, so
+// thumbnails and context don't bloat the store. Media and Poll are dropped
+// (Truncated) when the message is larger than the configured cap.
+type StoredMessage struct {
+	InstanceID string           `json:"instanceId"`
+	ID         string           `json:"id"`
+	Chat       string           `json:"chat"`
+	ChatJID    string           `json:"chatJid,omitempty"` // chat with lids resolved to the phone number, indexes the chat history
+	Sender     string           `json:"sender,omitempty"`
+	SenderJID  string           `json:"senderJid,omitempty"` // sender resolved to the phone number
+	SenderLID  string           `json:"senderLid,omitempty"`
+	FromMe     bool             `json:"fromMe,omitempty"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Type       string           `json:"type,omitempty"`
+	Text       string           `json:"text,omitempty"`     // text or caption
+	MediaURL   string           `json:"mediaUrl,omitempty"` // inbound media uploaded to the storage
+	Media      *MediaDescriptor `json:"media,omitempty"`
+	Poll       *StoredPoll      `json:"poll,omitempty"`
+	Truncated  bool             `json:"truncated,omitempty"`
+}
+
+// MediaDescriptor is what downloading the media again needs, including asking the
+// sender to re-upload it once expired
+type MediaDescriptor struct {
+	Kind          string `json:"kind"` // image, video, audio, document or sticker
+	DirectPath    string `json:"directPath"`
+	MediaKey      []byte `json:"mediaKey"`
+	FileEncSHA256 []byte `json:"fileEncSha256"`
+	FileSHA256    []byte `json:"fileSha256"`
+	FileLength    uint64 `json:"fileLength,omitempty"`
+	Mimetype      string `json:"mimetype,omitempty"`
+	FileName      string `json:"fileName,omitempty"`
+}
+
+// StoredPoll is what vote aggregation needs from a poll creation message
+type StoredPoll struct {
+	Name    string   `json:"name"`
+	Options []string `json:"options"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ScheduledMessage struct {
+	ID         string          `json:"id,omitempty"`
+	InstanceID string          `json:"instanceId,omitempty"`
+	RemoteJID  string          `json:"remoteJid,omitempty"`
+	Message    json.RawMessage `json:"message,omitempty"` // whatsmiau.ScheduledMessage
+	At         time.Time       `json:"at"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	Attempts   int             `json:"attempts,omitempty"` // failed sends so far
+}
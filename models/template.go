@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MessageTemplate is a named text with {{var}} placeholders stored per instance
+type MessageTemplate struct {
+	InstanceID string    `json:"instanceId"`
+	Name       string    `json:"name"`
+	Text       string    `json:"text"`
+	Variables  []string  `json:"variables"` // placeholders found in Text, all required to send
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,71 @@
+package deadletters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisDeadLetter follows dead letter interface pattern
+var _ interfaces.DeadLetterRepository = (*RedisDeadLetter)(nil)
+
+type RedisDeadLetter struct {
+	db      *redis.Client
+	maxSize int64
+}
+
+func (s *RedisDeadLetter) key(id string) string {
+	return fmt.Sprintf("dead_letter_%s", id)
+}
+
+// NewRedis creates a dead letter queue stored as one redis list per instance,
+// keeping only the newest maxSize entries (0 means unbounded)
+func NewRedis(client *redis.Client, maxSize int) *RedisDeadLetter {
+	return &RedisDeadLetter{
+		db:      client,
+		maxSize: int64(maxSize),
+	}
+}
+
+func (s *RedisDeadLetter) Push(ctx context.Context, id string, letter *models.DeadLetter) error {
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.RPush(ctx, s.key(id), data).Err(); err != nil {
+		return err
+	}
+
+	if s.maxSize > 0 {
+		return s.db.LTrim(ctx, s.key(id), -s.maxSize, -1).Err()
+	}
+
+	return nil
+}
+
+func (s *RedisDeadLetter) Pop(ctx context.Context, id string, max int) ([]models.DeadLetter, error) {
+	var result []models.DeadLetter
+	for len(result) < max {
+		data, err := s.db.LPop(ctx, s.key(id)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		var letter models.DeadLetter
+		if err := json.Unmarshal(data, &letter); err != nil {
+			return result, err
+		}
+		result = append(result, letter)
+	}
+
+	return result, nil
+}
@@ -70,15 +70,79 @@ func (s *RedisInstance) Update(ctx context.Context, id string, toUpdate *models.
 	if len(toUpdate.RemoteJID) > 0 {
 		oldInstance.RemoteJID = toUpdate.RemoteJID
 	}
+	if toUpdate.EphemeralExpiration != nil {
+		oldInstance.EphemeralExpiration = toUpdate.EphemeralExpiration
+	}
+	if len(toUpdate.Locale) > 0 {
+		oldInstance.Locale = toUpdate.Locale
+	}
+	if toUpdate.EmitFromMe != nil {
+		oldInstance.EmitFromMe = toUpdate.EmitFromMe
+	}
+	if toUpdate.AutoMarkRead != nil {
+		oldInstance.AutoMarkRead = toUpdate.AutoMarkRead
+	}
+	if toUpdate.AutoDownloadMedia != nil {
+		oldInstance.AutoDownloadMedia = toUpdate.AutoDownloadMedia
+	}
+	if toUpdate.GenerateLinkPreview != nil {
+		oldInstance.GenerateLinkPreview = toUpdate.GenerateLinkPreview
+	}
+	if toUpdate.SendRateLimit != nil {
+		oldInstance.SendRateLimit = toUpdate.SendRateLimit
+	}
+	if toUpdate.IgnoreInbound != nil {
+		oldInstance.IgnoreInbound = toUpdate.IgnoreInbound
+	}
+	if toUpdate.GroupAutoJoin != nil {
+		oldInstance.GroupAutoJoin = toUpdate.GroupAutoJoin
+	}
+	if toUpdate.ProxyPool != nil {
+		oldInstance.ProxyPool = toUpdate.ProxyPool
+	}
+	if toUpdate.Block != nil {
+		if toUpdate.Block.Status == "" {
+			oldInstance.Block = nil
+		} else {
+			oldInstance.Block = toUpdate.Block
+		}
+	}
+	if toUpdate.AlwaysOnline != nil {
+		oldInstance.AlwaysOnline = toUpdate.AlwaysOnline
+	}
 	if toUpdate.Webhook.Url != "" {
 		oldInstance.Webhook.Url = toUpdate.Webhook.Url
 	}
+	if toUpdate.Webhook.Secret != nil {
+		if *toUpdate.Webhook.Secret == "" {
+			oldInstance.Webhook.Secret = nil
+		} else {
+			oldInstance.Webhook.Secret = toUpdate.Webhook.Secret
+		}
+	}
+	if toUpdate.Webhook.Template != nil {
+		if *toUpdate.Webhook.Template == "" {
+			oldInstance.Webhook.Template = nil
+		} else {
+			oldInstance.Webhook.Template = toUpdate.Webhook.Template
+		}
+	}
+	if toUpdate.Webhook.Format != "" {
+		oldInstance.Webhook.Format = toUpdate.Webhook.Format
+	}
 	if toUpdate.Webhook.ByEvents != nil {
 		oldInstance.Webhook.ByEvents = toUpdate.Webhook.ByEvents
 	}
 	if toUpdate.Webhook.Base64 != nil {
 		oldInstance.Webhook.Base64 = toUpdate.Webhook.Base64
 	}
+	if toUpdate.Webhook.Raw != nil {
+		oldInstance.Webhook.Raw = toUpdate.Webhook.Raw
+	}
+	if toUpdate.Webhook.Routes != nil {
+		// replaced as a whole so routes can be removed, an empty map clears them
+		oldInstance.Webhook.Routes = toUpdate.Webhook.Routes
+	}
 	if toUpdate.Webhook.Headers != nil {
 		if oldInstance.Webhook.Headers == nil {
 			oldInstance.Webhook.Headers = map[string]string{}
@@ -0,0 +1,181 @@
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisMessage follows message interface pattern
+var _ interfaces.MessageRepository = (*RedisMessage)(nil)
+
+var ErrorNotFound = errors.New("message not found")
+
+type RedisMessage struct {
+	db  *redis.Client
+	ttl time.Duration
+}
+
+func (s *RedisMessage) key(instanceID, messageID string) string {
+	return fmt.Sprintf("message_%s_%s", instanceID, messageID)
+}
+
+func (s *RedisMessage) chatKey(instanceID, chat string) string {
+	return fmt.Sprintf("chat_messages_%s_%s", instanceID, chat)
+}
+
+func (s *RedisMessage) votesKey(instanceID, pollID string) string {
+	return fmt.Sprintf("poll_votes_%s_%s", instanceID, pollID)
+}
+
+// NewRedis creates a message store with one key per message expiring after ttl
+// (0 means no expiration), plus one sorted set per chat indexing its messages
+// by timestamp
+func NewRedis(client *redis.Client, ttl time.Duration) *RedisMessage {
+	return &RedisMessage{
+		db:  client,
+		ttl: ttl,
+	}
+}
+
+func (s *RedisMessage) Save(ctx context.Context, message *models.StoredMessage) (bool, error) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return false, err
+	}
+
+	created, err := s.db.SetNX(ctx, s.key(message.InstanceID, message.ID), data, s.ttl).Result()
+	if err != nil || !created || len(message.ChatJID) <= 0 {
+		return created, err
+	}
+
+	key := s.chatKey(message.InstanceID, message.ChatJID)
+	pipe := s.db.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(message.Timestamp.UnixMilli()), Member: message.ID})
+	if s.ttl > 0 {
+		// ids of expired messages are dropped with the index
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", time.Now().Add(-s.ttl).UnixMilli()))
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return true, err
+}
+
+func (s *RedisMessage) Get(ctx context.Context, instanceID, messageID string) (*models.StoredMessage, error) {
+	data, err := s.db.Get(ctx, s.key(instanceID, messageID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrorNotFound
+		}
+		return nil, err
+	}
+
+	var message models.StoredMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (s *RedisMessage) ListChat(ctx context.Context, instanceID, chat string, limit int, before string) ([]models.StoredMessage, error) {
+	key := s.chatKey(instanceID, chat)
+
+	var start int64
+	if len(before) > 0 {
+		rank, err := s.db.ZRevRank(ctx, key, before).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil, ErrorNotFound
+			}
+			return nil, err
+		}
+		start = rank + 1
+	}
+
+	ids, err := s.db.ZRevRange(ctx, key, start, start+int64(limit)-1).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.key(instanceID, id)
+	}
+
+	values, err := s.db.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.StoredMessage, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok { // expired
+			continue
+		}
+
+		var message models.StoredMessage
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			return nil, err
+		}
+		result = append(result, message)
+	}
+
+	return result, nil
+}
+
+func (s *RedisMessage) SetMediaURL(ctx context.Context, instanceID, messageID, url string) error {
+	message, err := s.Get(ctx, instanceID, messageID)
+	if err != nil {
+		return err
+	}
+
+	message.MediaURL = url
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(ctx, s.key(instanceID, messageID), data, redis.KeepTTL).Err()
+}
+
+func (s *RedisMessage) SaveVote(ctx context.Context, instanceID, pollID, voter string, options [][]byte) error {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+
+	key := s.votesKey(instanceID, pollID)
+	pipe := s.db.TxPipeline()
+	pipe.HSet(ctx, key, voter, data)
+	if s.ttl > 0 {
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisMessage) GetVotes(ctx context.Context, instanceID, pollID string) (map[string][][]byte, error) {
+	values, err := s.db.HGetAll(ctx, s.votesKey(instanceID, pollID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make(map[string][][]byte, len(values))
+	for voter, data := range values {
+		var options [][]byte
+		if err := json.Unmarshal([]byte(data), &options); err != nil {
+			return nil, err
+		}
+		votes[voter] = options
+	}
+
+	return votes, nil
+}
@@ -0,0 +1,87 @@
+package schedules
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisSchedule follows schedule interface pattern
+var _ interfaces.ScheduleRepository = (*RedisSchedule)(nil)
+
+const key = "scheduled_messages"
+
+var ErrorNotFound = errors.New("scheduled message not found")
+
+type RedisSchedule struct {
+	db *redis.Client
+}
+
+// NewRedis creates a schedule store with every pending message in a single
+// redis hash, they are all loaded on boot
+func NewRedis(client *redis.Client) *RedisSchedule {
+	return &RedisSchedule{
+		db: client,
+	}
+}
+
+func (s *RedisSchedule) Save(ctx context.Context, message *models.ScheduledMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return s.db.HSet(ctx, key, message.ID, data).Err()
+}
+
+func (s *RedisSchedule) List(ctx context.Context) ([]models.ScheduledMessage, error) {
+	values, err := s.db.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.ScheduledMessage, 0, len(values))
+	for _, data := range values {
+		var message models.ScheduledMessage
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			return nil, err
+		}
+		result = append(result, message)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].At.Before(result[j].At)
+	})
+
+	return result, nil
+}
+
+func (s *RedisSchedule) Get(ctx context.Context, id string) (*models.ScheduledMessage, error) {
+	data, err := s.db.HGet(ctx, key, id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrorNotFound
+		}
+		return nil, err
+	}
+
+	var message models.ScheduledMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+func (s *RedisSchedule) Delete(ctx context.Context, id string) (bool, error) {
+	deleted, err := s.db.HDel(ctx, key, id).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
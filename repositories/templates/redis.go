@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/models"
+	"golang.org/x/net/context"
+)
+
+// These verify if RedisTemplate follows template interface pattern
+var _ interfaces.TemplateRepository = (*RedisTemplate)(nil)
+
+var ErrorNotFound = errors.New("template not found")
+
+type RedisTemplate struct {
+	db *redis.Client
+}
+
+func (s *RedisTemplate) key(instanceID string) string {
+	return fmt.Sprintf("templates_%s", instanceID)
+}
+
+// NewRedis creates a template store with one redis hash per instance
+func NewRedis(client *redis.Client) *RedisTemplate {
+	return &RedisTemplate{
+		db: client,
+	}
+}
+
+func (s *RedisTemplate) Save(ctx context.Context, template *models.MessageTemplate) error {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	return s.db.HSet(ctx, s.key(template.InstanceID), template.Name, data).Err()
+}
+
+func (s *RedisTemplate) Get(ctx context.Context, instanceID, name string) (*models.MessageTemplate, error) {
+	data, err := s.db.HGet(ctx, s.key(instanceID), name).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrorNotFound
+		}
+		return nil, err
+	}
+
+	var template models.MessageTemplate
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (s *RedisTemplate) List(ctx context.Context, instanceID string) ([]models.MessageTemplate, error) {
+	values, err := s.db.HGetAll(ctx, s.key(instanceID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.MessageTemplate, 0, len(values))
+	for _, data := range values {
+		var template models.MessageTemplate
+		if err := json.Unmarshal([]byte(data), &template); err != nil {
+			return nil, err
+		}
+		result = append(result, template)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+func (s *RedisTemplate) Delete(ctx context.Context, instanceID, name string) error {
+	deleted, err := s.db.HDel(ctx, s.key(instanceID), name).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrorNotFound
+	}
+
+	return nil
+}
+
+func (s *RedisTemplate) DeleteAll(ctx context.Context, instanceID string) error {
+	return s.db.Del(ctx, s.key(instanceID)).Err()
+}
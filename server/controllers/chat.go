@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/server/dto"
 	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 	"go.uber.org/zap"
 )
@@ -36,25 +39,40 @@ func (s *Chat) ReadMessages(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
 	}
 
-	result := make(map[string][]string)
+	// one receipt per chat and sender, group receipts must name the sender
+	type receiptKey struct{ remoteJid, sender string }
+	result := make(map[receiptKey][]string)
 	for _, msg := range request.ReadMessages {
-		result[msg.RemoteJid] = append(result[msg.RemoteJid], msg.ID)
+		key := receiptKey{msg.RemoteJid, msg.Sender}
+		result[key] = append(result[key], msg.ID)
 	}
 
-	for remoteJid, msgs := range result {
-		number, err := numberToJid(remoteJid)
+	c := ctx.Request().Context()
+	for key, msgs := range result {
+		number, err := numberToJid(key.remoteJid)
 		if err != nil {
 			zap.L().Error("error converting number to jid", zap.Error(err))
 			continue
 		}
 
-		if err := s.whatsmiau.ReadMessage(&whatsmiau.ReadMessageRequest{
+		var sender *types.JID
+		if key.sender != "" {
+			if sender, err = numberToJid(key.sender); err != nil {
+				zap.L().Error("error converting sender to jid", zap.Error(err))
+				continue
+			}
+		}
+
+		if err := s.whatsmiau.MarkRead(c, &whatsmiau.ReadMessageRequest{
 			MessageIDs: msgs,
 			InstanceID: request.InstanceID,
 			RemoteJID:  number,
-			Sender:     nil,
+			Sender:     sender,
 		}); err != nil {
-			zap.L().Error("Whatsmiau.ReadMessages failed", zap.Error(err))
+			if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+				return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not connected")
+			}
+			zap.L().Error("Whatsmiau.MarkRead failed", zap.Error(err))
 		}
 	}
 
@@ -78,22 +96,25 @@ func (s *Chat) SendChatPresence(ctx echo.Context) error {
 	}
 
 	var presence types.ChatPresence
+	presenceType := types.ChatPresenceMediaText
+	if request.Type == dto.PresenceTypeAudio {
+		presenceType = types.ChatPresenceMediaAudio
+	}
+
 	switch request.Presence {
 	case dto.PresenceComposing:
 		presence = types.ChatPresenceComposing
-	case dto.PresenceAvailable:
-		presence = types.ChatPresencePaused
-	}
-
-	presenceType := types.ChatPresenceMediaText
-	if request.Type == dto.PresenceTypeAudio {
+	case dto.PresenceRecording:
+		presence = types.ChatPresenceComposing
 		presenceType = types.ChatPresenceMediaAudio
+	case dto.PresenceAvailable, dto.PresencePaused:
+		presence = types.ChatPresencePaused
 	}
 
 	if request.Delay > 0 {
 		go func() {
 			time.Sleep(time.Duration(request.Delay) * time.Millisecond)
-			if err := s.whatsmiau.ChatPresence(&whatsmiau.ChatPresenceRequest{
+			if err := s.whatsmiau.ChatPresence(context.Background(), &whatsmiau.ChatPresenceRequest{
 				InstanceID: request.InstanceID,
 				RemoteJID:  number,
 				Presence:   types.ChatPresencePaused,
@@ -104,19 +125,78 @@ func (s *Chat) SendChatPresence(ctx echo.Context) error {
 		}()
 	}
 
-	if err := s.whatsmiau.ChatPresence(&whatsmiau.ChatPresenceRequest{
+	if err := s.whatsmiau.ChatPresence(ctx.Request().Context(), &whatsmiau.ChatPresenceRequest{
 		InstanceID: request.InstanceID,
 		RemoteJID:  number,
 		Presence:   presence,
 		Media:      presenceType,
 	}); err != nil {
-		zap.L().Error("Whatsmiau.ReadMessages failed", zap.Error(err))
+		if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not connected")
+		}
+		zap.L().Error("Whatsmiau.ChatPresence failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "Whatsmiau.ChatPresence failed")
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]interface{}{})
 }
 
+func (s *Chat) SendPresence(ctx echo.Context) error {
+	var request dto.SendPresenceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if err := s.whatsmiau.SendPresence(ctx.Request().Context(), &whatsmiau.SendPresenceRequest{
+		InstanceID: request.InstanceID,
+		Available:  request.Presence == dto.PresenceAvailable,
+	}); err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not connected")
+		case errors.Is(err, whatsmeow.ErrNoPushName):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "account has no push name yet")
+		}
+		zap.L().Error("Whatsmiau.SendPresence failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "Whatsmiau.SendPresence failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendPresenceResponse{Presence: request.Presence})
+}
+
+func (s *Chat) SubscribePresence(ctx echo.Context) error {
+	var request dto.SubscribePresenceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	if err := s.whatsmiau.SubscribePresence(ctx.Request().Context(), request.InstanceID, *jid); err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not connected")
+		case errors.Is(err, whatsmiau.ErrPresenceOffline):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "send an available presence first")
+		}
+		zap.L().Error("Whatsmiau.SubscribePresence failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to subscribe presence")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
 func (s *Chat) NumberExists(ctx echo.Context) error {
 	instanceID := ctx.Param("instance")
 	if instanceID == "" {
@@ -143,3 +223,274 @@ func (s *Chat) NumberExists(ctx echo.Context) error {
 
 	return ctx.JSON(http.StatusOK, response)
 }
+
+func (s *Chat) OnWhatsApp(ctx echo.Context) error {
+	var request dto.OnWhatsAppRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	response, err := s.whatsmiau.IsOnWhatsApp(ctx.Request().Context(), request.InstanceID, request.Numbers)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not connected")
+		}
+		zap.L().Error("Whatsmiau.IsOnWhatsApp failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to check numbers")
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Chat) ProfilePicture(ctx echo.Context) error {
+	var request dto.ProfilePictureRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	info, err := s.whatsmiau.GetProfilePicture(ctx.Request().Context(), request.InstanceID, *jid, request.Preview, request.ExistingID)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		case errors.Is(err, whatsmiau.ErrNoProfilePicture):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "no profile picture")
+		case errors.Is(err, whatsmiau.ErrProfilePictureHidden):
+			return utils.HTTPFail(ctx, http.StatusForbidden, err, "profile picture hidden by privacy settings")
+		}
+		zap.L().Error("Whatsmiau.GetProfilePicture failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get profile picture")
+	}
+
+	return ctx.JSON(http.StatusOK, info)
+}
+
+func (s *Chat) GetMessages(ctx echo.Context) error {
+	var request dto.GetMessagesRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	result, err := s.whatsmiau.GetMessages(ctx.Request().Context(), request.InstanceID, *jid, request.Limit, request.Before)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrMessageStoreDisabled):
+			return utils.HTTPFail(ctx, http.StatusNotImplemented, err, "message store is disabled")
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "before message not found")
+		}
+		zap.L().Error("Whatsmiau.GetMessages failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get messages")
+	}
+
+	if result == nil {
+		return ctx.JSON(http.StatusOK, []string{})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (s *Chat) RequestHistory(ctx echo.Context) error {
+	var request dto.RequestHistoryRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	if request.Count <= 0 {
+		request.Count = 50
+	}
+
+	if err := s.whatsmiau.RequestHistory(ctx.Request().Context(), request.InstanceID, *jid, request.Count, request.Before); err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrHistorySyncUnavailable):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "history sync is unavailable")
+		case errors.Is(err, whatsmiau.ErrHistorySyncInProgress):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "history sync already requested for this chat")
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "anchor message not found")
+		}
+		zap.L().Error("Whatsmiau.RequestHistory failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to request history")
+	}
+
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+func (s *Chat) SetBlocked(ctx echo.Context) error {
+	var request dto.SetBlockedRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	if err := s.whatsmiau.SetBlocked(ctx.Request().Context(), request.InstanceID, *jid, *request.Blocked); err != nil {
+		if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SetBlocked failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to update blocklist")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Chat) Blocklist(ctx echo.Context) error {
+	var request dto.BlocklistRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jids, err := s.whatsmiau.GetBlocklist(ctx.Request().Context(), request.InstanceID)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.GetBlocklist failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get blocklist")
+	}
+
+	response := dto.BlocklistResponse{Blocked: make([]string, 0, len(jids))}
+	for _, jid := range jids {
+		response.Blocked = append(response.Blocked, jid.String())
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Chat) BusinessProfile(ctx echo.Context) error {
+	var request dto.BusinessProfileRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	profile, err := s.whatsmiau.GetBusinessProfile(ctx.Request().Context(), request.InstanceID, *jid)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		case errors.Is(err, whatsmiau.ErrNotBusiness):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "not a business account")
+		}
+		zap.L().Error("Whatsmiau.GetBusinessProfile failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get business profile")
+	}
+
+	response := dto.BusinessProfileResponse{
+		Jid:         profile.JID.String(),
+		Description: profile.Description,
+		Websites:    profile.Websites,
+		Address:     profile.Address,
+		Email:       profile.Email,
+		Categories:  make([]dto.BusinessCategory, 0, len(profile.Categories)),
+		TimeZone:    profile.BusinessHoursTimeZone,
+		Options:     profile.ProfileOptions,
+	}
+	for _, category := range profile.Categories {
+		response.Categories = append(response.Categories, dto.BusinessCategory{ID: category.ID, Name: category.Name})
+	}
+	for _, hours := range profile.BusinessHours {
+		response.BusinessHours = append(response.BusinessHours, dto.BusinessHoursConfig{
+			DayOfWeek: hours.DayOfWeek,
+			Mode:      hours.Mode,
+			OpenTime:  hours.OpenTime,
+			CloseTime: hours.CloseTime,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Chat) KeepMessage(ctx echo.Context) error {
+	var request dto.KeepMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	remoteJid, err := numberToJid(request.RemoteJid)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	var participant *types.JID
+	if len(request.Participant) > 0 {
+		participant, err = numberToJid(request.Participant)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant format")
+		}
+	}
+
+	res, err := s.whatsmiau.KeepMessage(ctx.Request().Context(), &whatsmiau.KeepMessageRequest{
+		InstanceID:  request.InstanceID,
+		RemoteJID:   remoteJid,
+		MessageID:   request.ID,
+		FromMe:      request.FromMe,
+		Participant: participant,
+		Keep:        request.Keep,
+	})
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
+		zap.L().Error("Whatsmiau.KeepMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to keep message")
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,525 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/dto"
+	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+type Group struct {
+	repo      interfaces.InstanceRepository
+	whatsmiau *whatsmiau.Whatsmiau
+}
+
+func NewGroups(repository interfaces.InstanceRepository, whatsmiau *whatsmiau.Whatsmiau) *Group {
+	return &Group{
+		repo:      repository,
+		whatsmiau: whatsmiau,
+	}
+}
+
+func (s *Group) Create(ctx echo.Context) error {
+	var request dto.CreateGroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	participants, err := numbersToJids(request.Participants)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant number")
+	}
+
+	info, err := s.whatsmiau.CreateGroup(ctx.Request().Context(), &whatsmiau.CreateGroupRequest{
+		InstanceID:   request.InstanceID,
+		Name:         request.Subject,
+		Participants: participants,
+	})
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.CreateGroup failed")
+	}
+
+	return ctx.JSON(http.StatusCreated, groupResponse(info))
+}
+
+func (s *Group) UpdateParticipants(ctx echo.Context) error {
+	var request dto.UpdateGroupParticipantsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	participants, err := numbersToJids(request.Participants)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant number")
+	}
+
+	result, err := s.whatsmiau.UpdateGroupParticipants(ctx.Request().Context(), &whatsmiau.UpdateGroupParticipantsRequest{
+		InstanceID:   request.InstanceID,
+		Group:        *group,
+		Action:       whatsmiau.ParticipantAction(request.Action),
+		Participants: participants,
+	})
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.UpdateGroupParticipants failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupParticipantsResponse{
+		Participants: participantsResponse(result),
+	})
+}
+
+func (s *Group) Info(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	info, err := s.whatsmiau.GetGroupInfo(ctx.Request().Context(), request.InstanceID, *group)
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.GetGroupInfo failed")
+	}
+
+	return ctx.JSON(http.StatusOK, groupResponse(info))
+}
+
+func (s *Group) UpdateSubject(ctx echo.Context) error {
+	var request dto.UpdateGroupSubjectRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	if err := s.whatsmiau.SetGroupName(ctx.Request().Context(), &whatsmiau.SetGroupNameRequest{
+		InstanceID: request.InstanceID,
+		Group:      *group,
+		Name:       request.Subject,
+	}); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.SetGroupName failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Group) UpdateDescription(ctx echo.Context) error {
+	var request dto.UpdateGroupDescriptionRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	if err := s.whatsmiau.SetGroupDescription(ctx.Request().Context(), &whatsmiau.SetGroupDescriptionRequest{
+		InstanceID:  request.InstanceID,
+		Group:       *group,
+		Description: request.Description,
+	}); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.SetGroupDescription failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Group) UpdatePicture(ctx echo.Context) error {
+	var request dto.UpdateGroupPictureRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	var photo *whatsmiau.MediaInput
+	if request.Image != "" {
+		if photo, err = imageToMediaInput(request.Image); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid image")
+		}
+	}
+
+	pictureID, err := s.whatsmiau.SetGroupPhoto(ctx.Request().Context(), &whatsmiau.SetGroupPhotoRequest{
+		InstanceID: request.InstanceID,
+		Group:      *group,
+		Photo:      photo,
+	})
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.SetGroupPhoto failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.UpdateGroupPictureResponse{
+		PictureID: pictureID,
+	})
+}
+
+func (s *Group) InviteLink(ctx echo.Context) error {
+	return s.inviteLink(ctx, false)
+}
+
+func (s *Group) RevokeInviteLink(ctx echo.Context) error {
+	return s.inviteLink(ctx, true)
+}
+
+func (s *Group) inviteLink(ctx echo.Context, reset bool) error {
+	var request dto.GroupInviteLinkRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	link, err := s.whatsmiau.GetGroupInviteLink(ctx.Request().Context(), request.InstanceID, *group, reset)
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.GetGroupInviteLink failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.GroupInviteLinkResponse{
+		Link: link,
+	})
+}
+
+func (s *Group) InfoFromLink(ctx echo.Context) error {
+	var request dto.GroupLinkRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	info, err := s.whatsmiau.GetGroupInfoFromLink(ctx.Request().Context(), request.InstanceID, request.Link)
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.GetGroupInfoFromLink failed")
+	}
+
+	return ctx.JSON(http.StatusOK, groupResponse(info))
+}
+
+func (s *Group) Join(ctx echo.Context) error {
+	var request dto.GroupLinkRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := s.whatsmiau.JoinGroupWithLink(ctx.Request().Context(), request.InstanceID, request.Link)
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.JoinGroupWithLink failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.JoinGroupResponse{
+		ID: group.String(),
+	})
+}
+
+func (s *Group) UpdateSetting(ctx echo.Context) error {
+	var request dto.UpdateGroupSettingRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	if err := s.whatsmiau.SetGroupSetting(ctx.Request().Context(), &whatsmiau.SetGroupSettingRequest{
+		InstanceID: request.InstanceID,
+		Group:      *group,
+		Setting:    whatsmiau.GroupSetting(request.Setting),
+		Enabled:    *request.Enabled,
+	}); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.SetGroupSetting failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Group) Leave(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	group, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	if err := s.whatsmiau.LeaveGroup(ctx.Request().Context(), request.InstanceID, *group); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.LeaveGroup failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Group) SubGroups(ctx echo.Context) error {
+	var request dto.GroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	community, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	subGroups, err := s.whatsmiau.GetSubGroups(ctx.Request().Context(), request.InstanceID, *community)
+	if err != nil {
+		return groupFail(ctx, err, "Whatsmiau.GetSubGroups failed")
+	}
+
+	result := make([]dto.SubGroupResponse, 0, len(subGroups))
+	for _, subGroup := range subGroups {
+		result = append(result, dto.SubGroupResponse{
+			ID:                subGroup.JID.String(),
+			Subject:           subGroup.Name,
+			IsDefaultSubGroup: subGroup.IsDefaultSubGroup,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (s *Group) LinkSubGroup(ctx echo.Context) error {
+	var request dto.LinkSubGroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	community, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	subGroup, err := groupToJid(request.SubGroup)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid sub group")
+	}
+
+	if err := s.whatsmiau.LinkGroup(ctx.Request().Context(), request.InstanceID, *community, *subGroup); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.LinkGroup failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Group) UnlinkSubGroup(ctx echo.Context) error {
+	var request dto.UnlinkSubGroupRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	community, err := groupToJid(request.Group)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	}
+
+	subGroup, err := groupToJid(request.SubGroup)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid sub group")
+	}
+
+	if err := s.whatsmiau.UnlinkGroup(ctx.Request().Context(), request.InstanceID, *community, *subGroup); err != nil {
+		return groupFail(ctx, err, "Whatsmiau.UnlinkGroup failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func groupFail(ctx echo.Context, err error, log string) error {
+	switch {
+	case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+		return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+	case errors.Is(err, whatsmiau.ErrNoParticipants):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participants")
+	case errors.Is(err, whatsmiau.ErrInvalidGroup):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid group")
+	case errors.Is(err, whatsmiau.ErrNotCommunity):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "group is not a community")
+	case errors.Is(err, whatsmiau.ErrNotGroupAdmin):
+		return utils.HTTPFail(ctx, http.StatusForbidden, err, "instance is not a group admin")
+	case errors.Is(err, whatsmeow.ErrGroupNotFound):
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "group not found")
+	case errors.Is(err, whatsmiau.ErrInvalidInviteLink):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid invite link")
+	case errors.Is(err, whatsmiau.ErrInviteLinkExpired):
+		return utils.HTTPFail(ctx, http.StatusGone, err, "invite link expired")
+	case errors.Is(err, whatsmeow.ErrGroupInviteLinkUnauthorized):
+		return utils.HTTPFail(ctx, http.StatusForbidden, err, "instance can't get the group invite link")
+	case errors.Is(err, whatsmeow.ErrInvalidImageFormat):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid image")
+	case errors.Is(err, whatsmeow.ErrNotInGroup):
+		return utils.HTTPFail(ctx, http.StatusForbidden, err, "instance is not in the group")
+	}
+
+	zap.L().Error(log, zap.Error(err))
+	return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "group request failed")
+}
+
+func numbersToJids(numbers []string) ([]types.JID, error) {
+	result := make([]types.JID, 0, len(numbers))
+	for _, number := range numbers {
+		jid, err := numberToJid(number)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *jid)
+	}
+
+	return result, nil
+}
+
+// imageToMediaInput takes an http(s) URL or base64 content, with or without
+// the data URI prefix
+func imageToMediaInput(image string) (*whatsmiau.MediaInput, error) {
+	if strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://") {
+		return &whatsmiau.MediaInput{URL: image}, nil
+	}
+
+	if _, encoded, ok := strings.Cut(image, ";base64,"); ok && strings.HasPrefix(image, "data:") {
+		image = encoded
+	}
+
+	data, err := base64.StdEncoding.DecodeString(image)
+	if err != nil {
+		return nil, err
+	}
+
+	return &whatsmiau.MediaInput{Data: data}, nil
+}
+
+func groupResponse(info *types.GroupInfo) dto.GroupResponse {
+	result := dto.GroupResponse{
+		ID:           info.JID.String(),
+		Subject:      info.Name,
+		Desc:         info.Topic,
+		Size:         len(info.Participants),
+		Restrict:     info.IsLocked,
+		Announce:     info.IsAnnounce,
+		Participants: participantsResponse(info.Participants),
+	}
+	if !info.NameSetBy.IsEmpty() {
+		result.SubjectOwner = info.NameSetBy.String()
+	}
+	if !info.NameSetAt.IsZero() {
+		result.SubjectTime = info.NameSetAt.Unix()
+	}
+	if !info.OwnerJID.IsEmpty() {
+		result.Owner = info.OwnerJID.String()
+	}
+	if !info.GroupCreated.IsZero() {
+		result.Creation = info.GroupCreated.Unix()
+	}
+	if info.IsParent {
+		result.IsCommunity = true
+	}
+	if !info.LinkedParentJID.IsEmpty() {
+		result.Community = info.LinkedParentJID.String()
+	}
+
+	return result
+}
+
+func participantsResponse(participants []types.GroupParticipant) []dto.GroupParticipantResponse {
+	result := make([]dto.GroupParticipantResponse, 0, len(participants))
+	for _, participant := range participants {
+		item := dto.GroupParticipantResponse{
+			ID:    participant.JID.String(),
+			Error: participant.Error,
+		}
+		if !participant.PhoneNumber.IsEmpty() {
+			item.PhoneNumber = participant.PhoneNumber.String()
+		}
+		switch {
+		case participant.IsSuperAdmin:
+			item.Admin = "superadmin"
+		case participant.IsAdmin:
+			item.Admin = "admin"
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
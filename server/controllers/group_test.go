@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+)
+
+func TestLinkSubGroupValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		group       string
+		body        string
+		wantMessage string
+	}{
+		{name: "missing sub group", group: "120363000000000001", body: `{}`, wantMessage: "invalid request body"},
+		{name: "invalid community", group: "5511911111111@s.whatsapp.net", body: `{"subGroup":"120363000000000002"}`, wantMessage: "invalid group"},
+		{name: "invalid sub group", group: "120363000000000001", body: `{"subGroup":"5511911111111@s.whatsapp.net"}`, wantMessage: "invalid sub group"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, handler := range []func(*Group, echo.Context) error{(*Group).LinkSubGroup, (*Group).UnlinkSubGroup} {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				rec := httptest.NewRecorder()
+				ctx := echo.New().NewContext(req, rec)
+				ctx.SetParamNames("instance", "group")
+				ctx.SetParamValues("instance", tt.group)
+
+				if err := handler(NewGroups(nil, nil), ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				var response struct {
+					Message string `json:"message"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				if rec.Code != http.StatusBadRequest || response.Message != tt.wantMessage {
+					t.Errorf("got %d %q, want 400 %q", rec.Code, response.Message, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupFailNotCommunity(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	if err := groupFail(ctx, whatsmiau.ErrNotCommunity, "failed"); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "group is not a community") {
+		t.Errorf("got %d %s, want 400", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/utils"
+)
+
+// Live answers while the process serves requests, it checks no dependency
+func Live(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Ready fails with 503 and the failed subsystem when the device store
+// database or the event emitter is down, so traffic is shed
+func Ready(ctx echo.Context) error {
+	c, cancel := context.WithTimeout(ctx.Request().Context(), 5*time.Second)
+	defer cancel()
+
+	if err := whatsmiau.Get().Health(c); err != nil {
+		return utils.HTTPFail(ctx, http.StatusServiceUnavailable, err, "service not ready")
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
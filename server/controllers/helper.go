@@ -5,11 +5,17 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"go.mau.fi/whatsmeow/types"
 )
 
 func numberToJid(number string) (*types.JID, error) {
+	if number == types.StatusBroadcastJID.String() {
+		jid := types.StatusBroadcastJID
+		return &jid, nil
+	}
+
 	splitNumber := strings.Split(number, "@")
 	if len(splitNumber) != 2 {
 		number += "@s.whatsapp.net"
@@ -27,6 +33,33 @@ func numberToJid(number string) (*types.JID, error) {
 	return &jid, nil
 }
 
+// groupToJid accepts a group JID or just its user part
+func groupToJid(group string) (*types.JID, error) {
+	if !strings.Contains(group, "@") {
+		group += "@" + types.GroupServer
+	}
+
+	jid, err := types.ParseJID(group)
+	if err != nil || jid.Server != types.GroupServer {
+		return nil, fmt.Errorf("invalid group jid")
+	}
+
+	return &jid, nil
+}
+
+func newsletterToJid(newsletter string) (*types.JID, error) {
+	if !strings.Contains(newsletter, "@") {
+		newsletter += "@" + types.NewsletterServer
+	}
+
+	jid, err := types.ParseJID(newsletter)
+	if err != nil || jid.Server != types.NewsletterServer {
+		return nil, fmt.Errorf("invalid newsletter jid")
+	}
+
+	return &jid, nil
+}
+
 func parseProxyURL(proxyURL string) (*models.InstanceProxy, error) {
 	if !strings.Contains(proxyURL, "://") {
 		return nil, fmt.Errorf("invalid proxy url, missing scheme: %s", proxyURL)
@@ -37,6 +70,10 @@ func parseProxyURL(proxyURL string) (*models.InstanceProxy, error) {
 		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
 	}
 
+	if !whatsmiau.IsSupportedProxyScheme(u.Scheme) {
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https, socks5 or socks5h", u.Scheme)
+	}
+
 	var username, password string
 	if u.User != nil {
 		username = u.User.Username()
@@ -10,6 +10,7 @@ import (
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/models"
 	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 
 	"github.com/go-playground/validator/v10"
@@ -52,6 +53,21 @@ func (s *Instance) Create(ctx echo.Context) error {
 		request.Instance.ID = request.InstanceName
 	}
 	request.RemoteJID = ""
+	request.Block = nil
+
+	if request.Webhook.Template != nil && len(*request.Webhook.Template) > 0 {
+		if _, err := whatsmiau.ParsePayloadTemplate(*request.Webhook.Template); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid webhook template")
+		}
+	}
+
+	if err := validator.New().Var(request.Webhook.Routes, "omitempty,dive,keys,required,endkeys,http_url"); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid webhook routes")
+	}
+
+	if err := validator.New().Var(request.Webhook.Format, "omitempty,oneof=evolution event"); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid webhook format")
+	}
 
 	if len(request.ProxyHost) <= 0 && len(env.Env.ProxyAddresses) > 0 {
 		rd := rand.IntN(len(env.Env.ProxyAddresses))
@@ -64,6 +80,18 @@ func (s *Instance) Create(ctx echo.Context) error {
 		request.InstanceProxy = *proxy
 	}
 
+	if len(request.ProxyHost) > 0 {
+		if _, err := whatsmiau.ProxyURL(request.InstanceProxy); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid proxy")
+		}
+	}
+
+	for _, proxyUrl := range request.ProxyPool {
+		if _, err := whatsmiau.ParseProxyURL(proxyUrl); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid proxy pool")
+		}
+	}
+
 	c := ctx.Request().Context()
 	if err := s.repo.Create(c, request.Instance); err != nil {
 		zap.L().Error("failed to create instance", zap.Error(err))
@@ -71,7 +99,7 @@ func (s *Instance) Create(ctx echo.Context) error {
 	}
 
 	return ctx.JSON(http.StatusCreated, dto.CreateInstanceResponse{
-		Instance: request.Instance,
+		Instance: whatsmiau.MaskWebhookSecret(request.Instance),
 	})
 }
 
@@ -85,12 +113,49 @@ func (s *Instance) Update(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
 	}
 
+	if request.Webhook.Template != nil && len(*request.Webhook.Template) > 0 {
+		if _, err := whatsmiau.ParsePayloadTemplate(*request.Webhook.Template); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid webhook template")
+		}
+	}
+
+	for _, proxyUrl := range request.ProxyPool {
+		if _, err := whatsmiau.ParseProxyURL(proxyUrl); err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid proxy pool")
+		}
+	}
+
+	var groupAutoJoin *models.GroupAutoJoin
+	if request.GroupAutoJoin != nil {
+		groupAutoJoin = &models.GroupAutoJoin{
+			Inviters:  request.GroupAutoJoin.Inviters,
+			MaxGroups: request.GroupAutoJoin.MaxGroups,
+		}
+	}
+
 	c := ctx.Request().Context()
 	instance, err := s.repo.Update(c, request.ID, &models.Instance{
-		ID: request.ID,
+		ID:                  request.ID,
+		AlwaysOnline:        request.AlwaysOnline,
+		EphemeralExpiration: request.EphemeralExpiration,
+		EmitFromMe:          request.EmitFromMe,
+		AutoMarkRead:        request.AutoMarkRead,
+		AutoDownloadMedia:   request.AutoDownloadMedia,
+		GenerateLinkPreview: request.GenerateLinkPreview,
+		SendRateLimit:       request.SendRateLimit,
+		Locale:              request.Locale,
+		GroupAutoJoin:       groupAutoJoin,
+		IgnoreInbound:       request.IgnoreInbound,
+		ProxyPool:           request.ProxyPool,
 		Webhook: models.InstanceWebhook{
-			Url:    request.Webhook.URL,
-			Base64: &[]bool{request.Webhook.Base64}[0],
+			Url:      request.Webhook.URL,
+			Base64:   &[]bool{request.Webhook.Base64}[0],
+			Template: request.Webhook.Template,
+			Format:   request.Webhook.Format,
+			Secret:   request.Webhook.Secret,
+			Raw:      request.Webhook.Raw,
+			Routes:   request.Webhook.Routes,
+			Headers:  request.Webhook.Headers,
 		},
 	})
 	if err != nil {
@@ -102,7 +167,7 @@ func (s *Instance) Update(ctx echo.Context) error {
 	}
 
 	return ctx.JSON(http.StatusCreated, dto.UpdateInstanceResponse{
-		Instance: instance,
+		Instance: whatsmiau.MaskWebhookSecret(instance),
 	})
 }
 
@@ -130,7 +195,7 @@ func (s *Instance) List(ctx echo.Context) error {
 		}
 
 		response = append(response, dto.ListInstancesResponse{
-			Instance:     &instance,
+			Instance:     whatsmiau.MaskWebhookSecret(&instance),
 			OwnerJID:     jid.ToNonAD().String(),
 			InstanceName: instance.ID,
 		})
@@ -162,6 +227,9 @@ func (s *Instance) Connect(ctx echo.Context) error {
 
 	qrCode, err := s.whatsmiau.Connect(c, request.ID)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrPairingInProgress) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "pairing code login in progress")
+		}
 		zap.L().Error("failed to connect instance", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to connect instance")
 	}
@@ -184,6 +252,46 @@ func (s *Instance) Connect(ctx echo.Context) error {
 	})
 }
 
+func (s *Instance) PairPhone(ctx echo.Context) error {
+	c := ctx.Request().Context()
+	var request dto.PairPhoneRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	result, err := s.repo.List(c, request.ID)
+	if err != nil {
+		zap.L().Error("failed to list instances", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances")
+	}
+
+	if len(result) == 0 {
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	}
+
+	code, err := s.whatsmiau.PairPhone(c, request.ID, request.Phone)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrInvalidPhoneNumber):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid phone number")
+		case errors.Is(err, whatsmiau.ErrAlreadyLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance already connected")
+		case errors.Is(err, whatsmiau.ErrPairingInProgress), errors.Is(err, whatsmiau.ErrQrCodeInProgress):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "login already in progress")
+		}
+		zap.L().Error("Whatsmiau.PairPhone failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to pair phone")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.PairPhoneResponse{
+		Code: code,
+	})
+}
+
 func (s *Instance) ConnectQRBuffer(ctx echo.Context) error {
 	c := ctx.Request().Context()
 	var request dto.ConnectInstanceRequest
@@ -201,18 +309,16 @@ func (s *Instance) ConnectQRBuffer(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
 	}
 
-	qrCode, err := s.whatsmiau.Connect(c, request.ID)
+	png, contentType, err := s.whatsmiau.ConnectImage(c, request.ID)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrPairingInProgress) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "pairing code login in progress")
+		}
 		zap.L().Error("failed to connect instance", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to connect instance")
 	}
-	if qrCode != "" {
-		png, err := qrcode.Encode(qrCode, qrcode.Medium, 256)
-		if err != nil {
-			zap.L().Error("failed to encode qrcode", zap.Error(err))
-			return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to encode qrcode")
-		}
-		return ctx.Blob(http.StatusOK, "image/png", png)
+	if len(png) > 0 {
+		return ctx.Blob(http.StatusOK, contentType, png)
 	}
 
 	return ctx.NoContent(http.StatusOK)
@@ -251,6 +357,220 @@ func (s *Instance) Status(ctx echo.Context) error {
 	})
 }
 
+func (s *Instance) Debug(ctx echo.Context) error {
+	c := ctx.Request().Context()
+	var request dto.DebugInstanceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	result, err := s.repo.List(c, request.ID)
+	if err != nil {
+		zap.L().Error("failed to list instances", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances")
+	}
+
+	if len(result) == 0 {
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	}
+
+	debug, err := s.whatsmiau.DebugInstance(request.ID)
+	if err != nil {
+		zap.L().Error("failed to debug instance", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to debug instance")
+	}
+
+	return ctx.JSON(http.StatusOK, debug)
+}
+
+func (s *Instance) TestProxy(ctx echo.Context) error {
+	var request dto.TestProxyRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	result, err := s.whatsmiau.TestProxyConnectivity(ctx.Request().Context(), request.ProxyURL)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid proxy url")
+	}
+
+	response := dto.TestProxyResponse{Success: result.Success}
+	for _, target := range result.Targets {
+		response.Targets = append(response.Targets, dto.TestProxyResponseTarget{
+			Target:    target.Target,
+			Success:   target.Success,
+			Status:    target.Status,
+			LatencyMs: target.Latency.Milliseconds(),
+			Error:     target.Error,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Instance) Stats(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, s.whatsmiau.InstanceStats())
+}
+
+func (s *Instance) ListStatus(ctx echo.Context) error {
+	result, err := s.whatsmiau.ListInstances(ctx.Request().Context())
+	if err != nil {
+		zap.L().Error("failed to list instances status", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances status")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (s *Instance) ClockSkew(ctx echo.Context) error {
+	var request dto.ClockSkewInstanceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	skew, err := s.whatsmiau.ClockSkew(request.ID)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrNoClockSample) {
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "no clock sample for instance")
+		}
+		zap.L().Error("Whatsmiau.ClockSkew failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get clock skew")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.ClockSkewInstanceResponse{
+		ID:          skew.InstanceID,
+		OffsetMs:    skew.Offset.Milliseconds(),
+		RoundTripMs: skew.RoundTrip.Milliseconds(),
+		SampledAt:   skew.SampledAt,
+	})
+}
+
+func (s *Instance) GetPrivacySettings(ctx echo.Context) error {
+	var request dto.GetPrivacySettingsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	settings, err := s.whatsmiau.GetPrivacySettings(ctx.Request().Context(), request.ID)
+	if err != nil {
+		zap.L().Error("Whatsmiau.GetPrivacySettings failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get privacy settings")
+	}
+
+	return ctx.JSON(http.StatusOK, settings)
+}
+
+func (s *Instance) SetPrivacySetting(ctx echo.Context) error {
+	var request dto.SetPrivacySettingRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	settings, err := s.whatsmiau.SetPrivacySetting(ctx.Request().Context(), &whatsmiau.SetPrivacySettingRequest{
+		InstanceID: request.ID,
+		Name:       types.PrivacySettingType(request.Name),
+		Value:      types.PrivacySetting(request.Value),
+	})
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrInvalidPrivacySetting) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid privacy setting")
+		}
+		zap.L().Error("Whatsmiau.SetPrivacySetting failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to set privacy setting")
+	}
+
+	return ctx.JSON(http.StatusOK, settings)
+}
+
+func (s *Instance) SetProfileName(ctx echo.Context) error {
+	var request dto.SetProfileNameRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if err := s.whatsmiau.SetOwnProfileName(ctx.Request().Context(), request.ID, request.Name); err != nil {
+		return profileFail(ctx, err, "Whatsmiau.SetOwnProfileName failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Instance) SetProfileStatus(ctx echo.Context) error {
+	var request dto.SetProfileStatusRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if err := s.whatsmiau.SetOwnStatusText(ctx.Request().Context(), request.ID, request.Status); err != nil {
+		return profileFail(ctx, err, "Whatsmiau.SetOwnStatusText failed")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Instance) SetProfilePicture(ctx echo.Context) error {
+	var request dto.SetProfilePictureRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	photo, err := imageToMediaInput(request.Image)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid image")
+	}
+
+	pictureID, err := s.whatsmiau.SetOwnProfilePicture(ctx.Request().Context(), request.ID, photo)
+	if err != nil {
+		return profileFail(ctx, err, "Whatsmiau.SetOwnProfilePicture failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SetProfilePictureResponse{
+		PictureID: pictureID,
+	})
+}
+
+func profileFail(ctx echo.Context, err error, log string) error {
+	switch {
+	case errors.Is(err, whatsmeow.ErrClientIsNil):
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+		return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+	case errors.Is(err, whatsmeow.ErrInvalidImageFormat):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid image")
+	}
+
+	zap.L().Error(log, zap.Error(err))
+	return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to update profile")
+}
+
 func (s *Instance) Logout(ctx echo.Context) error {
 	c := ctx.Request().Context()
 	var request dto.DeleteInstanceRequest
@@ -278,6 +598,37 @@ func (s *Instance) Logout(ctx echo.Context) error {
 	})
 }
 
+// LogoutAllDevices is irreversible, every instance linked to the account needs
+// a new QR code scan afterward
+func (s *Instance) LogoutAllDevices(ctx echo.Context) error {
+	c := ctx.Request().Context()
+	var request dto.DeleteInstanceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	result, err := s.repo.List(c, request.ID)
+	if err != nil {
+		zap.L().Error("failed to list instances", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list instances")
+	}
+
+	if len(result) == 0 {
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+	}
+
+	res, err := s.whatsmiau.LogoutAllDevices(c, request.ID)
+	if err != nil {
+		zap.L().Error("Whatsmiau.LogoutAllDevices failed", zap.Error(err))
+		if res == nil {
+			return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to logout devices")
+		}
+		return ctx.JSON(http.StatusMultiStatus, res)
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
 func (s *Instance) Delete(ctx echo.Context) error {
 	c := ctx.Request().Context()
 	var request dto.DeleteInstanceRequest
@@ -306,8 +657,94 @@ func (s *Instance) Delete(ctx echo.Context) error {
 		zap.L().Error("failed to delete instance", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to delete instance")
 	}
+	s.whatsmiau.ForgetInstance(c, request.ID)
 
 	return ctx.JSON(http.StatusOK, dto.DeleteInstanceResponse{
 		Message: "instance deleted",
 	})
 }
+
+func (s *Instance) ListStoredMedia(ctx echo.Context) error {
+	var request dto.ListStoredMediaRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	result, err := s.whatsmiau.ListStoredMedia(ctx.Request().Context(), &whatsmiau.ListStoredMediaRequest{
+		InstanceID: request.ID,
+		From:       request.From,
+		To:         request.To,
+		PageToken:  request.PageToken,
+		Limit:      request.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrStorageDisabled) {
+			return utils.HTTPFail(ctx, http.StatusNotImplemented, err, "file storage is disabled")
+		}
+		zap.L().Error("Whatsmiau.ListStoredMedia failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list stored media")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (s *Instance) DownloadStoredMedia(ctx echo.Context) error {
+	var request dto.DownloadStoredMediaRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	reader, media, err := s.whatsmiau.DownloadStoredMedia(ctx.Request().Context(), request.ID, request.Key)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrStorageDisabled):
+			return utils.HTTPFail(ctx, http.StatusNotImplemented, err, "file storage is disabled")
+		case errors.Is(err, whatsmiau.ErrStoredMediaNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "stored media not found")
+		}
+		zap.L().Error("Whatsmiau.DownloadStoredMedia failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to download stored media")
+	}
+	defer reader.Close()
+
+	contentType := media.ContentType
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+
+	return ctx.Stream(http.StatusOK, contentType, reader)
+}
+
+func (s *Instance) ReplayDeadLetter(ctx echo.Context) error {
+	var request dto.ReplayDeadLetterRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if request.Max == 0 {
+		request.Max = 100
+	}
+
+	replayed, err := s.whatsmiau.ReplayDeadLetter(ctx.Request().Context(), request.ID, request.Max)
+	if err != nil {
+		if errors.Is(err, instances.ErrorNotFound) {
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "instance not found")
+		}
+		zap.L().Error("Whatsmiau.ReplayDeadLetter failed", zap.Int("replayed", replayed), zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to replay dead letters")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.ReplayDeadLetterResponse{Replayed: replayed})
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"mime"
 	"net/http"
 	"regexp"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
 	"github.com/verbeux-ai/whatsmiau/server/dto"
 	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"go.uber.org/zap"
 )
@@ -43,19 +46,51 @@ func (s *Message) SendText(ctx echo.Context) error {
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
 	}
 
+	options := &whatsmiau.SendTextOptions{
+		MentionsEveryone:    request.MentionsEveryOne,
+		Expiration:          time.Duration(request.Expiration) * time.Second,
+		GenerateLinkPreview: request.LinkPreview,
+	}
+
+	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 {
+		options.Quote = &whatsmiau.QuoteOptions{
+			MessageID: request.Quoted.Key.Id,
+			Text:      request.Quoted.Message.Conversation,
+			FromMe:    request.Quoted.Key.FromMe,
+		}
+		if len(request.Quoted.Key.Participant) > 0 {
+			participant, err := numberToJid(request.Quoted.Key.Participant)
+			if err != nil {
+				return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid quoted participant")
+			}
+			options.Quote.Participant = participant
+		}
+		if len(request.Quoted.Key.RemoteJid) > 0 {
+			chat, err := numberToJid(request.Quoted.Key.RemoteJid)
+			if err != nil {
+				return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid quoted remoteJid")
+			}
+			options.Quote.Chat = chat
+		}
+	}
+
+	for _, number := range request.Mentioned {
+		mentioned, err := numberToJid(number)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid mentioned number")
+		}
+		options.Mentions = append(options.Mentions, *mentioned)
+	}
+
 	sendText := &whatsmiau.SendText{
 		Text:       request.Text,
 		InstanceID: request.InstanceID,
 		RemoteJID:  jid,
-	}
-
-	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
-		sendText.QuoteMessage = request.Quoted.Message.Conversation
-		sendText.QuoteMessageID = request.Quoted.Key.Id
+		Options:    options,
 	}
 
 	c := ctx.Request().Context()
-	if err := s.whatsmiau.ChatPresence(&whatsmiau.ChatPresenceRequest{
+	if err := s.whatsmiau.ChatPresence(c, &whatsmiau.ChatPresenceRequest{
 		InstanceID: request.InstanceID,
 		RemoteJID:  jid,
 		Presence:   types.ChatPresenceComposing,
@@ -67,6 +102,12 @@ func (s *Message) SendText(ctx echo.Context) error {
 
 	res, err := s.whatsmiau.SendText(c, sendText)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
+		if errors.Is(err, whatsmiau.ErrNotLoggedIn) {
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
 		zap.L().Error("Whatsmiau.SendText failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send text")
 	}
@@ -87,6 +128,217 @@ func (s *Message) SendText(ctx echo.Context) error {
 	})
 }
 
+func (s *Message) SendSequence(ctx echo.Context) error {
+	var request dto.SendSequenceRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	messages := make([]*waE2E.Message, len(request.Messages))
+	for i, item := range request.Messages {
+		messages[i] = &waE2E.Message{Conversation: &item.Text}
+	}
+
+	results, err := s.whatsmiau.SendSequence(ctx.Request().Context(), &whatsmiau.SendSequenceRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		Messages:   messages,
+		Delay:      time.Duration(request.Delay) * time.Millisecond,
+		Typing:     request.Typing,
+	})
+	if err != nil {
+		zap.L().Error("Whatsmiau.SendSequence failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send sequence")
+	}
+
+	response := dto.SendSequenceResponse{Results: make([]dto.SendSequenceResponseItem, len(results))}
+	for i, result := range results {
+		if len(result.Error) > 0 {
+			response.Results[i] = dto.SendSequenceResponseItem{Status: "failed", Error: result.Error}
+			continue
+		}
+		response.Results[i] = dto.SendSequenceResponseItem{
+			Key: &dto.MessageResponseKey{
+				RemoteJid: request.Number,
+				FromMe:    true,
+				Id:        result.ID,
+			},
+			Status:           "sent",
+			MessageTimestamp: int(result.CreatedAt.Unix()),
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+func (s *Message) SendTemplate(ctx echo.Context) error {
+	var request dto.SendTemplateRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.SendTemplate(ctx.Request().Context(), &whatsmiau.SendTemplateRequest{
+		InstanceID:   request.InstanceID,
+		RemoteJID:    jid,
+		TemplateName: request.Template,
+		Vars:         request.Vars,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrTemplateNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "template not found")
+		case errors.Is(err, whatsmiau.ErrMissingTemplateVars):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "missing template variables")
+		}
+		zap.L().Error("Whatsmiau.SendTemplate failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send template")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendTextResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "conversation",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) ScheduleMessage(ctx echo.Context) error {
+	var request dto.ScheduleMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	id, err := s.whatsmiau.ScheduleMessage(ctx.Request().Context(), request.InstanceID, *jid, whatsmiau.ScheduledMessage{
+		Text: request.Text,
+		Options: &whatsmiau.SendTextOptions{
+			GenerateLinkPreview: request.LinkPreview,
+		},
+	}, request.At)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrScheduleInPast) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "scheduled time is in the past")
+		}
+		zap.L().Error("Whatsmiau.ScheduleMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to schedule message")
+	}
+
+	return ctx.JSON(http.StatusCreated, dto.ScheduleMessageResponse{
+		ID: id,
+		At: request.At,
+	})
+}
+
+func (s *Message) CancelScheduled(ctx echo.Context) error {
+	var request dto.CancelScheduledRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if err := s.whatsmiau.CancelScheduled(ctx.Request().Context(), request.InstanceID, request.ID); err != nil {
+		if errors.Is(err, whatsmiau.ErrScheduleNotFound) {
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "scheduled message not found")
+		}
+		zap.L().Error("Whatsmiau.CancelScheduled failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to cancel scheduled message")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (s *Message) SendStatus(ctx echo.Context) error {
+	var request dto.SendStatusRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	content := whatsmiau.StatusContent{
+		Text:            request.Text,
+		BackgroundColor: request.BackgroundColor,
+		TextColor:       request.TextColor,
+		Font:            request.Font,
+	}
+	if len(request.Media) > 0 {
+		media, err := imageToMediaInput(request.Media)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid media")
+		}
+		media.Mimetype = request.Mimetype
+		content.Media = media
+	}
+
+	id, err := s.whatsmiau.SendStatus(ctx.Request().Context(), request.InstanceID, content)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrUnsupportedStatus), errors.Is(err, whatsmiau.ErrUnsupportedMediaType):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "unsupported status content")
+		case errors.Is(err, whatsmiau.ErrMediaTooLarge):
+			return utils.HTTPFail(ctx, http.StatusRequestEntityTooLarge, err, "media too large")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendStatus failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send status")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendStatusResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: types.StatusBroadcastJID.String(),
+			FromMe:    true,
+			Id:        id,
+		},
+		Status:           "sent",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
 func (s *Message) SendAudio(ctx echo.Context) error {
 	var request dto.SendAudioRequest
 	if err := ctx.Bind(&request); err != nil {
@@ -107,15 +359,23 @@ func (s *Message) SendAudio(ctx echo.Context) error {
 		AudioURL:   request.Audio,
 		InstanceID: request.InstanceID,
 		RemoteJID:  jid,
+		PTT:        request.PTT,
 	}
 
 	if request.Quoted != nil && len(request.Quoted.Key.Id) > 0 && len(request.Quoted.Message.Conversation) > 0 {
 		sendText.QuoteMessage = request.Quoted.Message.Conversation
 		sendText.QuoteMessageID = request.Quoted.Key.Id
+		if len(request.Quoted.Key.Participant) > 0 {
+			participant, err := numberToJid(request.Quoted.Key.Participant)
+			if err != nil {
+				return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid quoted participant")
+			}
+			sendText.Participant = participant
+		}
 	}
 
 	c := ctx.Request().Context()
-	if err := s.whatsmiau.ChatPresence(&whatsmiau.ChatPresenceRequest{
+	if err := s.whatsmiau.ChatPresence(c, &whatsmiau.ChatPresenceRequest{
 		InstanceID: request.InstanceID,
 		RemoteJID:  jid,
 		Presence:   types.ChatPresenceComposing,
@@ -128,6 +388,12 @@ func (s *Message) SendAudio(ctx echo.Context) error {
 
 	res, err := s.whatsmiau.SendAudio(c, sendText)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
+		if errors.Is(err, whatsmiau.ErrInvalidAudio) || errors.Is(err, whatsmiau.ErrUnsupportedMediaType) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid audio")
+		}
 		zap.L().Error("Whatsmiau.SendAudioRequest failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send audio")
 	}
@@ -146,7 +412,8 @@ func (s *Message) SendAudio(ctx echo.Context) error {
 	})
 }
 
-// For evolution compatibility
+// For evolution compatibility. Other mediatypes than image and document, or
+// none, used to be sent as documents, now the kind follows the mimetype.
 func (s *Message) SendMedia(ctx echo.Context) error {
 	var request dto.SendMediaRequest
 	if err := ctx.Bind(&request); err != nil {
@@ -160,9 +427,65 @@ func (s *Message) SendMedia(ctx echo.Context) error {
 	case "image":
 		request.SendDocumentRequest.Mimetype = "image/png"
 		return s.sendImage(ctx, request.SendDocumentRequest)
+	case "document":
+		return s.sendDocument(ctx, request.SendDocumentRequest)
+	}
+
+	return s.sendMedia(ctx, request.SendDocumentRequest)
+}
+
+// sendMedia picks image, video, audio or document from the mimetype, detected
+// from the file when empty
+func (s *Message) sendMedia(ctx echo.Context, request dto.SendDocumentRequest) error {
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	sendData := &whatsmiau.SendMediaRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		Media: whatsmiau.MediaInput{
+			URL:      request.Media,
+			Mimetype: request.Mimetype,
+			Caption:  request.Caption,
+			FileName: request.FileName,
+		},
+	}
+
+	c := ctx.Request().Context()
+	time.Sleep(time.Millisecond * time.Duration(request.Delay)) // TODO: create a more robust solution
+
+	res, err := s.whatsmiau.SendMedia(c, sendData)
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
+		if errors.Is(err, whatsmiau.ErrUnsupportedMediaType) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "unsupported media type")
+		}
+		if errors.Is(err, whatsmiau.ErrMediaTooLarge) {
+			return utils.HTTPFail(ctx, http.StatusRequestEntityTooLarge, err, "media too large")
+		}
+		if errors.Is(err, whatsmiau.ErrPrivateAddress) {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "media url is not public")
+		}
+		zap.L().Error("Whatsmiau.SendMedia failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send media")
 	}
 
-	return s.sendDocument(ctx, request.SendDocumentRequest)
+	return ctx.JSON(http.StatusOK, dto.SendDocumentResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      res.MessageType,
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
 }
 
 func (s *Message) SendDocument(ctx echo.Context) error {
@@ -199,6 +522,9 @@ func (s *Message) sendDocument(ctx echo.Context, request dto.SendDocumentRequest
 
 	res, err := s.whatsmiau.SendDocument(c, sendData)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
 		zap.L().Error("Whatsmiau.SendDocument failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send document")
 	}
@@ -249,6 +575,9 @@ func (s *Message) sendImage(ctx echo.Context, request dto.SendDocumentRequest) e
 
 	res, err := s.whatsmiau.SendImage(c, sendData)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
 		zap.L().Error("Whatsmiau.SendDocument failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send document")
 	}
@@ -283,7 +612,7 @@ func (s *Message) SendReaction(ctx echo.Context) error {
 	}
 
 	var emojiRegex = regexp.MustCompile(`[\x{1F600}-\x{1F64F}]|[\x{1F300}-\x{1F5FF}]|[\x{1F680}-\x{1F6FF}]|[\x{2600}-\x{26FF}]|[\x{2700}-\x{27BF}]`)
-	if !emojiRegex.MatchString(request.Reaction) {
+	if len(request.Reaction) > 0 && !emojiRegex.MatchString(request.Reaction) {
 		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid reaction, must be a emoji")
 	}
 
@@ -293,11 +622,22 @@ func (s *Message) SendReaction(ctx echo.Context) error {
 		RemoteJID:  jid,
 		MessageID:  request.Key.Id,
 		FromMe:     request.Key.FromMe,
+		ServerID:   types.MessageServerID(request.ServerId),
+	}
+
+	if len(request.Key.Participant) > 0 {
+		sendReaction.Participant, err = numberToJid(request.Key.Participant)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant format")
+		}
 	}
 
 	c := ctx.Request().Context()
 	res, err := s.whatsmiau.SendReaction(c, sendReaction)
 	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
 		zap.L().Error("Whatsmiau.SendReaction failed", zap.Error(err))
 		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send reaction")
 	}
@@ -314,3 +654,629 @@ func (s *Message) SendReaction(ctx echo.Context) error {
 		InstanceId:       request.InstanceID,
 	})
 }
+
+func (s *Message) SendButtons(ctx echo.Context) error {
+	var request dto.SendButtonsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	buttons := make([]whatsmiau.Button, 0, len(request.Buttons))
+	for _, button := range request.Buttons {
+		buttons = append(buttons, whatsmiau.Button{ID: button.ID, Text: button.Text})
+	}
+
+	messageID, err := s.whatsmiau.SendButtons(ctx.Request().Context(), request.InstanceID, *jid, request.Text, buttons)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrInvalidButtons):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid buttons")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendButtons failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send buttons")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendButtonsResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageType:      "buttonsMessage",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendList(ctx echo.Context) error {
+	var request dto.SendListRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	list := whatsmiau.ListMessage{
+		Title:       request.Title,
+		Description: request.Description,
+		ButtonText:  request.ButtonText,
+		FooterText:  request.FooterText,
+		Sections:    make([]whatsmiau.ListSection, 0, len(request.Sections)),
+	}
+	for _, section := range request.Sections {
+		rows := make([]whatsmiau.ListRow, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			rows = append(rows, whatsmiau.ListRow{ID: row.RowID, Title: row.Title, Description: row.Description})
+		}
+		list.Sections = append(list.Sections, whatsmiau.ListSection{Title: section.Title, Rows: rows})
+	}
+
+	messageID, err := s.whatsmiau.SendList(ctx.Request().Context(), request.InstanceID, *jid, list)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrInvalidList):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid list")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendList failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send list")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendListResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageType:      "listMessage",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendPoll(ctx echo.Context) error {
+	var request dto.SendPollRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	if request.SelectableCount == 0 {
+		request.SelectableCount = 1
+	}
+
+	messageID, err := s.whatsmiau.SendPoll(ctx.Request().Context(), request.InstanceID, *jid, request.Name, request.Values, request.SelectableCount)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrInvalidPoll):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid poll")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendPoll failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send poll")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendPollResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageType:      "pollCreationMessage",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendLocation(ctx echo.Context) error {
+	var request dto.SendLocationRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	messageID, err := s.whatsmiau.SendLocation(ctx.Request().Context(), request.InstanceID, *jid, *request.Latitude, *request.Longitude, request.Name, request.Address)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrInvalidLocation):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid location")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendLocation failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send location")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendLocationResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageType:      "locationMessage",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) SendContact(ctx echo.Context) error {
+	var request dto.SendContactRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	contacts := make([]whatsmiau.VCard, 0, len(request.Contact))
+	for _, contact := range request.Contact {
+		card := whatsmiau.VCard{
+			FullName:     contact.FullName,
+			FirstName:    contact.FirstName,
+			LastName:     contact.LastName,
+			Organization: contact.Organization,
+		}
+		if contact.PhoneNumber != "" {
+			card.Phones = append(card.Phones, whatsmiau.VCardPhone{Number: contact.PhoneNumber})
+		}
+		for _, phone := range contact.Phones {
+			card.Phones = append(card.Phones, whatsmiau.VCardPhone{Number: phone.Number, Type: phone.Type})
+		}
+		if contact.Email != "" {
+			card.Emails = []string{contact.Email}
+		}
+		contacts = append(contacts, card)
+	}
+
+	messageID, err := s.whatsmiau.SendContact(ctx.Request().Context(), request.InstanceID, *jid, contacts)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrInvalidContact):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid contact")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.SendContact failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to send contact")
+	}
+
+	messageType := "contactMessage"
+	if len(contacts) > 1 {
+		messageType = "contactsArrayMessage"
+	}
+
+	return ctx.JSON(http.StatusOK, dto.SendContactResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageType:      messageType,
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) RequestLocation(ctx echo.Context) error {
+	var request dto.RequestLocationRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.RequestLocation(ctx.Request().Context(), &whatsmiau.RequestLocationRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		Body:       request.Text,
+	})
+	if err != nil {
+		if errors.Is(err, whatsmiau.ErrRateLimited) {
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		}
+		zap.L().Error("Whatsmiau.RequestLocation failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to request location")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.RequestLocationResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "interactiveMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) ForwardMessage(ctx echo.Context) error {
+	var request dto.ForwardMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	to, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	from, err := numberToJid(request.Key.RemoteJid)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid key remoteJid")
+	}
+
+	messageID, err := s.whatsmiau.ForwardMessage(ctx.Request().Context(), request.InstanceID, *from, request.Key.Id, *to)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+		case errors.Is(err, whatsmiau.ErrUnsupportedForward):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "message can't be forwarded")
+		case errors.Is(err, whatsmiau.ErrMediaExpired):
+			return utils.HTTPFail(ctx, http.StatusGone, err, "media expired")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.ForwardMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to forward message")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.ForwardMessageResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        messageID,
+		},
+		Status:           "sent",
+		MessageTimestamp: int(time.Now().Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) EditMessage(ctx echo.Context) error {
+	var request dto.EditMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.EditMessage(ctx.Request().Context(), &whatsmiau.EditMessageRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		MessageID:  request.Key.Id,
+		Text:       request.Text,
+	})
+	if err != nil {
+		return editFail(ctx, err, "Whatsmiau.EditMessage failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.EditMessageResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "editedMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) DeleteMessage(ctx echo.Context) error {
+	var request dto.DeleteMessageRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	deleteMessage := &whatsmiau.DeleteMessageRequest{
+		InstanceID:  request.InstanceID,
+		RemoteJID:   jid,
+		MessageID:   request.Key.Id,
+		ForEveryone: request.ForEveryone,
+		FromMe:      request.Key.FromMe,
+	}
+	if len(request.Key.Participant) > 0 {
+		deleteMessage.Participant, err = numberToJid(request.Key.Participant)
+		if err != nil {
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid participant number")
+		}
+	}
+
+	if _, err := s.whatsmiau.DeleteMessage(ctx.Request().Context(), deleteMessage); err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrRateLimited):
+			return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.DeleteMessage failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to delete message")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.DeleteMessageResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    request.Key.FromMe,
+			Id:        request.Key.Id,
+		},
+		ForEveryone: request.ForEveryone,
+		InstanceId:  request.InstanceID,
+	})
+}
+
+func (s *Message) EditMediaCaption(ctx echo.Context) error {
+	var request dto.EditMediaCaptionRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.EditMediaCaption(ctx.Request().Context(), &whatsmiau.EditMediaCaptionRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		MessageID:  request.Key.Id,
+		Caption:    request.Caption,
+	})
+	if err != nil {
+		return editFail(ctx, err, "Whatsmiau.EditMediaCaption failed")
+	}
+
+	return ctx.JSON(http.StatusOK, dto.EditMessageResponse{
+		Key: dto.MessageResponseKey{
+			RemoteJid: request.Number,
+			FromMe:    true,
+			Id:        res.ID,
+		},
+		Status:           "sent",
+		MessageType:      "editedMessage",
+		MessageTimestamp: int(res.CreatedAt.Unix()),
+		InstanceId:       request.InstanceID,
+	})
+}
+
+func (s *Message) GetPollResults(ctx echo.Context) error {
+	var request dto.GetPollResultsRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.GetPollResults(ctx.Request().Context(), request.InstanceID, *jid, request.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "poll not found")
+		case errors.Is(err, whatsmiau.ErrNotPollMessage):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "message is not a poll")
+		}
+		zap.L().Error("Whatsmiau.GetPollResults failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get poll results")
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (s *Message) GetMessageThumbnail(ctx echo.Context) error {
+	var request dto.GetMessageThumbnailRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	res, err := s.whatsmiau.GetMessageThumbnail(ctx.Request().Context(), request.InstanceID, request.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+		case errors.Is(err, whatsmiau.ErrThumbnailUnavailable):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "thumbnail unavailable")
+		}
+		zap.L().Error("Whatsmiau.GetMessageThumbnail failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to get thumbnail")
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (s *Message) DownloadMedia(ctx echo.Context) error {
+	var request dto.DownloadMediaRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	jid, err := numberToJid(request.Number)
+	if err != nil {
+		zap.L().Error("error converting number to jid", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid number format")
+	}
+
+	res, err := s.whatsmiau.DownloadMedia(ctx.Request().Context(), &whatsmiau.DownloadMediaRequest{
+		InstanceID: request.InstanceID,
+		RemoteJID:  jid,
+		MessageID:  request.ID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmiau.ErrMessageNotFound):
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+		case errors.Is(err, whatsmiau.ErrNoDownloadableMedia):
+			return utils.HTTPFail(ctx, http.StatusBadRequest, err, "message has no media")
+		case errors.Is(err, whatsmiau.ErrMediaExpired):
+			return utils.HTTPFail(ctx, http.StatusGone, err, "media expired")
+		case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+			return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+		}
+		zap.L().Error("Whatsmiau.DownloadMedia failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to download media")
+	}
+
+	if len(res.FileName) > 0 {
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": res.FileName}))
+	}
+
+	return ctx.Blob(http.StatusOK, res.Mimetype, res.Data)
+}
+
+func editFail(ctx echo.Context, err error, log string) error {
+	switch {
+	case errors.Is(err, whatsmiau.ErrMessageNotFound):
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "message not found")
+	case errors.Is(err, whatsmiau.ErrNotOwnMessage), errors.Is(err, whatsmiau.ErrNotMediaMessage), errors.Is(err, whatsmiau.ErrNotTextMessage):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "message can't be edited")
+	case errors.Is(err, whatsmiau.ErrEditWindowExpired):
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "edit window expired")
+	case errors.Is(err, whatsmiau.ErrRateLimited):
+		return utils.HTTPFail(ctx, http.StatusTooManyRequests, err, "send rate limit exceeded")
+	}
+
+	zap.L().Error(log, zap.Error(err))
+	return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to edit message")
+}
+
+func (s *Message) FetchLinkPreview(ctx echo.Context) error {
+	var request dto.FetchLinkPreviewRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	res, err := s.whatsmiau.FetchLinkPreview(ctx.Request().Context(), &whatsmiau.FetchLinkPreviewRequest{
+		InstanceID: request.InstanceID,
+		Url:        request.Url,
+	})
+	if err != nil {
+		zap.L().Error("Whatsmiau.FetchLinkPreview failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusBadGateway, err, "failed to fetch link preview")
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
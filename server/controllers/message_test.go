@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestSendReactionValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantMessage string
+	}{
+		{
+			// passes validation, the reaction check after it answers
+			name:        "not from me",
+			body:        `{"reaction":"a","key":{"remoteJid":"5511911111111","id":"A1","fromMe":false}}`,
+			wantMessage: "invalid reaction, must be a emoji",
+		},
+		{
+			name:        "from me omitted",
+			body:        `{"reaction":"a","key":{"remoteJid":"5511911111111","id":"A1"}}`,
+			wantMessage: "invalid reaction, must be a emoji",
+		},
+		{
+			name:        "missing id",
+			body:        `{"reaction":"a","key":{"remoteJid":"5511911111111","fromMe":true}}`,
+			wantMessage: "invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := echo.New().NewContext(req, rec)
+			ctx.SetParamNames("instance")
+			ctx.SetParamValues("instance")
+
+			if err := NewMessages(nil, nil).SendReaction(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			var response struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != http.StatusBadRequest || response.Message != tt.wantMessage {
+				t.Errorf("got %d %q, want 400 %q", rec.Code, response.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/interfaces"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/dto"
+	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+)
+
+type Newsletter struct {
+	repo      interfaces.InstanceRepository
+	whatsmiau *whatsmiau.Whatsmiau
+}
+
+func NewNewsletters(repository interfaces.InstanceRepository, whatsmiau *whatsmiau.Whatsmiau) *Newsletter {
+	return &Newsletter{
+		repo:      repository,
+		whatsmiau: whatsmiau,
+	}
+}
+
+func (s *Newsletter) Info(ctx echo.Context) error {
+	var request dto.NewsletterRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	newsletter, err := newsletterToJid(request.Newsletter)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid newsletter")
+	}
+
+	info, err := s.whatsmiau.GetNewsletterInfo(ctx.Request().Context(), request.InstanceID, *newsletter)
+	if err != nil {
+		return newsletterFail(ctx, err, "Whatsmiau.GetNewsletterInfo failed")
+	}
+
+	return ctx.JSON(http.StatusOK, newsletterResponse(info))
+}
+
+func (s *Newsletter) Follow(ctx echo.Context) error {
+	return s.follow(ctx, true)
+}
+
+func (s *Newsletter) Unfollow(ctx echo.Context) error {
+	return s.follow(ctx, false)
+}
+
+func (s *Newsletter) follow(ctx echo.Context, follow bool) error {
+	var request dto.NewsletterRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	newsletter, err := newsletterToJid(request.Newsletter)
+	if err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid newsletter")
+	}
+
+	c := ctx.Request().Context()
+	if follow {
+		if err := s.whatsmiau.FollowNewsletter(c, request.InstanceID, *newsletter); err != nil {
+			return newsletterFail(ctx, err, "Whatsmiau.FollowNewsletter failed")
+		}
+	} else {
+		if err := s.whatsmiau.UnfollowNewsletter(c, request.InstanceID, *newsletter); err != nil {
+			return newsletterFail(ctx, err, "Whatsmiau.UnfollowNewsletter failed")
+		}
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func newsletterFail(ctx echo.Context, err error, log string) error {
+	switch {
+	case errors.Is(err, whatsmiau.ErrNotLoggedIn):
+		return utils.HTTPFail(ctx, http.StatusConflict, err, "instance is not logged in")
+	case errors.Is(err, whatsmiau.ErrInvalidNewsletter):
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid newsletter")
+	case errors.Is(err, whatsmiau.ErrNewsletterNotFound):
+		return utils.HTTPFail(ctx, http.StatusNotFound, err, "newsletter not found")
+	case errors.Is(err, whatsmiau.ErrNewsletterForbidden):
+		return utils.HTTPFail(ctx, http.StatusForbidden, err, "instance can't access newsletters")
+	}
+
+	zap.L().Error(log, zap.Error(err))
+	return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "newsletter request failed")
+}
+
+func newsletterResponse(info *types.NewsletterMetadata) dto.NewsletterResponse {
+	meta := info.ThreadMeta
+	result := dto.NewsletterResponse{
+		ID:          info.ID.String(),
+		Name:        meta.Name.Text,
+		Description: meta.Description.Text,
+		InviteCode:  meta.InviteCode,
+		Subscribers: meta.SubscriberCount,
+		Verified:    meta.VerificationState == types.NewsletterVerificationStateVerified,
+		State:       string(info.State.Type),
+	}
+	if meta.Picture != nil {
+		result.Picture = meta.Picture.URL
+	}
+	if !meta.CreationTime.IsZero() {
+		result.Creation = meta.CreationTime.Unix()
+	}
+	if info.ViewerMeta != nil {
+		result.Role = string(info.ViewerMeta.Role)
+		result.Muted = info.ViewerMeta.Mute == types.NewsletterMuteOn
+	}
+
+	return result
+}
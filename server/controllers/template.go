@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/dto"
+	"github.com/verbeux-ai/whatsmiau/utils"
+	"go.uber.org/zap"
+)
+
+type Template struct {
+	whatsmiau *whatsmiau.Whatsmiau
+}
+
+func NewTemplates(whatsmiau *whatsmiau.Whatsmiau) *Template {
+	return &Template{
+		whatsmiau: whatsmiau,
+	}
+}
+
+func (s *Template) Save(ctx echo.Context) error {
+	var request dto.SaveTemplateRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	template, err := s.whatsmiau.SaveTemplate(ctx.Request().Context(), request.InstanceID, request.Name, request.Text)
+	if err != nil {
+		zap.L().Error("Whatsmiau.SaveTemplate failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to save template")
+	}
+
+	return ctx.JSON(http.StatusOK, template)
+}
+
+func (s *Template) List(ctx echo.Context) error {
+	var request dto.ListTemplatesRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	result, err := s.whatsmiau.ListTemplates(ctx.Request().Context(), request.InstanceID)
+	if err != nil {
+		zap.L().Error("Whatsmiau.ListTemplates failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to list templates")
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (s *Template) Delete(ctx echo.Context) error {
+	var request dto.DeleteTemplateRequest
+	if err := ctx.Bind(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusUnprocessableEntity, err, "failed to bind request body")
+	}
+
+	if err := validator.New().Struct(&request); err != nil {
+		return utils.HTTPFail(ctx, http.StatusBadRequest, err, "invalid request body")
+	}
+
+	if err := s.whatsmiau.DeleteTemplate(ctx.Request().Context(), request.InstanceID, request.Name); err != nil {
+		if errors.Is(err, whatsmiau.ErrTemplateNotFound) {
+			return utils.HTTPFail(ctx, http.StatusNotFound, err, "template not found")
+		}
+		zap.L().Error("Whatsmiau.DeleteTemplate failed", zap.Error(err))
+		return utils.HTTPFail(ctx, http.StatusInternalServerError, err, "failed to delete template")
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
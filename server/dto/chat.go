@@ -16,6 +16,9 @@ type SendPresenceRequestPresence string
 const (
 	PresenceComposing SendPresenceRequestPresence = "composing"
 	PresenceAvailable SendPresenceRequestPresence = "available"
+	PresenceRecording SendPresenceRequestPresence = "recording"
+	PresencePaused    SendPresenceRequestPresence = "paused"
+	PresenceOffline   SendPresenceRequestPresence = "unavailable"
 )
 
 type SendPresenceRequestType string
@@ -37,6 +40,98 @@ type SendChatPresenceResponse struct {
 	Presence SendPresenceRequestPresence `json:"presence"`
 }
 
+type SendPresenceRequest struct {
+	InstanceID string                      `param:"instance" validate:"required"`
+	Presence   SendPresenceRequestPresence `json:"presence" validate:"required,oneof=available unavailable"`
+}
+
+type SendPresenceResponse struct {
+	Presence SendPresenceRequestPresence `json:"presence"`
+}
+
+type SubscribePresenceRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number" validate:"required"`
+}
+
 type NumberExistsRequest struct {
 	Numbers []string `json:"numbers"     validate:"required,min=1,dive,required"`
 }
+
+type OnWhatsAppRequest struct {
+	InstanceID string   `param:"instance" validate:"required"`
+	Numbers    []string `json:"numbers" validate:"required,min=1,dive,required"`
+}
+
+type ProfilePictureRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `query:"number" validate:"required"` // number, LID or group JID
+	Preview    bool   `query:"preview"`
+	ExistingID string `query:"existingId"` // id of a previous result, answers notChanged if it is still current
+}
+
+type GetMessagesRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `query:"number" validate:"required"` // number, LID or group JID
+	Limit      int    `query:"limit" validate:"omitempty,min=1,max=200"`
+	Before     string `query:"before"` // id of the last message of the previous page
+}
+
+type RequestHistoryRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number" validate:"required"` // number, LID or group JID
+	Count      int    `json:"count,omitempty" validate:"omitempty,min=1,max=50"`
+	Before     string `json:"before,omitempty"` // message id, defaults to the newest stored message of the chat
+}
+
+type SetBlockedRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number" validate:"required"`
+	Blocked    *bool  `json:"blocked" validate:"required"`
+}
+
+type BlocklistRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+}
+
+type BlocklistResponse struct {
+	Blocked []string `json:"blocked"`
+}
+
+type KeepMessageRequest struct {
+	InstanceID  string `param:"instance" validate:"required"`
+	RemoteJid   string `json:"remoteJid" validate:"required"`
+	ID          string `json:"id" validate:"required"`
+	FromMe      bool   `json:"fromMe"`
+	Participant string `json:"participant"` // required if group and not from me
+	Keep        bool   `json:"keep"`
+}
+
+type BusinessProfileRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `query:"number" validate:"required"`
+}
+
+type BusinessProfileResponse struct {
+	Jid           string                `json:"jid"`
+	Description   string                `json:"description,omitempty"`
+	Websites      []string              `json:"websites,omitempty"`
+	Address       string                `json:"address,omitempty"`
+	Email         string                `json:"email,omitempty"`
+	Categories    []BusinessCategory    `json:"categories"`
+	TimeZone      string                `json:"timeZone,omitempty"`
+	BusinessHours []BusinessHoursConfig `json:"businessHours,omitempty"`
+	Options       map[string]string     `json:"options,omitempty"`
+}
+
+type BusinessCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type BusinessHoursConfig struct {
+	DayOfWeek string `json:"dayOfWeek"`
+	Mode      string `json:"mode"`                // specific_hours, open_24h or appointment_only
+	OpenTime  string `json:"openTime,omitempty"`  // minutes since midnight
+	CloseTime string `json:"closeTime,omitempty"` // minutes since midnight
+}
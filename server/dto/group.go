@@ -0,0 +1,111 @@
+package dto
+
+type CreateGroupRequest struct {
+	InstanceID   string   `param:"instance" validate:"required"`
+	Subject      string   `json:"subject" validate:"required,max=25"`
+	Participants []string `json:"participants" validate:"required,min=1,dive,required"` // numbers or JIDs
+}
+
+type UpdateGroupParticipantsRequest struct {
+	InstanceID   string   `param:"instance" validate:"required"`
+	Group        string   `param:"group" validate:"required"`
+	Action       string   `json:"action" validate:"required,oneof=add remove promote demote"`
+	Participants []string `json:"participants" validate:"required,min=1,dive,required"`
+}
+
+type UpdateGroupParticipantsResponse struct {
+	Participants []GroupParticipantResponse `json:"participants"`
+}
+
+type LinkSubGroupRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"` // the community
+	SubGroup   string `json:"subGroup" validate:"required"`
+}
+
+type UnlinkSubGroupRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"` // the community
+	SubGroup   string `param:"subGroup" validate:"required"`
+}
+
+type SubGroupResponse struct {
+	ID                string `json:"id"`
+	Subject           string `json:"subject"`
+	IsDefaultSubGroup bool   `json:"isDefaultSubGroup"` // the community announcement group
+}
+
+type GroupResponse struct {
+	ID           string                     `json:"id"`
+	Subject      string                     `json:"subject"`
+	SubjectOwner string                     `json:"subjectOwner,omitempty"`
+	SubjectTime  int64                      `json:"subjectTime,omitempty"`
+	Desc         string                     `json:"desc,omitempty"`
+	Owner        string                     `json:"owner,omitempty"`
+	Creation     int64                      `json:"creation,omitempty"`
+	Size         int                        `json:"size"`
+	Restrict     bool                       `json:"restrict"` // only admins edit the group info
+	Announce     bool                       `json:"announce"` // only admins send messages
+	Participants []GroupParticipantResponse `json:"participants"`
+	IsCommunity  bool                       `json:"isCommunity,omitempty"`
+	Community    string                     `json:"community,omitempty"` // parent community of a linked group
+}
+
+type GroupParticipantResponse struct {
+	ID          string `json:"id"`
+	PhoneNumber string `json:"phoneNumber,omitempty"`
+	Admin       string `json:"admin,omitempty"` // admin or superadmin
+	Error       int    `json:"error,omitempty"` // code WhatsApp reported when the change failed, ex: 403 not allowed, 409 already there
+}
+
+type GroupRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"`
+}
+
+type UpdateGroupSubjectRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"`
+	Subject    string `json:"subject" validate:"required,max=25"`
+}
+
+type UpdateGroupDescriptionRequest struct {
+	InstanceID  string `param:"instance" validate:"required"`
+	Group       string `param:"group" validate:"required"`
+	Description string `json:"description" validate:"max=2048"` // empty removes it
+}
+
+type UpdateGroupPictureRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"`
+	Image      string `json:"image"` // URL or base64 (data URI allowed), empty removes the picture
+}
+
+type UpdateGroupPictureResponse struct {
+	PictureID string `json:"pictureId,omitempty"`
+}
+
+type GroupInviteLinkRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"`
+}
+
+type GroupInviteLinkResponse struct {
+	Link string `json:"link"`
+}
+
+type GroupLinkRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Link       string `json:"link" query:"link" validate:"required"` // chat.whatsapp.com link or bare invite code
+}
+
+type JoinGroupResponse struct {
+	ID string `json:"id"`
+}
+
+type UpdateGroupSettingRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Group      string `param:"group" validate:"required"`
+	Setting    string `json:"setting" validate:"required,oneof=announce locked"`
+	Enabled    *bool  `json:"enabled" validate:"required"`
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/verbeux-ai/whatsmiau/models"
+import (
+	"time"
+
+	"github.com/verbeux-ai/whatsmiau/models"
+)
 
 type CreateInstanceRequest struct {
 	ID               string `json:"id,omitempty" validate:"required_without=InstanceName"`
@@ -13,13 +17,35 @@ type CreateInstanceResponse struct {
 }
 
 type UpdateInstanceRequest struct {
-	ID      string `json:"id,omitempty" param:"id" validate:"required"`
-	Webhook struct {
-		Base64 bool   `json:"base64,omitempty"`
-		URL    string `json:"url,omitempty"`
+	ID                  string                       `json:"id,omitempty" param:"id" validate:"required"`
+	AlwaysOnline        *bool                        `json:"alwaysOnline,omitempty"`
+	EmitFromMe          *bool                        `json:"emitFromMe,omitempty"`
+	AutoMarkRead        *bool                        `json:"autoMarkRead,omitempty"`
+	AutoDownloadMedia   *bool                        `json:"autoDownloadMedia,omitempty"`
+	GenerateLinkPreview *bool                        `json:"generateLinkPreview,omitempty"`
+	SendRateLimit       *int                         `json:"sendRateLimit,omitempty" validate:"omitempty,min=0"`
+	Locale              string                       `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag"`
+	EphemeralExpiration *uint32                      `json:"ephemeralExpiration,omitempty" validate:"omitempty,oneof=0 86400 604800 7776000"` // seconds, 0 disables
+	GroupAutoJoin       *UpdateInstanceGroupAutoJoin `json:"groupAutoJoin,omitempty"`
+	IgnoreInbound       []string                     `json:"ignoreInbound,omitempty" validate:"omitempty,dive,oneof=status newsletter broadcast group presence receipt"`
+	ProxyPool           []string                     `json:"proxyPool,omitempty"` // replaces the pool, an empty list clears it. Applied on the next connect
+	Webhook             struct {
+		Base64   bool              `json:"base64,omitempty"`
+		URL      string            `json:"url,omitempty"`
+		Template *string           `json:"template,omitempty"` // an empty string removes the template
+		Format   string            `json:"format,omitempty" validate:"omitempty,oneof=evolution event"`
+		Secret   *string           `json:"secret,omitempty"` // an empty string removes the secret, falling back to WEBHOOK_SECRET
+		Raw      *bool             `json:"raw,omitempty"`
+		Routes   map[string]string `json:"routes,omitempty" validate:"omitempty,dive,keys,required,endkeys,http_url"`
+		Headers  map[string]string `json:"headers,omitempty" validate:"omitempty,dive,keys,required,endkeys"`
 	} `json:"webhook,omitempty"`
 }
 
+type UpdateInstanceGroupAutoJoin struct {
+	Inviters  []string `json:"inviters" validate:"dive,required"`
+	MaxGroups int      `json:"maxGroups,omitempty" validate:"min=0"`
+}
+
 type UpdateInstanceResponse struct {
 	*models.Instance
 }
@@ -47,6 +73,15 @@ type ConnectInstanceResponse struct {
 	*models.Instance
 }
 
+type PairPhoneRequest struct {
+	ID    string `param:"id" validate:"required"`
+	Phone string `json:"phone" validate:"required"` // E.164, ex: +5511999999999
+}
+
+type PairPhoneResponse struct {
+	Code string `json:"code"`
+}
+
 type StatusInstanceRequest struct {
 	ID string `param:"id" validate:"required"`
 }
@@ -62,6 +97,67 @@ type StatusInstanceResponseEvolutionCompatibility struct {
 	State        string `json:"state,omitempty"`
 }
 
+type DebugInstanceRequest struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type ClockSkewInstanceRequest struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type ClockSkewInstanceResponse struct {
+	ID          string    `json:"id"`
+	OffsetMs    int64     `json:"offsetMs"`
+	RoundTripMs int64     `json:"roundTripMs"`
+	SampledAt   time.Time `json:"sampledAt"`
+}
+
+type TestProxyRequest struct {
+	ProxyURL string `json:"proxyUrl" validate:"required,url"`
+}
+
+type TestProxyResponse struct {
+	Success bool                      `json:"success"`
+	Targets []TestProxyResponseTarget `json:"targets"`
+}
+
+type TestProxyResponseTarget struct {
+	Target    string `json:"target"`
+	Success   bool   `json:"success"`
+	Status    int    `json:"status,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+type GetPrivacySettingsRequest struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type SetPrivacySettingRequest struct {
+	ID    string `param:"id" validate:"required"`
+	Name  string `json:"name,omitempty" validate:"required,oneof=groupadd last status profile readreceipts online calladd"`
+	Value string `json:"value,omitempty" validate:"required"`
+}
+
+type SetProfileNameRequest struct {
+	ID   string `param:"id" validate:"required"`
+	Name string `json:"name" validate:"required,max=25"`
+}
+
+type SetProfileStatusRequest struct {
+	ID     string `param:"id" validate:"required"`
+	Status string `json:"status" validate:"max=139"`
+}
+
+type SetProfilePictureRequest struct {
+	ID    string `param:"id" validate:"required"`
+	Image string `json:"image" validate:"required"` // URL or base64 (data URI allowed)
+}
+
+type SetProfilePictureResponse struct {
+	PictureID string `json:"pictureId"`
+}
+
 type DeleteInstanceRequest struct {
 	ID string `param:"id" validate:"required"`
 }
@@ -77,3 +173,25 @@ type LogoutInstanceRequest struct {
 type LogoutInstanceResponse struct {
 	Message string `json:"message,omitempty"`
 }
+
+type ListStoredMediaRequest struct {
+	ID        string    `param:"id" validate:"required"`
+	From      time.Time `query:"from"` // RFC 3339
+	To        time.Time `query:"to"`
+	PageToken string    `query:"pageToken"`
+	Limit     int       `query:"limit" validate:"omitempty,min=1,max=1000"`
+}
+
+type DownloadStoredMediaRequest struct {
+	ID  string `param:"id" validate:"required"`
+	Key string `query:"key" validate:"required"`
+}
+
+type ReplayDeadLetterRequest struct {
+	ID  string `param:"id" validate:"required"`
+	Max int    `query:"max" validate:"omitempty,min=1,max=10000"` // default 100
+}
+
+type ReplayDeadLetterResponse struct {
+	Replayed int `json:"replayed"`
+}
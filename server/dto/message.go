@@ -6,9 +6,33 @@ type SendTextRequest struct {
 	Text             string                `json:"text,omitempty" validate:"required"`
 	Delay            int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
 	Quoted           *MessageRequestQuoted `json:"quoted,omitempty"`
-	LinkPreview      bool                  `json:"linkPreview,omitempty"`
+	LinkPreview      *bool                 `json:"linkPreview,omitempty"` // overrides the instance generateLinkPreview
 	MentionsEveryOne bool                  `json:"mentionsEveryOne,omitempty"`
 	Mentioned        []string              `json:"mentioned,omitempty"`
+	Expiration       int                   `json:"expiration,omitempty" validate:"omitempty,oneof=-1 86400 604800 7776000"` // seconds, -1 skips the instance default
+}
+
+type SendSequenceRequest struct {
+	InstanceID string                    `param:"instance" validate:"required"`
+	Number     string                    `json:"number,omitempty" validate:"required"`
+	Messages   []SendSequenceRequestItem `json:"messages" validate:"required,min=1,max=50,dive"`
+	Delay      int                       `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"` // ms between messages
+	Typing     bool                      `json:"typing,omitempty"`
+}
+
+type SendSequenceRequestItem struct {
+	Text string `json:"text" validate:"required"`
+}
+
+type SendSequenceResponse struct {
+	Results []SendSequenceResponseItem `json:"results"`
+}
+
+type SendSequenceResponseItem struct {
+	Key              *MessageResponseKey `json:"key,omitempty"`
+	Status           string              `json:"status"`
+	MessageTimestamp int                 `json:"messageTimestamp,omitempty"`
+	Error            string              `json:"error,omitempty"`
 }
 
 type MessageRequestQuoted struct {
@@ -17,7 +41,10 @@ type MessageRequestQuoted struct {
 }
 
 type QuotedKey struct {
-	Id string `json:"id,omitempty"`
+	Id          string `json:"id,omitempty"`
+	RemoteJid   string `json:"remoteJid,omitempty"` // only when quoting a message from another chat
+	FromMe      bool   `json:"fromMe,omitempty"`
+	Participant string `json:"participant,omitempty"` // required on groups when not from me
 }
 
 type QuotedMessage struct {
@@ -60,6 +87,7 @@ type SendAudioRequest struct {
 	InstanceID       string                `param:"instance"`
 	Number           string                `json:"number,omitempty"`
 	Audio            string                `json:"audio,omitempty"`
+	PTT              *bool                 `json:"ptt,omitempty"` // default true, false sends a regular audio
 	Delay            int                   `json:"delay,omitempty" validate:"omitempty,min=0,max=300000"`
 	Quoted           *MessageRequestQuoted `json:"quoted,omitempty"`
 	MentionsEveryOne bool                  `json:"mentionsEveryOne,omitempty"`
@@ -174,12 +202,14 @@ type SendDocumentResponseDataImage struct {
 
 type SendReactionRequest struct {
 	InstanceID string `param:"instance" validate:"required"`
-	Reaction   string `json:"reaction,omitempty" validate:"required,len=1"`
+	Reaction   string `json:"reaction,omitempty" validate:"omitempty,len=1"` // empty removes the reaction
 	Key        struct {
-		RemoteJid string `json:"remoteJid,omitempty" validate:"required"`
-		Id        string `json:"id,omitempty" validate:"required"`
-		FromMe    bool   `json:"fromMe,omitempty" validate:"required"`
+		RemoteJid   string `json:"remoteJid,omitempty" validate:"required"`
+		Id          string `json:"id,omitempty" validate:"required"`
+		FromMe      bool   `json:"fromMe,omitempty"`
+		Participant string `json:"participant,omitempty"` // required on groups and status when not from me
 	} `json:"key"`
+	ServerId int `json:"serverId,omitempty"` // required on newsletters
 }
 
 type SendReactionResponse struct {
@@ -191,3 +221,209 @@ type SendReactionResponse struct {
 	Source           string             `json:"source,omitempty"`
 	Status           string             `json:"status,omitempty"`
 }
+
+type SendButtonsRequest struct {
+	InstanceID string          `param:"instance" validate:"required"`
+	Number     string          `json:"number,omitempty" validate:"required"`
+	Text       string          `json:"text,omitempty" validate:"required"`
+	Buttons    []ButtonRequest `json:"buttons" validate:"required,min=1,max=3,dive"`
+}
+
+type ButtonRequest struct {
+	ID   string `json:"id" validate:"required,max=256"`
+	Text string `json:"text" validate:"required,max=20"`
+}
+
+type SendButtonsResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type SendListRequest struct {
+	InstanceID  string               `param:"instance" validate:"required"`
+	Number      string               `json:"number,omitempty" validate:"required"`
+	Title       string               `json:"title,omitempty" validate:"max=60"`
+	Description string               `json:"description,omitempty" validate:"required,max=1024"`
+	ButtonText  string               `json:"buttonText,omitempty" validate:"required,max=20"`
+	FooterText  string               `json:"footerText,omitempty" validate:"max=60"`
+	Sections    []ListSectionRequest `json:"sections" validate:"required,min=1,dive"`
+}
+
+type ListSectionRequest struct {
+	Title string           `json:"title,omitempty" validate:"max=24"`
+	Rows  []ListRowRequest `json:"rows" validate:"required,min=1,dive"`
+}
+
+type ListRowRequest struct {
+	RowID       string `json:"rowId" validate:"required,max=200"`
+	Title       string `json:"title" validate:"required,max=24"`
+	Description string `json:"description,omitempty" validate:"max=72"`
+}
+
+type SendListResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type SendPollRequest struct {
+	InstanceID      string   `param:"instance" validate:"required"`
+	Number          string   `json:"number,omitempty" validate:"required"`
+	Name            string   `json:"name,omitempty" validate:"required,max=255"`
+	SelectableCount int      `json:"selectableCount,omitempty"` // defaults to 1
+	Values          []string `json:"values" validate:"required,min=2,max=12,dive,required,max=100"`
+}
+
+type SendPollResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type SendLocationRequest struct {
+	InstanceID string   `param:"instance" validate:"required"`
+	Number     string   `json:"number,omitempty" validate:"required"`
+	Latitude   *float64 `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude  *float64 `json:"longitude" validate:"required,min=-180,max=180"`
+	Name       string   `json:"name,omitempty"`
+	Address    string   `json:"address,omitempty"`
+}
+
+type SendLocationResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type SendContactRequest struct {
+	InstanceID string           `param:"instance" validate:"required"`
+	Number     string           `json:"number,omitempty" validate:"required"`
+	Contact    []ContactRequest `json:"contact" validate:"required,min=1,dive"`
+}
+
+type ContactRequest struct {
+	FullName     string                `json:"fullName,omitempty"`
+	FirstName    string                `json:"firstName,omitempty"`
+	LastName     string                `json:"lastName,omitempty"`
+	Organization string                `json:"organization,omitempty"`
+	PhoneNumber  string                `json:"phoneNumber,omitempty"` // single cell phone, same as one entry in phones
+	Phones       []ContactPhoneRequest `json:"phones,omitempty" validate:"dive"`
+	Email        string                `json:"email,omitempty" validate:"omitempty,email"`
+}
+
+type ContactPhoneRequest struct {
+	Number string `json:"number" validate:"required"`
+	Type   string `json:"type,omitempty"` // CELL, WORK, HOME...
+}
+
+type SendContactResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type RequestLocationRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" validate:"required"`
+	Text       string `json:"text,omitempty" validate:"required"`
+}
+
+type RequestLocationResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type GetPollResultsRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	ID         string `param:"id" validate:"required"`
+	Number     string `query:"number" validate:"required"`
+}
+
+type GetMessageThumbnailRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	ID         string `param:"id" validate:"required"`
+}
+
+type DownloadMediaRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	ID         string `param:"id" validate:"required"`
+	Number     string `query:"number" validate:"required"` // chat of the message
+}
+
+type EditMessageRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" validate:"required"`
+	Key        struct {
+		Id string `json:"id,omitempty" validate:"required"`
+	} `json:"key"`
+	Text string `json:"text,omitempty" validate:"required"`
+}
+
+type ForwardMessageRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" validate:"required"` // destination
+	Key        struct {
+		RemoteJid string `json:"remoteJid,omitempty" validate:"required"` // chat of the original message
+		Id        string `json:"id,omitempty" validate:"required"`
+	} `json:"key"`
+}
+
+type ForwardMessageResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type DeleteMessageRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" validate:"required"`
+	Key        struct {
+		Id          string `json:"id,omitempty" validate:"required"`
+		FromMe      bool   `json:"fromMe,omitempty"`
+		Participant string `json:"participant,omitempty"`
+	} `json:"key"`
+	ForEveryone bool `json:"forEveryone,omitempty"`
+}
+
+type DeleteMessageResponse struct {
+	Key         MessageResponseKey `json:"key,omitempty"`
+	ForEveryone bool               `json:"forEveryone"`
+	InstanceId  string             `json:"instanceId,omitempty"`
+}
+
+type EditMediaCaptionRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Number     string `json:"number,omitempty" validate:"required"`
+	Key        struct {
+		Id string `json:"id,omitempty" validate:"required"`
+	} `json:"key"`
+	Caption string `json:"caption,omitempty" validate:"required"`
+}
+
+type EditMessageResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageType      string             `json:"messageType"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
+
+type FetchLinkPreviewRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Url        string `json:"url,omitempty" validate:"required,url"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+type NewsletterRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Newsletter string `param:"newsletter" validate:"required"` // JID or the bare id before @newsletter
+}
+
+type NewsletterResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InviteCode  string `json:"inviteCode,omitempty"`
+	Subscribers int    `json:"subscribers"`
+	Verified    bool   `json:"verified"`
+	State       string `json:"state"` // active, suspended or geosuspended
+	Picture     string `json:"picture,omitempty"`
+	Creation    int64  `json:"creation,omitempty"`
+	Role        string `json:"role,omitempty"` // subscriber, guest, admin or owner, empty when not following
+	Muted       bool   `json:"muted"`
+}
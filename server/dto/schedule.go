@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type ScheduleMessageRequest struct {
+	InstanceID  string    `param:"instance" validate:"required"`
+	Number      string    `json:"number,omitempty" validate:"required"`
+	Text        string    `json:"text" validate:"required"`
+	At          time.Time `json:"at" validate:"required"` // RFC 3339
+	LinkPreview *bool     `json:"linkPreview,omitempty"`  // overrides the instance generateLinkPreview
+}
+
+type ScheduleMessageResponse struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+}
+
+type CancelScheduledRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	ID         string `param:"id" validate:"required"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type SendStatusRequest struct {
+	InstanceID      string `param:"instance" validate:"required"`
+	Text            string `json:"text,omitempty" validate:"required_without=Media"` // caption of media statuses
+	Media           string `json:"media,omitempty"`                                  // image or video, URL or base64
+	Mimetype        string `json:"mimetype,omitempty"`
+	BackgroundColor string `json:"backgroundColor,omitempty"` // #RRGGBB or #AARRGGBB, text only
+	TextColor       string `json:"textColor,omitempty"`
+	Font            *int32 `json:"font,omitempty"`
+}
+
+type SendStatusResponse struct {
+	Key              MessageResponseKey `json:"key"`
+	Status           string             `json:"status"`
+	MessageTimestamp int                `json:"messageTimestamp"`
+	InstanceId       string             `json:"instanceId"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+type SaveTemplateRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Name       string `param:"name" validate:"required,max=128"`
+	Text       string `json:"text" validate:"required"`
+}
+
+type ListTemplatesRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+}
+
+type DeleteTemplateRequest struct {
+	InstanceID string `param:"instance" validate:"required"`
+	Name       string `param:"name" validate:"required"`
+}
+
+type SendTemplateRequest struct {
+	InstanceID string            `param:"instance" validate:"required"`
+	Number     string            `json:"number,omitempty" validate:"required"`
+	Template   string            `json:"template" validate:"required"`
+	Vars       map[string]string `json:"vars,omitempty"`
+}
@@ -7,9 +7,16 @@ import (
 	"github.com/verbeux-ai/whatsmiau/env"
 )
 
+// publicPaths are served without the api key, probes and scrapers can't send it
+var publicPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
 func Auth(ctx echo.Context, next echo.HandlerFunc) error {
 	gotApikey := ctx.Request().Header.Get("apikey")
-	if len(env.Env.ApiKey) == 0 {
+	if len(env.Env.ApiKey) == 0 || publicPaths[ctx.Request().URL.Path] {
 		return next(ctx)
 	}
 
@@ -13,7 +13,17 @@ func Chat(group *echo.Group) {
 	controller := controllers.NewChats(redisInstance, whatsmiau.Get())
 
 	group.POST("/presence", controller.SendChatPresence)
+	group.POST("/online", controller.SendPresence)
+	group.POST("/presence/subscribe", controller.SubscribePresence)
 	group.POST("/read-messages", controller.ReadMessages)
+	group.POST("/keep-message", controller.KeepMessage)
+	group.POST("/whatsapp-numbers", controller.OnWhatsApp)
+	group.GET("/profile-picture", controller.ProfilePicture)
+	group.GET("/messages", controller.GetMessages)
+	group.POST("/history", controller.RequestHistory)
+	group.GET("/business-profile", controller.BusinessProfile)
+	group.GET("/blocklist", controller.Blocklist)
+	group.POST("/block", controller.SetBlocked)
 }
 
 func ChatEVO(group *echo.Group) {
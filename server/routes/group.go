@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"github.com/verbeux-ai/whatsmiau/server/controllers"
+	"github.com/verbeux-ai/whatsmiau/services"
+)
+
+func Group(group *echo.Group) {
+	redisInstance := instances.NewRedis(services.Redis())
+	controller := controllers.NewGroups(redisInstance, whatsmiau.Get())
+
+	group.POST("", controller.Create)
+	group.GET("/invite", controller.InfoFromLink)
+	group.POST("/join", controller.Join)
+	group.GET("/:group", controller.Info)
+	group.GET("/:group/invite", controller.InviteLink)
+	group.POST("/:group/invite/revoke", controller.RevokeInviteLink)
+	group.PUT("/:group/subject", controller.UpdateSubject)
+	group.PUT("/:group/description", controller.UpdateDescription)
+	group.PUT("/:group/picture", controller.UpdatePicture)
+	group.PUT("/:group/settings", controller.UpdateSetting)
+	group.POST("/:group/participants", controller.UpdateParticipants)
+	group.POST("/:group/leave", controller.Leave)
+	group.GET("/:group/subgroups", controller.SubGroups)
+	group.POST("/:group/subgroups", controller.LinkSubGroup)
+	group.DELETE("/:group/subgroups/:subGroup", controller.UnlinkSubGroup)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/server/controllers"
+)
+
+func Health(app *echo.Echo) {
+	app.GET("/health", controllers.Live)
+	app.GET("/ready", controllers.Ready)
+}
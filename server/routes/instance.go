@@ -14,10 +14,25 @@ func Instance(group *echo.Group) {
 	controller := controllers.NewInstances(redisInstance, whatsmiau.Get())
 	group.POST("", controller.Create)
 	group.GET("", controller.List)
+	group.GET("/stats", controller.Stats)
+	group.GET("/status", controller.ListStatus)
+	group.POST("/proxy-test", controller.TestProxy)
 	group.POST("/:id/connect", controller.Connect)
+	group.POST("/:id/pair", controller.PairPhone)
 	group.POST("/:id/logout", controller.Logout)
+	group.POST("/:id/logout-all", controller.LogoutAllDevices)
 	group.DELETE("/:id", controller.Delete)
 	group.GET("/:id/status", controller.Status)
+	group.GET("/:id/debug", controller.Debug)
+	group.GET("/:id/clock-skew", controller.ClockSkew)
+	group.GET("/:id/privacy", controller.GetPrivacySettings)
+	group.PUT("/:id/privacy", controller.SetPrivacySetting)
+	group.PUT("/:id/profile/name", controller.SetProfileName)
+	group.PUT("/:id/profile/status", controller.SetProfileStatus)
+	group.PUT("/:id/profile/picture", controller.SetProfilePicture)
+	group.GET("/:id/media", controller.ListStoredMedia)
+	group.GET("/:id/media/download", controller.DownloadStoredMedia)
+	group.POST("/:id/dead-letters/replay", controller.ReplayDeadLetter)
 
 	// Evolution API Compatibility (partially REST)
 	group.POST("/create", controller.Create)
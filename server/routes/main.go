@@ -9,6 +9,9 @@ func Load(app *echo.Echo) {
 	app.Pre(middleware.Simplify(middleware.Auth))
 
 	V1(app.Group("/v1"))
+	Metrics(app)
+	Health(app)
+	Media(app)
 }
 
 func V1(group *echo.Group) {
@@ -16,6 +19,9 @@ func V1(group *echo.Group) {
 	Instance(group.Group("/instance"))
 	Message(group.Group("/instance/:instance/message"))
 	Chat(group.Group("/instance/:instance/chat"))
+	Template(group.Group("/instance/:instance/templates"))
+	Group(group.Group("/instance/:instance/groups"))
+	Newsletter(group.Group("/instance/:instance/newsletters"))
 
 	ChatEVO(group.Group("/chat"))
 	MessageEVO(group.Group("/message"))
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/env"
+)
+
+// Media serves the local storage files, behind the api key like every route
+func Media(app *echo.Echo) {
+	if !env.Env.LocalStorageEnabled || env.Env.GCSEnabled || env.Env.S3Enabled {
+		return
+	}
+
+	app.Static("/media", env.Env.LocalStoragePath)
+}
@@ -13,9 +13,29 @@ func Message(group *echo.Group) {
 	controller := controllers.NewMessages(redisInstance, whatsmiau.Get())
 
 	group.POST("/text", controller.SendText)
+	group.POST("/sequence", controller.SendSequence)
+	group.POST("/template", controller.SendTemplate)
+	group.POST("/schedule", controller.ScheduleMessage)
+	group.DELETE("/schedule/:id", controller.CancelScheduled)
 	group.POST("/audio", controller.SendAudio)
 	group.POST("/document", controller.SendDocument)
 	group.POST("/image", controller.SendImage)
+	group.POST("/media", controller.SendMedia)
+	group.POST("/status", controller.SendStatus)
+	group.POST("/link-preview", controller.FetchLinkPreview)
+	group.POST("/buttons", controller.SendButtons)
+	group.POST("/list", controller.SendList)
+	group.POST("/poll", controller.SendPoll)
+	group.POST("/location", controller.SendLocation)
+	group.POST("/contact", controller.SendContact)
+	group.POST("/location-request", controller.RequestLocation)
+	group.POST("/forward", controller.ForwardMessage)
+	group.POST("/edit", controller.EditMessage)
+	group.POST("/delete", controller.DeleteMessage)
+	group.POST("/edit-caption", controller.EditMediaCaption)
+	group.GET("/poll/:id/results", controller.GetPollResults)
+	group.GET("/:id/thumbnail", controller.GetMessageThumbnail)
+	group.GET("/:id/media", controller.DownloadMedia)
 }
 
 func MessageEVO(group *echo.Group) {
@@ -27,4 +47,9 @@ func MessageEVO(group *echo.Group) {
 	group.POST("/sendWhatsAppAudio/:instance", controller.SendAudio) // is always whatsapp 🤣
 	group.POST("/sendMedia/:instance", controller.SendMedia)
 	group.POST("/sendReaction/:instance", controller.SendReaction)
+	group.POST("/sendButtons/:instance", controller.SendButtons)
+	group.POST("/sendList/:instance", controller.SendList)
+	group.POST("/sendPoll/:instance", controller.SendPoll)
+	group.POST("/sendLocation/:instance", controller.SendLocation)
+	group.POST("/sendContact/:instance", controller.SendContact)
 }
@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/metrics"
+)
+
+func Metrics(app *echo.Echo) {
+	app.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/repositories/instances"
+	"github.com/verbeux-ai/whatsmiau/server/controllers"
+	"github.com/verbeux-ai/whatsmiau/services"
+)
+
+func Newsletter(group *echo.Group) {
+	redisInstance := instances.NewRedis(services.Redis())
+	controller := controllers.NewNewsletters(redisInstance, whatsmiau.Get())
+
+	group.GET("/:newsletter", controller.Info)
+	group.POST("/:newsletter/follow", controller.Follow)
+	group.POST("/:newsletter/unfollow", controller.Unfollow)
+}
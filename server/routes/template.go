@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/verbeux-ai/whatsmiau/lib/whatsmiau"
+	"github.com/verbeux-ai/whatsmiau/server/controllers"
+)
+
+func Template(group *echo.Group) {
+	controller := controllers.NewTemplates(whatsmiau.Get())
+
+	group.GET("", controller.List)
+	group.PUT("/:name", controller.Save)
+	group.DELETE("/:name", controller.Delete)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -18,8 +19,21 @@ func SQLStore() *sqlstore.Container {
 	defer c()
 
 	if sqlStoreInstance == nil {
-		container, err := sqlstore.New(ctx, env.Env.DBDialect, env.Env.DBURL, nil)
+		db, err := openSQLStoreDB()
 		if err != nil {
+			zap.L().Panic("failed to open sqlstore database", zap.Error(err))
+		}
+
+		// zero values keep the database/sql defaults
+		db.SetMaxOpenConns(env.Env.DBMaxOpenConns)
+		if env.Env.DBMaxIdleConns > 0 {
+			db.SetMaxIdleConns(env.Env.DBMaxIdleConns)
+		}
+		db.SetConnMaxLifetime(env.Env.DBConnMaxLifetime)
+		db.SetConnMaxIdleTime(env.Env.DBConnMaxIdleTime)
+
+		container := sqlstore.NewWithDB(db, env.Env.DBDialect, nil)
+		if err := container.Upgrade(ctx); err != nil {
 			zap.L().Panic("failed to start sqlstore", zap.Error(err))
 		}
 
@@ -28,3 +42,11 @@ func SQLStore() *sqlstore.Container {
 
 	return sqlStoreInstance
 }
+
+func openSQLStoreDB() (*sql.DB, error) {
+	if env.Env.DBStmtCacheSize > 0 {
+		return openStmtCacheDB(env.Env.DBDialect, env.Env.DBURL, env.Env.DBStmtCacheSize)
+	}
+
+	return sql.Open(env.Env.DBDialect, env.Env.DBURL)
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+// openStmtCacheDB opens the database through a connector that keeps up to size
+// prepared statements per connection. The device store runs the same few
+// queries for every message, so after warming up they skip the parse and plan
+// step. Only queries with arguments are cached, the schema upgrades run several
+// statements per Exec and must not be prepared.
+func openStmtCacheDB(dialect, dsn string, size int) (*sql.DB, error) {
+	probe, err := sql.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	_ = probe.Close()
+
+	var connector driver.Connector = dsnConnector{driver: drv, dsn: dsn}
+	if withContext, ok := drv.(driver.DriverContext); ok {
+		if connector, err = withContext.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(&stmtCacheConnector{Connector: connector, size: size}), nil
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type stmtCacheConnector struct {
+	driver.Connector
+	size int
+}
+
+func (c *stmtCacheConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmtCacheConn{Conn: conn, size: c.size, stmts: make(map[string]*cachedStmt)}, nil
+}
+
+type cachedStmt struct {
+	driver.Stmt
+	inUse bool // rows still open, a nested query with the same sql prepares its own
+}
+
+// stmtCacheConn is used by one goroutine at a time, as every driver.Conn, so the
+// cache needs no locking
+type stmtCacheConn struct {
+	driver.Conn
+	size  int
+	stmts map[string]*cachedStmt
+	order []string // insertion order, the oldest statement is evicted first
+}
+
+func (c *stmtCacheConn) cached(ctx context.Context, query string) (*cachedStmt, error) {
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	prepared, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(c.order) >= c.size {
+		if !c.evict() {
+			break // every cached statement has open rows, grow past the limit
+		}
+	}
+
+	stmt := &cachedStmt{Stmt: prepared}
+	c.stmts[query] = stmt
+	c.order = append(c.order, query)
+	return stmt, nil
+}
+
+// evict closes the oldest statement not in use, false when all are in use
+func (c *stmtCacheConn) evict() bool {
+	for i, query := range c.order {
+		stmt := c.stmts[query]
+		if stmt.inUse {
+			continue
+		}
+
+		_ = stmt.Close()
+		delete(c.stmts, query)
+		c.order = append(c.order[:i], c.order[i+1:]...)
+		return true
+	}
+
+	return false
+}
+
+func (c *stmtCacheConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if len(args) == 0 {
+		return c.execDirect(ctx, query, args)
+	}
+
+	stmt, err := c.cached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.inUse {
+		return c.execDirect(ctx, query, args)
+	}
+
+	execer, ok := stmt.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return c.execDirect(ctx, query, args)
+	}
+
+	return execer.ExecContext(ctx, args)
+}
+
+func (c *stmtCacheConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) == 0 {
+		return c.queryDirect(ctx, query, args)
+	}
+
+	stmt, err := c.cached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.inUse {
+		return c.queryDirect(ctx, query, args)
+	}
+
+	queryer, ok := stmt.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return c.queryDirect(ctx, query, args)
+	}
+
+	rows, err := queryer.QueryContext(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.inUse = true
+	return &cachedRows{Rows: rows, stmt: stmt}, nil
+}
+
+func (c *stmtCacheConn) execDirect(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *stmtCacheConn) queryDirect(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *stmtCacheConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Prepare(query)
+}
+
+func (c *stmtCacheConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("driver does not support transaction options")
+	}
+	return c.Begin()
+}
+
+func (c *stmtCacheConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (c *stmtCacheConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *stmtCacheConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *stmtCacheConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *stmtCacheConn) Close() error {
+	for _, stmt := range c.stmts {
+		_ = stmt.Close()
+	}
+	c.stmts, c.order = nil, nil
+	return c.Conn.Close()
+}
+
+type cachedRows struct {
+	driver.Rows
+	stmt *cachedStmt
+}
+
+// Close releases the statement for the next query instead of closing it
+func (r *cachedRows) Close() error {
+	r.stmt.inUse = false
+	return r.Rows.Close()
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestStmtCacheDB(t *testing.T) {
+	db, err := openStmtCacheDB("sqlite3", "file:stmtcache?mode=memory&_foreign_keys=on", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // every connection of an in-memory database is a new database
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT); CREATE TABLE other (id INTEGER)"); err != nil {
+		t.Fatalf("multi statement exec: %v", err)
+	}
+	var tables int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil || tables != 2 {
+		t.Fatalf("tables = %d, %v, want both created", tables, err)
+	}
+
+	for i, name := range []string{"a", "b", "c"} {
+		if _, err := db.ExecContext(ctx, "INSERT INTO items (id, name) VALUES ($1, $2)", i, name); err != nil {
+			t.Fatalf("insert %s: %v", name, err)
+		}
+	}
+
+	// one connection: the same statement queried again while its rows are still open
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	const query = "SELECT name FROM items WHERE id >= $1 ORDER BY id"
+	rows, err := tx.QueryContext(ctx, query, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+
+		var first string
+		if err := tx.QueryRowContext(ctx, query, 1).Scan(&first); err != nil || first != "b" {
+			t.Fatalf("nested first = %q, %v, want b", first, err)
+		}
+	}
+	rows.Close()
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Fatalf("names = %v, want [a b c]", names)
+	}
+
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM items WHERE id = $1", 1).Scan(&name); err != nil || name != "b" {
+		t.Fatalf("name = %q, %v, want b", name, err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Raw(func(driverConn any) error {
+		cache := driverConn.(*stmtCacheConn)
+		if len(cache.stmts) > 2 || len(cache.order) != len(cache.stmts) {
+			t.Errorf("cache holds %d statements (%d ordered), want at most 2", len(cache.stmts), len(cache.order))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStmtCacheDBDeviceStore(t *testing.T) {
+	db, err := openStmtCacheDB("sqlite3", "file:stmtcachestore?mode=memory&_foreign_keys=on", 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	container := sqlstore.NewWithDB(db, "sqlite3", nil)
+	if err := container.Upgrade(ctx); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+
+	for range 3 {
+		device, err := container.GetDevice(ctx, types.NewJID("5511900000000", types.DefaultUserServer))
+		if err != nil || device != nil {
+			t.Fatalf("device = %v, %v, want none", device, err)
+		}
+	}
+}